@@ -0,0 +1,225 @@
+package fluent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultPollInterval — пауза перед первым опросом статуса длительной операции, дальше она удваивается.
+	DefaultPollInterval = time.Second
+	// DefaultMaxPollInterval — максимальная пауза между опросами статуса.
+	DefaultMaxPollInterval = 30 * time.Second
+)
+
+var (
+	// ErrNoStatusURL возвращается Accepted, если в ответе 202 нет ни Operation-Location, ни Location,
+	// а также Tracker для задачи без адреса статуса.
+	ErrNoStatusURL = errors.New("no status URL in 202 Accepted response")
+	// ErrOperationFailed возвращается Accepted, если длительная операция завершилась статусом Failed или Canceled.
+	ErrOperationFailed = errors.New("operation failed")
+)
+
+// PollOptions настраивает опрос статуса длительной операции в Accepted.
+type PollOptions struct {
+	// Interval — пауза перед первым опросом, дальше она удваивается. По умолчанию DefaultPollInterval.
+	Interval time.Duration
+	// MaxInterval — верхняя граница паузы. По умолчанию DefaultMaxPollInterval.
+	MaxInterval time.Duration
+	// Timeout ограничивает общее время опроса. 0 — без ограничения, кроме ctx.
+	Timeout time.Duration
+}
+
+// delay возвращает паузу перед опросом attempt (начиная с 1). Если сервер прислал Retry-After,
+// пауза равна указанной.
+func (o PollOptions) delay(attempt int, h http.Header) time.Duration {
+	if until, ok := retryAfter(h.Get("Retry-After")); ok {
+		return time.Until(until)
+	}
+
+	lo, hi := o.Interval, o.MaxInterval
+	if lo <= 0 {
+		lo = DefaultPollInterval
+	}
+
+	if hi <= 0 {
+		hi = DefaultMaxPollInterval
+	}
+
+	if shift := attempt - 1; shift < 32 && lo<<shift < hi { //nolint:mnd
+		return lo << shift
+	}
+
+	return hi
+}
+
+// operationStatus — тело монитора статуса длительной операции в стиле Azure.
+type operationStatus struct {
+	Status           string `json:"status"`
+	ResourceLocation string `json:"resourceLocation"`
+	Error            *struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Accepted доводит до результата длительную операцию, которую сервер принял ответом 202 Accepted,
+// и декодирует результат в T, так же как Into. Ответы не 202 декодируются сразу.
+//
+// Если ответ содержит Operation-Location (или Azure-AsyncOperation), опрашивается монитор статуса:
+// JSON с полем status, пока оно не станет Succeeded, Failed или Canceled. При успехе результат берется
+// по ссылке resourceLocation, а без нее — из тела монитора; Failed и Canceled возвращают ErrOperationFailed
+// с сообщением из поля error. Иначе опрашивается Location: пока сервер отвечает 202, операция идет,
+// а первый другой успешный ответ считается результатом.
+//
+// Паузы между опросами растут от opts.Interval до opts.MaxInterval, а Retry-After сервера имеет приоритет.
+// Опросы выполняются через c.Request(), поэтому заголовки и авторизация клиента сохраняются:
+//
+//	resp := c.Request().Body(job).Post(ctx, "/exports")
+//	export, err := fluent.Accepted[Export](ctx, c, resp, fluent.PollOptions{Timeout: 10 * time.Minute})
+func Accepted[T any](ctx context.Context, c *Client, resp *Response, opts PollOptions) (T, error) {
+	var zero T
+
+	if err := resp.Error(); err != nil {
+		return zero, err
+	}
+
+	if resp.resp.StatusCode != http.StatusAccepted {
+		return intoResult[T](resp)
+	}
+
+	_, _ = io.Copy(io.Discard, resp.resp.Body)
+	resp.resp.Body.Close()
+
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	if monitor := operationLocation(resp.resp.Header); monitor != "" {
+		return pollMonitor[T](ctx, c, resp.resolve(monitor), resp.resp.Header, opts)
+	}
+
+	location := resp.resp.Header.Get("Location")
+	if location == "" {
+		return zero, ErrNoStatusURL
+	}
+
+	return pollLocation[T](ctx, c, resp.resolve(location), resp.resp.Header, opts)
+}
+
+// pollMonitor опрашивает монитор статуса url, пока операция не завершится.
+func pollMonitor[T any](ctx context.Context, c *Client, url string, h http.Header, opts PollOptions) (T, error) {
+	var zero T
+
+	for attempt := 1; ; attempt++ {
+		if err := sleepCtx(ctx, opts.delay(attempt, h)); err != nil {
+			return zero, err
+		}
+
+		resp := c.Request().Get(ctx, url)
+
+		body, err := resp.Raw()
+		if err != nil {
+			return zero, err
+		}
+
+		var status operationStatus
+		if err := json.Unmarshal(body, &status); err != nil {
+			return zero, fmt.Errorf("operation status %s: %w", url, err)
+		}
+
+		switch strings.ToLower(status.Status) {
+		case "succeeded":
+			if status.ResourceLocation != "" {
+				return intoResult[T](c.Request().Get(ctx, resp.resolve(status.ResourceLocation)))
+			}
+
+			var res T
+			err := json.Unmarshal(body, &res)
+
+			return res, err
+		case "failed", "canceled", "cancelled":
+			if status.Error != nil {
+				return zero, fmt.Errorf("%w: %s: %s: %s", ErrOperationFailed, status.Status, status.Error.Code, status.Error.Message)
+			}
+
+			return zero, fmt.Errorf("%w: %s", ErrOperationFailed, status.Status)
+		}
+
+		h = resp.resp.Header
+	}
+}
+
+// pollLocation опрашивает url, пока сервер отвечает 202 Accepted.
+func pollLocation[T any](ctx context.Context, c *Client, url string, h http.Header, opts PollOptions) (T, error) {
+	var zero T
+
+	for attempt := 1; ; attempt++ {
+		if err := sleepCtx(ctx, opts.delay(attempt, h)); err != nil {
+			return zero, err
+		}
+
+		resp := c.Request().Get(ctx, url)
+		if err := resp.Error(); err != nil {
+			return zero, err
+		}
+
+		if resp.resp.StatusCode != http.StatusAccepted {
+			return intoResult[T](resp)
+		}
+
+		_, _ = io.Copy(io.Discard, resp.resp.Body)
+		resp.resp.Body.Close()
+
+		h = resp.resp.Header
+		if next := h.Get("Location"); next != "" {
+			url = resp.resolve(next)
+		}
+	}
+}
+
+// intoResult декодирует результат операции; у ответов без тела он нулевой.
+func intoResult[T any](resp *Response) (T, error) {
+	if resp.NoContent() && resp.Error() == nil {
+		var zero T
+
+		return zero, nil
+	}
+
+	return Into[T](resp)
+}
+
+// operationLocation возвращает адрес монитора статуса из заголовков ответа 202.
+func operationLocation(h http.Header) string {
+	if v := h.Get("Operation-Location"); v != "" {
+		return v
+	}
+
+	return h.Get("Azure-AsyncOperation")
+}
+
+// sleepCtx ждет d или отмены ctx.
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
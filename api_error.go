@@ -0,0 +1,51 @@
+package fluent
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// ErrorType задает тип ошибки API, в который декодируется JSON-тело ответов не 2xx, например
+// ErrorType(&APIError{}), где *APIError реализует error. Декодированная ошибка сохраняется
+// в HTTPError.API и доступна через errors.As:
+//
+//	var apiErr *APIError
+//	if errors.As(err, &apiErr) { ... }
+//
+// Если тело не удалось декодировать, HTTPError.API остается nil, а тело доступно в HTTPError.Body.
+func (c *Client) ErrorType(prototype error) *Client {
+	c.errorType = nil
+	if prototype != nil {
+		c.errorType = reflect.TypeOf(prototype)
+	}
+
+	return c
+}
+
+// withAPIError декодирует тело e в ProblemDetails и в тип из ErrorType и возвращает e.
+func (c *Client) withAPIError(e *HTTPError) *HTTPError {
+	e.Problem = parseProblem(e.Header, e.Body)
+
+	if c.errorType == nil || len(e.Body) == 0 {
+		return e
+	}
+
+	var target reflect.Value
+	if c.errorType.Kind() == reflect.Pointer {
+		target = reflect.New(c.errorType.Elem())
+	} else {
+		target = reflect.New(c.errorType)
+	}
+
+	if err := json.Unmarshal(e.Body, target.Interface()); err != nil {
+		return e
+	}
+
+	if c.errorType.Kind() != reflect.Pointer {
+		target = target.Elem()
+	}
+
+	e.API, _ = target.Interface().(error)
+
+	return e
+}
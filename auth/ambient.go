@@ -0,0 +1,323 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/devem-tech/fluent"
+)
+
+// ErrNoCredentials возвращается, если в окружении не нашлось учетных данных.
+var ErrNoCredentials = errors.New("no ambient credentials")
+
+const (
+	// DefaultAWSMetadataURL — адрес сервиса метаданных инстанса EC2 (IMDS).
+	DefaultAWSMetadataURL = "http://169.254.169.254"
+	// DefaultGCPMetadataURL — адрес сервера метаданных GCE и GKE.
+	DefaultGCPMetadataURL = "http://metadata.google.internal"
+
+	// ecsCredentialsHost — адрес эндпоинта учетных данных контейнера ECS для относительного URI.
+	ecsCredentialsHost = "http://169.254.170.2"
+	// metadataTimeout ограничивает запросы к метаданным: вне облака адрес не отвечает,
+	// и без ограничения обнаружение ждало бы таймаута соединения.
+	metadataTimeout = 2 * time.Second
+	// imdsTokenTTL — срок сессионного токена IMDSv2 в секундах.
+	imdsTokenTTL = "21600"
+)
+
+// AWSAmbient находит учетные данные AWS в окружении процесса, чтобы один и тот же бинарник работал
+// локально, в ECS и на EC2 без передачи ключей. Источники проверяются по порядку:
+//
+//  1. переменные окружения AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY и AWS_SESSION_TOKEN;
+//  2. эндпоинт учетных данных контейнера ECS или EKS (AWS_CONTAINER_CREDENTIALS_RELATIVE_URI
+//     или AWS_CONTAINER_CREDENTIALS_FULL_URI с токеном из AWS_CONTAINER_AUTHORIZATION_TOKEN);
+//  3. роль инстанса EC2 через IMDSv2.
+//
+// Временные ключи кэшируются до истечения Expiration. AWSAmbient реализует fluent.AWSCredentialsProvider:
+//
+//	c := fluent.New().SignAWSv4Provider(&auth.AWSAmbient{}, "eu-central-1", "execute-api")
+//
+// AWSAmbient безопасен для конкурентного использования и может разделяться между клиентами.
+type AWSAmbient struct {
+	// MetadataURL — адрес IMDS. По умолчанию DefaultAWSMetadataURL.
+	MetadataURL string
+	// HTTPClient — клиент для запросов к метаданным. По умолчанию http.DefaultClient.
+	HTTPClient *http.Client
+
+	mu     sync.Mutex
+	creds  fluent.AWSCredentials
+	expiry time.Time
+}
+
+// Retrieve возвращает учетные данные из первого найденного источника.
+func (a *AWSAmbient) Retrieve(ctx context.Context) (fluent.AWSCredentials, error) {
+	if id, secret := os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY"); id != "" && secret != "" {
+		return fluent.AWSCredentials{AccessKeyID: id, SecretAccessKey: secret, SessionToken: os.Getenv("AWS_SESSION_TOKEN")}, nil
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.creds.AccessKeyID != "" && (a.expiry.IsZero() || time.Now().Before(a.expiry)) {
+		return a.creds, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, metadataTimeout)
+	defer cancel()
+
+	var (
+		creds awsRoleCredentials
+		err   error
+	)
+
+	if uri, header := containerEndpoint(); uri != "" {
+		creds, err = a.container(ctx, uri, header)
+	} else {
+		creds, err = a.instance(ctx)
+	}
+
+	if err != nil {
+		return fluent.AWSCredentials{}, err
+	}
+
+	a.creds = fluent.AWSCredentials{
+		AccessKeyID:     creds.AccessKeyID,
+		SecretAccessKey: creds.SecretAccessKey,
+		SessionToken:    creds.Token,
+	}
+	a.expiry = time.Time{}
+
+	if !creds.Expiration.IsZero() {
+		a.expiry = creds.Expiration.Add(-expiryDelta)
+	}
+
+	return a.creds, nil
+}
+
+// awsRoleCredentials — ответ эндпоинтов учетных данных ECS и IMDS.
+type awsRoleCredentials struct {
+	AccessKeyID     string    `json:"AccessKeyId"`
+	SecretAccessKey string    `json:"SecretAccessKey"`
+	Token           string    `json:"Token"`
+	Expiration      time.Time `json:"Expiration"`
+}
+
+// containerEndpoint возвращает адрес эндпоинта учетных данных контейнера и значение заголовка Authorization
+// для него или пустую строку, если процесс запущен не в контейнере ECS или EKS.
+func containerEndpoint() (string, string) {
+	if uri := os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"); uri != "" {
+		return ecsCredentialsHost + uri, ""
+	}
+
+	if uri := os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI"); uri != "" {
+		token := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN")
+		if path := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE"); path != "" {
+			if data, err := os.ReadFile(path); err == nil {
+				token = strings.TrimSpace(string(data))
+			}
+		}
+
+		return uri, token
+	}
+
+	return "", ""
+}
+
+// container запрашивает учетные данные задачи ECS или пода EKS.
+func (a *AWSAmbient) container(ctx context.Context, uri, authorization string) (awsRoleCredentials, error) {
+	header := make(http.Header)
+	if authorization != "" {
+		header.Set("Authorization", authorization)
+	}
+
+	body, err := metadata(ctx, a.HTTPClient, http.MethodGet, uri, header)
+	if err != nil {
+		return awsRoleCredentials{}, err
+	}
+
+	return decodeRoleCredentials(body)
+}
+
+// instance запрашивает учетные данные роли инстанса EC2 через IMDSv2: сессионный токен, имя роли
+// и ключи роли.
+func (a *AWSAmbient) instance(ctx context.Context) (awsRoleCredentials, error) {
+	base := a.MetadataURL
+	if base == "" {
+		base = DefaultAWSMetadataURL
+	}
+
+	token, err := metadata(ctx, a.HTTPClient, http.MethodPut, base+"/latest/api/token",
+		http.Header{"X-Aws-Ec2-Metadata-Token-Ttl-Seconds": {imdsTokenTTL}})
+	if err != nil {
+		return awsRoleCredentials{}, err
+	}
+
+	header := http.Header{"X-Aws-Ec2-Metadata-Token": {string(token)}}
+
+	roles, err := metadata(ctx, a.HTTPClient, http.MethodGet, base+"/latest/meta-data/iam/security-credentials/", header)
+	if err != nil {
+		return awsRoleCredentials{}, err
+	}
+
+	role, _, _ := strings.Cut(strings.TrimSpace(string(roles)), "\n")
+	if role == "" {
+		return awsRoleCredentials{}, fmt.Errorf("%w: no IAM role attached to the instance", ErrNoCredentials)
+	}
+
+	body, err := metadata(ctx, a.HTTPClient, http.MethodGet,
+		base+"/latest/meta-data/iam/security-credentials/"+url.PathEscape(role), header)
+	if err != nil {
+		return awsRoleCredentials{}, err
+	}
+
+	return decodeRoleCredentials(body)
+}
+
+func decodeRoleCredentials(body []byte) (awsRoleCredentials, error) {
+	var creds awsRoleCredentials
+
+	if err := json.Unmarshal(body, &creds); err != nil {
+		return awsRoleCredentials{}, fmt.Errorf("%w: %w", ErrNoCredentials, err)
+	}
+
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return awsRoleCredentials{}, fmt.Errorf("%w: empty AccessKeyId or SecretAccessKey", ErrNoCredentials)
+	}
+
+	return creds, nil
+}
+
+// GCPMetadata получает access token сервисного аккаунта из сервера метаданных GCE или GKE (Workload Identity),
+// кэширует его до истечения expires_in и подставляет в запросы как Bearer, так же как ClientCredentials:
+//
+//	c := fluent.New().Use((&auth.GCPMetadata{}).Middleware())
+//
+// Адрес сервера берется из переменной окружения GCE_METADATA_HOST, если она задана.
+// GCPMetadata безопасен для конкурентного использования и может разделяться между клиентами.
+type GCPMetadata struct {
+	// MetadataURL — адрес сервера метаданных. По умолчанию GCE_METADATA_HOST или DefaultGCPMetadataURL.
+	MetadataURL string
+	// ServiceAccount — сервисный аккаунт. По умолчанию "default".
+	ServiceAccount string
+	// Scopes — запрашиваемые области доступа. По умолчанию — области, выданные инстансу.
+	Scopes []string
+	// HTTPClient — клиент для запросов к метаданным. По умолчанию http.DefaultClient.
+	HTTPClient *http.Client
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+// Token возвращает кэшированный access token или получает новый, если кэшированный истек.
+func (g *GCPMetadata) Token(ctx context.Context) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.token != "" && time.Now().Before(g.expiry) {
+		return g.token, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, metadataTimeout)
+	defer cancel()
+
+	body, err := metadata(ctx, g.HTTPClient, http.MethodGet, g.tokenURL(), http.Header{"Metadata-Flavor": {"Google"}})
+	if err != nil {
+		return "", err
+	}
+
+	var tok struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+
+	if err := json.Unmarshal(body, &tok); err != nil {
+		return "", fmt.Errorf("%w: %w", ErrToken, err)
+	}
+
+	if tok.AccessToken == "" {
+		return "", fmt.Errorf("%w: empty access_token", ErrToken)
+	}
+
+	g.token = tok.AccessToken
+	g.expiry = time.Now().Add(time.Duration(tok.ExpiresIn)*time.Second - expiryDelta)
+
+	return g.token, nil
+}
+
+// Middleware возвращает middleware, который добавляет заголовок Authorization: Bearer <token>.
+func (g *GCPMetadata) Middleware() fluent.Middleware {
+	return func(next fluent.RoundFunc) fluent.RoundFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			token, err := g.Token(req.Context())
+			if err != nil {
+				return nil, err
+			}
+
+			return next(authorize(req, token))
+		}
+	}
+}
+
+func (g *GCPMetadata) tokenURL() string {
+	base := g.MetadataURL
+	if base == "" {
+		base = DefaultGCPMetadataURL
+		if host := os.Getenv("GCE_METADATA_HOST"); host != "" {
+			base = "http://" + host
+		}
+	}
+
+	account := g.ServiceAccount
+	if account == "" {
+		account = "default"
+	}
+
+	u := base + "/computeMetadata/v1/instance/service-accounts/" + url.PathEscape(account) + "/token"
+	if len(g.Scopes) != 0 {
+		u += "?" + url.Values{"scopes": {strings.Join(g.Scopes, ",")}}.Encode()
+	}
+
+	return u
+}
+
+// metadata выполняет запрос к сервису метаданных и возвращает тело ответа 200 OK. Недоступность сервиса
+// означает, что процесс запущен вне облака, и возвращается ErrNoCredentials.
+func metadata(ctx context.Context, client *http.Client, method, uri string, header http.Header) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, uri, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header = header
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrNoCredentials, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: %s %s: %s", ErrNoCredentials, method, uri, resp.Status)
+	}
+
+	return body, nil
+}
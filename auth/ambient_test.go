@@ -0,0 +1,151 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/devem-tech/fluent"
+	"github.com/devem-tech/fluent/auth"
+)
+
+// clearAWSEnv убирает учетные данные AWS из окружения теста, чтобы AWSAmbient дошел до метаданных.
+func clearAWSEnv(t *testing.T) {
+	t.Helper()
+
+	for _, name := range []string{
+		"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN",
+		"AWS_CONTAINER_CREDENTIALS_RELATIVE_URI", "AWS_CONTAINER_CREDENTIALS_FULL_URI",
+		"AWS_CONTAINER_AUTHORIZATION_TOKEN", "AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE",
+	} {
+		t.Setenv(name, "")
+	}
+}
+
+func TestAWSAmbient_Instance(t *testing.T) {
+	clearAWSEnv(t)
+
+	var fetched atomic.Int32
+
+	imds := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPut && r.URL.Path == "/latest/api/token":
+			_, _ = w.Write([]byte("session"))
+		case r.Header.Get("X-Aws-Ec2-Metadata-Token") != "session":
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.URL.Path == "/latest/meta-data/iam/security-credentials/":
+			_, _ = w.Write([]byte("app-role\n"))
+		case r.URL.Path == "/latest/meta-data/iam/security-credentials/app-role":
+			fetched.Add(1)
+			_, _ = w.Write([]byte(`{"Code":"Success","AccessKeyId":"AKIDROLE","SecretAccessKey":"secret",` +
+				`"Token":"session-token","Expiration":"` + time.Now().Add(time.Hour).UTC().Format(time.RFC3339) + `"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(imds.Close)
+
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Header.Get("Authorization") + " " + r.Header.Get("X-Amz-Security-Token")))
+	}))
+	t.Cleanup(api.Close)
+
+	c := fluent.New().BaseURL(api.URL).SignAWSv4Provider(&auth.AWSAmbient{MetadataURL: imds.URL}, "eu-central-1", "execute-api")
+
+	for range 2 {
+		got, err := c.Get(context.Background(), "/").Raw()
+		if err != nil {
+			t.Fatalf("Get returned error: %v", err)
+		}
+
+		if !strings.HasPrefix(string(got), "AWS4-HMAC-SHA256 Credential=AKIDROLE/") ||
+			!strings.HasSuffix(string(got), " session-token") {
+			t.Fatalf("unexpected signature: %q", got)
+		}
+	}
+
+	if fetched.Load() != 1 {
+		t.Fatalf("expected role credentials to be cached, fetched %d times", fetched.Load())
+	}
+}
+
+func TestAWSAmbient_Sources(t *testing.T) {
+	clearAWSEnv(t)
+
+	ecs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "pod-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"AccessKeyId":"AKIDTASK","SecretAccessKey":"secret","Token":"t"}`))
+	}))
+	t.Cleanup(ecs.Close)
+
+	t.Setenv("AWS_CONTAINER_CREDENTIALS_FULL_URI", ecs.URL+"/creds")
+	t.Setenv("AWS_CONTAINER_AUTHORIZATION_TOKEN", "pod-token")
+
+	creds, err := (&auth.AWSAmbient{}).Retrieve(context.Background())
+	if err != nil || creds.AccessKeyID != "AKIDTASK" || creds.SessionToken != "t" {
+		t.Fatalf("expected container credentials, got %+v: %v", creds, err)
+	}
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDENV")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+
+	if creds, err := (&auth.AWSAmbient{}).Retrieve(context.Background()); err != nil || creds.AccessKeyID != "AKIDENV" {
+		t.Fatalf("expected environment credentials first, got %+v: %v", creds, err)
+	}
+
+	clearAWSEnv(t)
+
+	unreachable := httptest.NewServer(http.NotFoundHandler())
+	unreachable.Close()
+
+	if _, err := (&auth.AWSAmbient{MetadataURL: unreachable.URL}).Retrieve(context.Background()); !errors.Is(err, auth.ErrNoCredentials) {
+		t.Fatalf("expected ErrNoCredentials outside the cloud, got %v", err)
+	}
+}
+
+func TestGCPMetadata(t *testing.T) {
+	t.Parallel()
+
+	var issued atomic.Int32
+
+	md := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata-Flavor") != "Google" ||
+			r.URL.Path != "/computeMetadata/v1/instance/service-accounts/default/token" {
+			w.WriteHeader(http.StatusForbidden)
+
+			return
+		}
+
+		issued.Add(1)
+		_, _ = w.Write([]byte(`{"access_token":"gke-token","expires_in":3599,"token_type":"Bearer"}`))
+	}))
+	t.Cleanup(md.Close)
+
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Header.Get("Authorization")))
+	}))
+	t.Cleanup(api.Close)
+
+	c := fluent.New().BaseURL(api.URL).Use((&auth.GCPMetadata{MetadataURL: md.URL}).Middleware())
+
+	for range 2 {
+		got, err := c.Get(context.Background(), "/").Raw()
+		if err != nil || string(got) != "Bearer gke-token" {
+			t.Fatalf("unexpected response %q: %v", got, err)
+		}
+	}
+
+	if issued.Load() != 1 {
+		t.Fatalf("expected the token to be cached, issued %d times", issued.Load())
+	}
+}
@@ -0,0 +1,178 @@
+// Package auth содержит middleware авторизации для fluent.
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/devem-tech/fluent"
+)
+
+// ErrToken возвращается, если не удалось получить access token.
+var ErrToken = errors.New("oauth2 token")
+
+// expiryDelta — запас, с которым токен считается истекшим заранее, чтобы он не истек по пути к серверу.
+const expiryDelta = 10 * time.Second
+
+// ClientCredentials получает access token OAuth2 по схеме client credentials (RFC 6749, раздел 4.4),
+// кэширует его до истечения expires_in и подставляет в запросы как Bearer:
+//
+//	creds := &auth.ClientCredentials{TokenURL: "https://auth.example.com/token", ClientID: id, ClientSecret: secret}
+//	c := fluent.New().Use(creds.Middleware())
+//
+// ClientCredentials безопасен для конкурентного использования и может разделяться между клиентами.
+type ClientCredentials struct {
+	// TokenURL — адрес token endpoint.
+	TokenURL string
+	// ClientID и ClientSecret передаются через HTTP Basic-аутентификацию.
+	ClientID     string
+	ClientSecret string
+	// Scopes — запрашиваемые области доступа.
+	Scopes []string
+	// HTTPClient — клиент для запросов токена. По умолчанию http.DefaultClient.
+	HTTPClient *http.Client
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+// Token возвращает кэшированный access token или получает новый, если кэшированный истек.
+func (cc *ClientCredentials) Token(ctx context.Context) (string, error) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	if cc.token != "" && (cc.expiry.IsZero() || time.Now().Before(cc.expiry)) {
+		return cc.token, nil
+	}
+
+	return cc.fetch(ctx)
+}
+
+// Middleware возвращает middleware, который добавляет заголовок Authorization: Bearer <token>.
+// Если сервер ответил 401, токен обновляется, и исходный запрос повторяется один раз.
+// Запросы с телом повторяются, только если тело можно перечитать (req.GetBody).
+func (cc *ClientCredentials) Middleware() fluent.Middleware {
+	return func(next fluent.RoundFunc) fluent.RoundFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			token, err := cc.Token(req.Context())
+			if err != nil {
+				return nil, err
+			}
+
+			resp, err := next(authorize(req, token))
+			if err != nil || resp.StatusCode != http.StatusUnauthorized {
+				return resp, err
+			}
+
+			if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+				return resp, nil
+			}
+
+			token, err = cc.refresh(req.Context(), token)
+			if err != nil {
+				return resp, nil //nolint:nilerr // Ответ 401 информативнее ошибки обновления токена.
+			}
+
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+
+			retry := authorize(req, token)
+			if req.GetBody != nil {
+				if retry.Body, err = req.GetBody(); err != nil {
+					return nil, err
+				}
+			}
+
+			return next(retry)
+		}
+	}
+}
+
+// refresh получает новый токен, если кэшированный все еще равен отвергнутому rejected.
+// Иначе токен уже обновил другой запрос, и повторно обращаться к token endpoint не нужно.
+func (cc *ClientCredentials) refresh(ctx context.Context, rejected string) (string, error) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	if cc.token != rejected {
+		return cc.token, nil
+	}
+
+	return cc.fetch(ctx)
+}
+
+// fetch запрашивает новый токен. Вызывается под cc.mu.
+func (cc *ClientCredentials) fetch(ctx context.Context) (string, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(cc.Scopes) != 0 {
+		form.Set("scope", strings.Join(cc.Scopes, " "))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cc.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(cc.ClientID), url.QueryEscape(cc.ClientSecret))
+
+	client := cc.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%w: %s: %s", ErrToken, resp.Status, body)
+	}
+
+	var tok struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+
+	if err := json.Unmarshal(body, &tok); err != nil {
+		return "", fmt.Errorf("%w: %w", ErrToken, err)
+	}
+
+	if tok.AccessToken == "" {
+		return "", fmt.Errorf("%w: empty access_token", ErrToken)
+	}
+
+	cc.token = tok.AccessToken
+	cc.expiry = time.Time{}
+
+	if tok.ExpiresIn > 0 {
+		cc.expiry = time.Now().Add(time.Duration(tok.ExpiresIn)*time.Second - expiryDelta)
+	}
+
+	return cc.token, nil
+}
+
+// authorize возвращает копию req с заголовком Authorization.
+func authorize(req *http.Request, token string) *http.Request {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	return req
+}
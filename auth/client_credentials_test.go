@@ -0,0 +1,64 @@
+package auth_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/devem-tech/fluent"
+	"github.com/devem-tech/fluent/auth"
+)
+
+func TestClientCredentials_RefreshOnUnauthorized(t *testing.T) {
+	t.Parallel()
+
+	var issued atomic.Int32
+
+	tokens := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, _ := r.BasicAuth(); user != "id" || pass != "secret" || r.FormValue("grant_type") != "client_credentials" {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		n := issued.Add(1)
+		_, _ = w.Write([]byte(`{"access_token":"t` + strconv.Itoa(int(n)) + `","token_type":"Bearer","expires_in":3600}`))
+	}))
+	t.Cleanup(tokens.Close)
+
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "Bearer t1" {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		_, _ = w.Write([]byte(r.Header.Get("Authorization") + " " + string(body)))
+	}))
+	t.Cleanup(api.Close)
+
+	creds := &auth.ClientCredentials{TokenURL: tokens.URL, ClientID: "id", ClientSecret: "secret"}
+	c := fluent.New().BaseURL(api.URL).Use(creds.Middleware())
+
+	got, err := c.Body(map[string]int{"n": 1}).Post(context.Background(), "/").Raw()
+	if err != nil {
+		t.Fatalf("Post returned error: %v", err)
+	}
+
+	if string(got) != `Bearer t2 {"n":1}` {
+		t.Fatalf("unexpected response: %q", got)
+	}
+
+	if _, err := c.Get(context.Background(), "/").Raw(); err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+
+	if n := issued.Load(); n != 2 {
+		t.Fatalf("expected cached token to be reused, got %d token requests", n)
+	}
+}
@@ -0,0 +1,100 @@
+package fluent
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// MaxBandwidth ограничивает суммарную скорость передачи тел запросов и ответов клиента значением
+// bytesPerSec байт в секунду, чтобы фоновая синхронизация не вытесняла чувствительный к задержкам
+// трафик на общем сетевом интерфейсе. Ограничение общее для всех запросов клиента, включая Transport;
+// клоны клиента (Clone) разделяют его с исходным клиентом. Ожидание прерывается отменой контекста запроса.
+// Значение 0 снимает ограничение.
+func (c *Client) MaxBandwidth(bytesPerSec int64) *Client {
+	c.bandwidth = nil
+	if bytesPerSec > 0 {
+		c.bandwidth = &bandwidth{rate: float64(bytesPerSec)}
+	}
+
+	return c
+}
+
+// bandwidth — ограничитель скорости передачи данных. Каждая порция байт резервирует время
+// на общей шкале, а следующая порция ждет, пока истечет время, зарезервированное предыдущими.
+type bandwidth struct {
+	rate float64
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+// chunk возвращает размер порции, за которую передается примерно десятая доля секунды.
+func (b *bandwidth) chunk() int {
+	return max(int(b.rate)/10, 1) //nolint:mnd
+}
+
+// wait ждет окончания ранее зарезервированного времени и резервирует время для n байт.
+func (b *bandwidth) wait(ctx context.Context, n int) error {
+	b.mu.Lock()
+
+	now := time.Now()
+	if b.next.Before(now) {
+		b.next = now
+	}
+
+	delay := b.next.Sub(now)
+	b.next = b.next.Add(time.Duration(float64(n) / b.rate * float64(time.Second)))
+	b.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// limit оборачивает тело запроса req ограничителем скорости. Исходный запрос не изменяется.
+func (b *bandwidth) limit(req *http.Request) *http.Request {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req
+	}
+
+	req = req.WithContext(req.Context())
+	req.Body = &throttledBody{ReadCloser: req.Body, ctx: req.Context(), bw: b}
+
+	return req
+}
+
+// throttledBody — тело, чтение которого ограничено bandwidth.
+type throttledBody struct {
+	io.ReadCloser
+
+	ctx context.Context //nolint:containedctx
+	bw  *bandwidth
+}
+
+func (t *throttledBody) Read(p []byte) (int, error) {
+	if len(p) > t.bw.chunk() {
+		p = p[:t.bw.chunk()]
+	}
+
+	n, err := t.ReadCloser.Read(p)
+	if n > 0 {
+		if werr := t.bw.wait(t.ctx, n); werr != nil {
+			return n, werr
+		}
+	}
+
+	return n, err
+}
@@ -0,0 +1,206 @@
+package fluent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// BatchCall — один вызов пакета, например запрос с декодированием ответа:
+//
+//	func(ctx context.Context) (User, error) {
+//		return fluent.Into[User](c.Request().Get(ctx, "/users/"+id))
+//	}
+type BatchCall[T any] func(ctx context.Context) (T, error)
+
+// BatchResult — результат одного вызова пакета.
+type BatchResult[T any] struct {
+	// Index — позиция вызова в пакете.
+	Index int
+	Value T
+	Err   error
+}
+
+// FanoutResult — результаты всех вызовов пакета в порядке вызовов.
+type FanoutResult[T any] []BatchResult[T]
+
+// Succeeded возвращает значения успешных вызовов в порядке вызовов.
+func (r FanoutResult[T]) Succeeded() []T {
+	var res []T
+
+	for _, br := range r {
+		if br.Err == nil {
+			res = append(res, br.Value)
+		}
+	}
+
+	return res
+}
+
+// Failed возвращает результаты неуспешных вызовов в порядке вызовов.
+func (r FanoutResult[T]) Failed() []BatchResult[T] {
+	var res []BatchResult[T]
+
+	for _, br := range r {
+		if br.Err != nil {
+			res = append(res, br)
+		}
+	}
+
+	return res
+}
+
+// FirstError возвращает ошибку первого по порядку неуспешного вызова или nil.
+func (r FanoutResult[T]) FirstError() error {
+	for _, br := range r {
+		if br.Err != nil {
+			return br.Err
+		}
+	}
+
+	return nil
+}
+
+// Err объединяет ошибки всех неуспешных вызовов через errors.Join, дополняя их номером вызова,
+// или возвращает nil, если все вызовы успешны. errors.Is и errors.As проверяют каждую ошибку.
+func (r FanoutResult[T]) Err() error {
+	var errs []error
+
+	for _, br := range r {
+		if br.Err != nil {
+			errs = append(errs, fmt.Errorf("call %d: %w", br.Index, br.Err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// BatchOptions настраивает выполнение пакета.
+type BatchOptions struct {
+	// Concurrency — число одновременно выполняемых вызовов. По умолчанию все вызовы выполняются сразу.
+	Concurrency int
+	// FailFast останавливает пакет на первой ошибке: контекст выполняющихся вызовов отменяется,
+	// а еще не начатые вызовы получают ошибку контекста. Пакет возвращает эту первую ошибку.
+	// Без FailFast выполняются все вызовы, а пакет возвращает ошибки всех вызовов через errors.Join
+	// (для Batch — FanoutResult.Err).
+	FailFast bool
+}
+
+// Batch выполняет вызовы конкурентно и возвращает результаты в порядке вызовов: results[i]
+// относится к calls[i]. Каждый вызов дает ровно один результат, включая вызовы, отмененные FailFast.
+// Ошибка определяется политикой BatchOptions.FailFast.
+func Batch[T any](ctx context.Context, opts BatchOptions, calls ...BatchCall[T]) (FanoutResult[T], error) {
+	results := make(FanoutResult[T], len(calls))
+
+	err := runBatch(ctx, opts, calls, func(r BatchResult[T]) bool {
+		results[r.Index] = r
+
+		return true
+	})
+	if err != nil && !opts.FailFast {
+		err = results.Err()
+	}
+
+	return results, err
+}
+
+// BatchChan выполняет вызовы конкурентно, так же как Batch, но отправляет результаты в ch по мере
+// их завершения. По завершении ch закрывается. Если ctx отменен, пока получатель не читает из ch,
+// оставшиеся вызовы отменяются, а BatchChan возвращает ошибку контекста.
+func BatchChan[T any](ctx context.Context, opts BatchOptions, ch chan<- BatchResult[T], calls ...BatchCall[T]) error {
+	defer close(ch)
+
+	return runBatch(ctx, opts, calls, func(r BatchResult[T]) bool {
+		select {
+		case ch <- r:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	})
+}
+
+// runBatch выполняет вызовы и передает результаты в emit в порядке завершения.
+// Если emit вернул false, оставшиеся вызовы отменяются, а runBatch возвращает ошибку ctx.
+func runBatch[T any](ctx context.Context, opts BatchOptions, calls []BatchCall[T], emit func(BatchResult[T]) bool) error {
+	parent := ctx
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	concurrency := opts.Concurrency
+	if concurrency <= 0 || concurrency > len(calls) {
+		concurrency = len(calls)
+	}
+
+	results := make(chan BatchResult[T])
+	sem := make(chan struct{}, concurrency)
+
+	go func() {
+		var wg sync.WaitGroup
+
+		for i, call := range calls {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				results <- BatchResult[T]{Index: i, Err: ctx.Err()}
+
+				continue
+			}
+
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				v, err := call(ctx)
+				<-sem
+
+				results <- BatchResult[T]{Index: i, Value: v, Err: err}
+			}()
+		}
+
+		wg.Wait()
+		close(results)
+	}()
+
+	var (
+		errs    []error
+		first   error
+		stopped bool
+	)
+
+	for r := range results {
+		if stopped {
+			continue
+		}
+
+		if r.Err != nil {
+			if first == nil {
+				first = r.Err
+				if opts.FailFast {
+					cancel()
+				}
+			}
+
+			errs = append(errs, r.Err)
+		}
+
+		if !emit(r) {
+			// Получатель больше не ждет результатов: дочитать их, чтобы завершить горутины
+			stopped = true
+
+			cancel()
+		}
+	}
+
+	switch {
+	case stopped:
+		return parent.Err()
+	case opts.FailFast:
+		return first
+	default:
+		return errors.Join(errs...)
+	}
+}
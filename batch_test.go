@@ -0,0 +1,145 @@
+package fluent_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/devem-tech/fluent"
+)
+
+var (
+	errFirst  = errors.New("first")
+	errSecond = errors.New("second")
+)
+
+func sleepCall(d time.Duration, v int, err error) fluent.BatchCall[int] {
+	return func(ctx context.Context) (int, error) {
+		select {
+		case <-time.After(d):
+			return v, err
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+}
+
+func TestBatch_Ordered(t *testing.T) {
+	t.Parallel()
+
+	var inFlight, peak atomic.Int32
+
+	call := func(d time.Duration, v int) fluent.BatchCall[int] {
+		return func(ctx context.Context) (int, error) {
+			n := inFlight.Add(1)
+			defer inFlight.Add(-1)
+
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+
+			return sleepCall(d, v, nil)(ctx)
+		}
+	}
+
+	results, err := fluent.Batch(context.Background(), fluent.BatchOptions{Concurrency: 2},
+		call(30*time.Millisecond, 0), call(10*time.Millisecond, 1), call(0, 2), call(5*time.Millisecond, 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, r := range results {
+		if r.Index != i || r.Value != i {
+			t.Fatalf("result %d out of order: %+v", i, r)
+		}
+	}
+
+	if peak.Load() > 2 {
+		t.Fatalf("concurrency limit exceeded: %d", peak.Load())
+	}
+}
+
+func TestBatch_CollectAll(t *testing.T) {
+	t.Parallel()
+
+	results, err := fluent.Batch(context.Background(), fluent.BatchOptions{},
+		sleepCall(0, 0, errFirst), sleepCall(10*time.Millisecond, 1, nil), sleepCall(0, 0, errSecond))
+
+	if !errors.Is(err, errFirst) || !errors.Is(err, errSecond) {
+		t.Fatalf("expected both errors, got %v", err)
+	}
+
+	if results[1].Err != nil || results[1].Value != 1 {
+		t.Fatalf("successful call must complete: %+v", results[1])
+	}
+}
+
+func TestBatch_FailFast(t *testing.T) {
+	t.Parallel()
+
+	start := time.Now()
+
+	results, err := fluent.Batch(context.Background(), fluent.BatchOptions{Concurrency: 2, FailFast: true},
+		sleepCall(time.Minute, 0, nil), sleepCall(0, 0, errFirst), sleepCall(0, 2, nil))
+
+	if !errors.Is(err, errFirst) || errors.Is(err, context.Canceled) {
+		t.Fatalf("expected only the first error, got %v", err)
+	}
+
+	if !errors.Is(results[0].Err, context.Canceled) {
+		t.Fatalf("running call must be cancelled: %+v", results[0])
+	}
+
+	if time.Since(start) > 5*time.Second {
+		t.Fatal("fail-fast did not cancel running calls")
+	}
+}
+
+func TestBatchChan_CompletionOrder(t *testing.T) {
+	t.Parallel()
+
+	ch := make(chan fluent.BatchResult[int])
+	errc := make(chan error, 1)
+
+	go func() {
+		errc <- fluent.BatchChan(context.Background(), fluent.BatchOptions{}, ch,
+			sleepCall(50*time.Millisecond, 0, nil), sleepCall(0, 1, nil))
+	}()
+
+	var order []int
+	for r := range ch {
+		order = append(order, r.Index)
+	}
+
+	if err := <-errc; err != nil || len(order) != 2 || order[0] != 1 {
+		t.Fatalf("unexpected completion order %v: %v", order, err)
+	}
+}
+
+func TestFanoutResult(t *testing.T) {
+	t.Parallel()
+
+	results, err := fluent.Batch(context.Background(), fluent.BatchOptions{},
+		sleepCall(0, 1, nil), sleepCall(5*time.Millisecond, 0, errSecond), sleepCall(0, 0, errFirst), sleepCall(0, 4, nil))
+
+	if got := results.Succeeded(); len(got) != 2 || got[0] != 1 || got[1] != 4 {
+		t.Fatalf("unexpected succeeded %v", got)
+	}
+
+	if failed := results.Failed(); len(failed) != 2 || failed[0].Index != 1 || failed[1].Index != 2 {
+		t.Fatalf("unexpected failed %+v", failed)
+	}
+
+	if !errors.Is(results.FirstError(), errSecond) {
+		t.Fatalf("expected first error by call order, got %v", results.FirstError())
+	}
+
+	if !errors.Is(err, errFirst) || !errors.Is(err, errSecond) || err.Error() != "call 1: second\ncall 2: first" {
+		t.Fatalf("unexpected joined error %q", err)
+	}
+}
@@ -0,0 +1,135 @@
+package fluent
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"net/http"
+)
+
+// rawBody — тело запроса, которое передается без сериализации в JSON.
+type rawBody struct {
+	data        []byte
+	reader      io.Reader
+	contentType string
+}
+
+// BodyRaw задает тело запроса, которое передается как есть, с Content-Type: application/octet-stream,
+// например protobuf. Content-Type можно переопределить через Header.
+func (c *Client) BodyRaw(data []byte) *Client {
+	c.body = rawBody{data: data, contentType: "application/octet-stream"}
+
+	return c
+}
+
+// BodyString задает тело запроса-строку с Content-Type: text/plain; charset=utf-8, например CSV или NDJSON
+// вместе с Header("Content-Type", ...).
+func (c *Client) BodyString(s string) *Client {
+	c.body = rawBody{data: []byte(s), contentType: "text/plain; charset=utf-8"}
+
+	return c
+}
+
+// BodyReader задает тело запроса, которое читается из r и передается потоком с указанным contentType.
+// Без Seal и ContentEncoding тело не читается в память, но и не может быть повторено RetryPolicy.
+func (c *Client) BodyReader(r io.Reader, contentType string) *Client {
+	c.body = rawBody{reader: r, contentType: contentType}
+
+	return c
+}
+
+// BodyRaw задает тело запроса, которое передается как есть, так же как Client.BodyRaw.
+func (r *Request) BodyRaw(data []byte) *Request {
+	r.body = rawBody{data: data, contentType: "application/octet-stream"}
+
+	return r
+}
+
+// BodyString задает тело запроса-строку, так же как Client.BodyString.
+func (r *Request) BodyString(s string) *Request {
+	r.body = rawBody{data: []byte(s), contentType: "text/plain; charset=utf-8"}
+
+	return r
+}
+
+// BodyReader задает потоковое тело запроса, так же как Client.BodyReader.
+func (r *Request) BodyReader(reader io.Reader, contentType string) *Request {
+	r.body = rawBody{reader: reader, contentType: contentType}
+
+	return r
+}
+
+// encodeBody сериализует тело запроса и применяет к нему Seal и ContentEncoding.
+// Content-Type тела и заголовки, выставленные при защите и сжатии, записываются в h.
+func (c *Client) encodeBody(body any, h http.Header) (io.Reader, error) {
+	var (
+		data []byte
+		err  error
+	)
+
+	switch v := body.(type) {
+	case rawBody:
+		if v.contentType != "" {
+			h.Set("Content-Type", v.contentType)
+		}
+
+		data = v.data
+
+		if v.reader != nil {
+			if len(c.sealers) == 0 && c.encoding == "" {
+				return v.reader, nil
+			}
+
+			if data, err = io.ReadAll(v.reader); err != nil {
+				return nil, err
+			}
+		}
+	case jsonStreamBody:
+		h.Set("Content-Type", "application/json")
+
+		if len(c.sealers) == 0 && c.encoding == "" {
+			return newJSONStream(v.v), nil
+		}
+
+		if data, err = json.Marshal(v.v); err != nil {
+			return nil, err
+		}
+	case codecBody:
+		h.Set("Content-Type", v.mediaType)
+
+		if data, err = c.marshal(v); err != nil {
+			return nil, err
+		}
+	case xmlBody:
+		h.Set("Content-Type", "application/xml")
+
+		if data, err = xml.Marshal(v.v); err != nil {
+			return nil, err
+		}
+
+		data = append([]byte(xml.Header), data...)
+	default:
+		h.Set("Content-Type", "application/json")
+
+		if data, err = json.Marshal(body); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, s := range c.sealers {
+		if data, err = s.Seal(data, h); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.encoding != "" {
+		if data, err = compress(c.encoding, data); err != nil {
+			return nil, err
+		}
+
+		h.Set("Content-Encoding", c.encoding)
+	}
+
+	return bytes.NewReader(data), nil
+}
@@ -0,0 +1,91 @@
+package fluent
+
+import (
+	"fmt"
+)
+
+// BulkItemError — ошибка отдельного элемента ответа пакетного эндпоинта.
+type BulkItemError[T any] struct {
+	// Index — позиция элемента в ответе.
+	Index int
+	// Item — сам элемент, как его вернул сервер.
+	Item T
+	// Err — ошибка элемента.
+	Err error
+}
+
+func (e *BulkItemError[T]) Error() string {
+	return fmt.Sprintf("item %d: %v", e.Index, e.Err)
+}
+
+func (e *BulkItemError[T]) Unwrap() error {
+	return e.Err
+}
+
+// BulkResult — ответ пакетного эндпоинта, разделенный на успешные элементы и ошибки отдельных элементов.
+// BulkResult реализует error, а errors.Is и errors.As проверяют ошибки всех элементов.
+type BulkResult[T any] struct {
+	Succeeded []T
+	Failed    []*BulkItemError[T]
+}
+
+// Bulk разделяет уже декодированные элементы на успешные и неуспешные.
+// itemErr возвращает ошибку элемента или nil, если элемент обработан успешно.
+// Удобно, когда элементы вложены в конверт ответа и декодируются через Into.
+func Bulk[T any](items []T, itemErr func(item T) error) *BulkResult[T] {
+	res := &BulkResult[T]{}
+
+	for i, item := range items {
+		if err := itemErr(item); err != nil {
+			res.Failed = append(res.Failed, &BulkItemError[T]{Index: i, Item: item, Err: err})
+
+			continue
+		}
+
+		res.Succeeded = append(res.Succeeded, item)
+	}
+
+	return res
+}
+
+// IntoBulk декодирует JSON-массив элементов из тела ответа и разделяет их через Bulk.
+// Для пакетных API, которые отвечают 200 с ошибками отдельных элементов.
+// Если хотя бы один элемент неуспешен, вместе с результатом возвращается ошибка — сам *BulkResult.
+func IntoBulk[T any](r *Response, itemErr func(item T) error) (*BulkResult[T], error) {
+	items, err := Into[[]T](r)
+	if err != nil {
+		return nil, err
+	}
+
+	res := Bulk(items, itemErr)
+
+	return res, res.Err()
+}
+
+// Err возвращает сам результат как ошибку, если есть неуспешные элементы, и nil в противном случае.
+func (r *BulkResult[T]) Err() error {
+	if len(r.Failed) == 0 {
+		return nil
+	}
+
+	return r
+}
+
+func (r *BulkResult[T]) Error() string {
+	total := len(r.Succeeded) + len(r.Failed)
+
+	if len(r.Failed) == 0 {
+		return fmt.Sprintf("bulk: 0 of %d items failed", total)
+	}
+
+	return fmt.Sprintf("bulk: %d of %d items failed: %v", len(r.Failed), total, r.Failed[0])
+}
+
+func (r *BulkResult[T]) Unwrap() []error {
+	errs := make([]error, len(r.Failed))
+	for i, e := range r.Failed {
+		errs[i] = e
+	}
+
+	return errs
+}
@@ -0,0 +1,44 @@
+package fluent_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/devem-tech/fluent"
+)
+
+var errItem = errors.New("item failed")
+
+type bulkItem struct {
+	ID    int    `json:"id"`
+	Error string `json:"error"`
+}
+
+func TestIntoBulk(t *testing.T) {
+	t.Parallel()
+
+	srv := serve(t, `[{"id":1},{"id":2,"error":"conflict"},{"id":3}]`)
+
+	res, err := fluent.IntoBulk(
+		fluent.New().Get(context.Background(), srv.URL),
+		func(item bulkItem) error {
+			if item.Error != "" {
+				return errItem
+			}
+
+			return nil
+		},
+	)
+	if !errors.Is(err, errItem) {
+		t.Fatalf("expected errItem, got: %v", err)
+	}
+
+	if len(res.Succeeded) != 2 || len(res.Failed) != 1 || res.Failed[0].Index != 1 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+
+	if err.Error() != "bulk: 1 of 3 items failed: item 1: item failed" {
+		t.Fatalf("unexpected error message: %q", err.Error())
+	}
+}
@@ -0,0 +1,142 @@
+package fluent
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ErrContentRange возвращается, если Content-Range отсутствует, некорректен или не совпадает с телом.
+var ErrContentRange = errors.New("invalid content range")
+
+// ByteRange — фрагмент ресурса из ответа на ranged GET.
+type ByteRange struct {
+	// Start и End — границы фрагмента включительно, как в Content-Range.
+	Start, End int64
+	// Size — полный размер ресурса или -1, если сервер его не сообщил ("*").
+	Size int64
+	// Data — содержимое фрагмента, len(Data) == End-Start+1.
+	Data []byte
+}
+
+// ByteRanges читает ответ на запрос с заголовком Range и возвращает полученные фрагменты.
+// Для 206 Partial Content разбирается как одиночный ответ с Content-Range, так и multipart/byteranges.
+// Длина каждого фрагмента сверяется с его Content-Range.
+// Если сервер проигнорировал Range и ответил 200, возвращается один фрагмент с телом целиком.
+// Тело ответа автоматически закрывается.
+func (r *Response) ByteRanges() ([]ByteRange, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	defer r.resp.Body.Close()
+
+	if r.resp.StatusCode != http.StatusPartialContent {
+		data, err := io.ReadAll(r.resp.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		return []ByteRange{{Start: 0, End: int64(len(data)) - 1, Size: int64(len(data)), Data: data}}, nil
+	}
+
+	mediaType, params, _ := mime.ParseMediaType(r.resp.Header.Get("Content-Type"))
+	if mediaType != "multipart/byteranges" {
+		data, err := io.ReadAll(r.resp.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		br, err := newByteRange(r.resp.Header.Get("Content-Range"), data)
+		if err != nil {
+			return nil, err
+		}
+
+		return []ByteRange{br}, nil
+	}
+
+	var ranges []ByteRange
+
+	mr := multipart.NewReader(r.resp.Body, params["boundary"])
+
+	for {
+		part, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			return ranges, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := io.ReadAll(part)
+		if err != nil {
+			return nil, err
+		}
+
+		br, err := newByteRange(part.Header.Get("Content-Range"), data)
+		if err != nil {
+			return nil, err
+		}
+
+		ranges = append(ranges, br)
+	}
+}
+
+// newByteRange разбирает Content-Range вида "bytes 0-499/1234" и проверяет, что длина data ему соответствует.
+func newByteRange(contentRange string, data []byte) (ByteRange, error) {
+	br, err := parseContentRange(contentRange)
+	if err != nil {
+		return ByteRange{}, err
+	}
+
+	if int64(len(data)) != br.End-br.Start+1 {
+		return ByteRange{}, fmt.Errorf("%w: %q does not match body length %d", ErrContentRange, contentRange, len(data))
+	}
+
+	br.Data = data
+
+	return br, nil
+}
+
+// parseContentRange разбирает Content-Range вида "bytes 0-499/1234" без данных фрагмента.
+func parseContentRange(contentRange string) (ByteRange, error) {
+	spec, ok := strings.CutPrefix(contentRange, "bytes ")
+	if !ok {
+		return ByteRange{}, fmt.Errorf("%w: %q", ErrContentRange, contentRange)
+	}
+
+	bounds, size, ok := strings.Cut(spec, "/")
+	if !ok {
+		return ByteRange{}, fmt.Errorf("%w: %q", ErrContentRange, contentRange)
+	}
+
+	first, last, ok := strings.Cut(bounds, "-")
+	if !ok {
+		return ByteRange{}, fmt.Errorf("%w: %q", ErrContentRange, contentRange)
+	}
+
+	br := ByteRange{Size: -1}
+
+	var err error
+
+	if br.Start, err = strconv.ParseInt(first, 10, 64); err != nil {
+		return ByteRange{}, fmt.Errorf("%w: %q", ErrContentRange, contentRange)
+	}
+
+	if br.End, err = strconv.ParseInt(last, 10, 64); err != nil || br.End < br.Start {
+		return ByteRange{}, fmt.Errorf("%w: %q", ErrContentRange, contentRange)
+	}
+
+	if size != "*" {
+		if br.Size, err = strconv.ParseInt(size, 10, 64); err != nil || br.End >= br.Size {
+			return ByteRange{}, fmt.Errorf("%w: %q", ErrContentRange, contentRange)
+		}
+	}
+
+	return br, nil
+}
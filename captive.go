@@ -0,0 +1,62 @@
+package fluent
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"mime"
+)
+
+var (
+	// ErrUnexpectedHTML возвращается из Into, если вместо данных сервер вернул HTML-страницу.
+	ErrUnexpectedHTML = errors.New("unexpected HTML response")
+	// ErrCaptivePortal возвращается из Into, если HTML-страница похожа на страницу входа или captive portal
+	// (гостевой Wi-Fi, корпоративный прокси). Ошибка также соответствует ErrUnexpectedHTML.
+	ErrCaptivePortal = fmt.Errorf("%w: login page or captive portal", ErrUnexpectedHTML)
+)
+
+// sniffLen — сколько байт тела достаточно, чтобы распознать HTML.
+const sniffLen = 512
+
+// loginMarkers — признаки страницы входа или captive portal в HTML в нижнем регистре.
+var loginMarkers = [][]byte{
+	[]byte(`type="password"`),
+	[]byte(`type='password'`),
+	[]byte("login"),
+	[]byte("log in"),
+	[]byte("sign in"),
+	[]byte("captive"),
+	[]byte("wi-fi"),
+	[]byte("wifi"),
+	[]byte("hotspot"),
+}
+
+// isHTML сообщает, что тело с началом head и заголовком contentType — HTML-страница.
+func isHTML(head []byte, contentType string) bool {
+	if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType == "text/html" {
+		return true
+	}
+
+	head = bytes.ToLower(bytes.TrimLeft(head, " \t\r\n\ufeff"))
+
+	return bytes.HasPrefix(head, []byte("<!doctype html")) || bytes.HasPrefix(head, []byte("<html"))
+}
+
+// htmlError возвращает ошибку для HTML-страницы data, полученной вместо данных.
+func (r *Response) htmlError(data []byte) error {
+	url := ""
+	if r.resp.Request != nil {
+		url = r.resp.Request.URL.String()
+	}
+
+	page := bytes.ToLower(data)
+	if bytes.Contains(page, []byte("<form")) || bytes.Contains(page, []byte("password")) {
+		for _, marker := range loginMarkers {
+			if bytes.Contains(page, marker) {
+				return fmt.Errorf("%w: %s", ErrCaptivePortal, url)
+			}
+		}
+	}
+
+	return fmt.Errorf("%w: %s", ErrUnexpectedHTML, url)
+}
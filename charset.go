@@ -0,0 +1,82 @@
+package fluent
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"mime"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// ErrUnsupportedCharset возвращается, если charset ответа не поддерживается.
+var ErrUnsupportedCharset = errors.New("unsupported charset")
+
+// windows1252 — символы Windows-1252 в диапазоне 0x80–0x9F, которые отличаются от ISO-8859-1.
+var windows1252 = [32]rune{
+	'€', 0x81, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0x8D, 'Ž', 0x8F,
+	0x90, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0x9D, 'ž', 'Ÿ',
+}
+
+// decodeCharset переводит тело в строку UTF-8 с учетом BOM и параметра charset из Content-Type.
+// Если charset не указан, тело считается UTF-8.
+func decodeCharset(data []byte, contentType string) (string, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xEF, 0xBB, 0xBF}):
+		return string(data[3:]), nil
+	case bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		return decodeUTF16(data[2:], binary.BigEndian), nil
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}):
+		return decodeUTF16(data[2:], binary.LittleEndian), nil
+	}
+
+	charset := ""
+	if _, params, err := mime.ParseMediaType(contentType); err == nil {
+		charset = strings.ToLower(params["charset"])
+	}
+
+	switch charset {
+	case "", "utf-8", "utf8", "us-ascii", "ascii":
+		return strings.ToValidUTF8(string(data), string(utf8.RuneError)), nil
+	case "iso-8859-1", "latin1", "l1":
+		return decodeSingleByte(data, nil), nil
+	case "windows-1252", "cp1252":
+		return decodeSingleByte(data, &windows1252), nil
+	case "utf-16be":
+		return decodeUTF16(data, binary.BigEndian), nil
+	case "utf-16le", "utf-16":
+		return decodeUTF16(data, binary.LittleEndian), nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedCharset, charset)
+	}
+}
+
+// decodeSingleByte декодирует однобайтовую кодировку, совпадающую с ISO-8859-1 за исключением диапазона 0x80–0x9F.
+func decodeSingleByte(data []byte, high *[32]rune) string {
+	var b strings.Builder
+
+	b.Grow(len(data))
+
+	for _, c := range data {
+		if high != nil && c >= 0x80 && c <= 0x9F {
+			b.WriteRune(high[c-0x80])
+
+			continue
+		}
+
+		b.WriteRune(rune(c))
+	}
+
+	return b.String()
+}
+
+func decodeUTF16(data []byte, order binary.ByteOrder) string {
+	units := make([]uint16, len(data)/2) //nolint:mnd
+	for i := range units {
+		units[i] = order.Uint16(data[i*2:])
+	}
+
+	return string(utf16.Decode(units))
+}
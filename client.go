@@ -3,13 +3,16 @@ package fluent
 import (
 	"bytes"
 	"context"
-	"encoding/json"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"reflect"
+	"slices"
 	"strings"
+	"time"
 )
 
 // ErrNotOK возвращается, если сервер ответил не 2xx.
@@ -20,57 +23,205 @@ type HTTPError struct {
 	Status     string
 	Method     string
 	URL        string
+	Header     http.Header
 	Body       []byte
+	// API — тело ответа, декодированное в тип из ErrorType, или nil.
+	API error
+	// Problem — тело ответа application/problem+json (RFC 7807) или nil.
+	Problem *ProblemDetails
+	// RequestID — отправленный идентификатор запроса (см. RequestID) или пустая строка.
+	RequestID string
 }
 
 func (e *HTTPError) Error() string {
+	status := e.Status
+	if e.RequestID != "" {
+		status += " (request id " + e.RequestID + ")"
+	}
+
 	if len(e.Body) == 0 {
-		return fmt.Sprintf("%s %s: %s", e.Method, e.URL, e.Status)
+		return fmt.Sprintf("%s %s: %s", e.Method, e.URL, status)
 	}
 
-	return fmt.Sprintf("%s %s: %s: %s", e.Method, e.URL, e.Status, string(e.Body))
+	return fmt.Sprintf("%s %s: %s: %s", e.Method, e.URL, status, string(e.Body))
 }
 
 func (e *HTTPError) Unwrap() error {
 	return ErrNotOK
 }
 
+// Is сообщает, совпадает ли target с ошибкой API или Problem, чтобы errors.Is находил сигнальные ошибки
+// типизированного тела ответа.
+func (e *HTTPError) Is(target error) bool {
+	return e.API != nil && errors.Is(e.API, target) || e.Problem != nil && errors.Is(e.Problem, target)
+}
+
+// As извлекает через errors.As ошибку API или Problem, если ее тип подходит под target.
+func (e *HTTPError) As(target any) bool {
+	return e.API != nil && errors.As(e.API, target) || e.Problem != nil && errors.As(e.Problem, target)
+}
+
 // httpClient — интерфейс для любого http-клиента, поддерживающего метод Do.
 // Обычно это *http.Client.
 type httpClient interface {
 	Do(req *http.Request) (*http.Response, error)
 }
 
+// Decrypter расшифровывает тело успешного ответа до того, как оно станет доступно через Response.
+// Встроенная реализация — JWE.
+type Decrypter interface {
+	Decrypt(data []byte) ([]byte, error)
+}
+
+// Sealer защищает сериализованное тело запроса перед отправкой: подписывает, шифрует или делает и то и другое.
+// Sealer может заменить тело и выставить заголовки (например, detached-подпись или Content-Type).
+// Встроенные реализации — JWS и JWE.
+type Sealer interface {
+	Seal(body []byte, header http.Header) ([]byte, error)
+}
+
 // Client реализует chainable HTTP-клиент с поддержкой кастомного клиента, query-параметров, заголовков и JSON body.
+//
+// Методы Get, Post и Do клиента изменяют его только для сброса тела и разового http-клиента,
+// поэтому для конкурентных запросов используйте Request: он копирует параметры клиента,
+// а клиент остается неизменным.
 type Client struct {
-	baseURL string
-	params  url.Values
-	headers http.Header
-	client  httpClient
-	body    any
+	requestState
+
+	baseURL    string
+	client     httpClient
+	decrypter  Decrypter
+	sealers    []Sealer
+	decoders   []Decoder
+	negative   *negativeCache
+	precheck   func(req *http.Request) bool
+	resetRetry bool
+	retry      RetryPolicy
+	middleware []Middleware
+	encoding   string
+	accept     []string
+	costCenter costCenter
+	token      func() string
+	ordered    bool
+	signers    []func(req *http.Request) error
+	timeout    time.Duration
+	ops        *opRegistry
+	locale     []string
+	fields     FieldsDialect
+	relogin    func(ctx context.Context) error
+	codecs     map[string]Codec
+	errorType  reflect.Type
+	quota      *Quota
+	limiter    *AdaptiveLimiter
+	clock      *ClockSkew
+	offline    *OfflineQueue
+	bandwidth  *bandwidth
+	spool      *spooler
+	maxPages   int
+	profile    func(p RequestProfile)
+	requestID  string
+	metrics    Metrics
+	debug      *curlDumper
+	negotiate  Negotiation
+	compat     Compat
 }
 
 // New создает новый fluent-клиент с пустым baseURL и стандартными параметрами.
 func New() *Client {
 	return &Client{
-		params:  make(url.Values),
-		headers: make(http.Header),
-		client:  http.DefaultClient,
+		requestState: requestState{
+			params:  make(url.Values),
+			headers: make(http.Header),
+		},
+		client: http.DefaultClient,
+		ops:    newOpRegistry(),
+	}
+}
+
+// Clone возвращает независимую копию клиента: query-параметры, заголовки, цепочки Seal, DecodeFallbacks,
+// Use и AcceptEncoding копируются, а *http.Client из HTTPClient копируется по значению
+// (транспорт и пул соединений остаются общими). Так можно настроить базовый клиент с авторизацией один раз
+// и получать из него варианты для отдельных сервисов, не влияя друг на друга.
+// Quota, Throttle, ClockSkew и MaxBandwidth остаются общими с исходным клиентом. NegativeCache копия получает
+// с тем же ttl, но пустым: клиенты с разной авторизацией не должны видеть закэшированные ответы друг друга.
+func (c *Client) Clone() *Client {
+	cp := *c
+	cp.requestState = c.clone()
+	cp.client = cloneHTTPClient(c.client)
+	cp.once = cloneHTTPClient(c.once)
+	cp.sealers = slices.Clone(c.sealers)
+	cp.decoders = slices.Clone(c.decoders)
+	cp.middleware = slices.Clone(c.middleware)
+	cp.accept = slices.Clone(c.accept)
+	cp.signers = slices.Clone(c.signers)
+	cp.locale = slices.Clone(c.locale)
+
+	if c.negative != nil {
+		cp.negative = newNegativeCache(c.negative.ttl)
+	}
+
+	return &cp
+}
+
+// cloneHTTPClient копирует *http.Client по значению. Другие реализации httpClient возвращаются как есть.
+func cloneHTTPClient(client httpClient) httpClient {
+	hc, ok := client.(*http.Client)
+	if !ok || hc == nil {
+		return client
 	}
+
+	cp := *hc
+
+	return &cp
 }
 
 // BaseURL задает базовый адрес для всех последующих запросов.
 // Если baseURL не задан, путь передается как абсолютный URL в метод Get или Post.
+// Абсолютные URL со схемой и хостом, например ссылки на следующую страницу, используются без baseURL.
 func (c *Client) BaseURL(baseURL string) *Client {
 	c.baseURL = baseURL
 
 	return c
 }
 
+// ownParams копирует query-параметры клиента перед изменением: Request и Clone разделяют их
+// с клиентом без копирования, а пометить это на клиенте нельзя — Request вызывается конкурентно.
+func (c *Client) ownParams() {
+	c.sharedParams = true
+	c.writableParams()
+}
+
+// ownHeaders копирует заголовки клиента перед изменением, так же как ownParams, и возвращает их.
+func (c *Client) ownHeaders() http.Header {
+	c.sharedHeaders = true
+
+	return c.writableHeaders()
+}
+
 // Query добавляет query-параметр к следующему запросу.
 // Можно вызывать несколько раз для добавления разных параметров.
 func (c *Client) Query(key, value string) *Client {
-	c.params.Add(key, value)
+	c.ownParams()
+	c.addQuery(key, value)
+
+	return c
+}
+
+// RawQuery задает строку запроса, которая передается ровно как есть, без разбора и повторного кодирования,
+// например для API, подпись которых вычисляется над точной последовательностью байтов query.
+// Строка заменяет query из path, а параметры из Query добавляются после нее.
+// Пустая строка выключает режим. Действует до вызова Reset.
+func (c *Client) RawQuery(query string) *Client {
+	c.rawQuery = query
+
+	return c
+}
+
+// OrderedQuery включает передачу query-параметров в порядке добавления вместо сортировки по ключу,
+// которую выполняет url.Values.Encode. Нужен для схем подписи и устаревших серверов, зависящих от порядка.
+// Ключи идут в порядке первого добавления, значения одного ключа — подряд; query из path остается как есть.
+func (c *Client) OrderedQuery(enabled bool) *Client {
+	c.ordered = enabled
 
 	return c
 }
@@ -78,7 +229,88 @@ func (c *Client) Query(key, value string) *Client {
 // Header добавляет HTTP-заголовок к следующему запросу.
 // Можно вызывать несколько раз для добавления разных заголовков.
 func (c *Client) Header(key, value string) *Client {
-	c.headers.Add(key, value)
+	c.ownHeaders().Add(key, value)
+
+	return c
+}
+
+// HeaderExact добавляет HTTP-заголовок, имя которого передается в запросе ровно в указанном регистре,
+// без приведения к каноническому виду (X-Api-Key). Нужен для устаревших серверов и схем подписи,
+// чувствительных к регистру имен. Действует только для HTTP/1.x: в HTTP/2 имена всегда в нижнем регистре.
+// SetHeader и DelHeader работают только с каноническими именами и такие заголовки не затрагивают.
+func (c *Client) HeaderExact(key, value string) *Client {
+	h := c.ownHeaders()
+	h[key] = append(h[key], value)
+
+	return c
+}
+
+// SetQuery заменяет все значения query-параметра key одним значением value.
+func (c *Client) SetQuery(key, value string) *Client {
+	c.ownParams()
+	c.setQuery(key, value)
+
+	return c
+}
+
+// DelQuery удаляет query-параметр key.
+func (c *Client) DelQuery(key string) *Client {
+	c.ownParams()
+	c.delQuery(key)
+
+	return c
+}
+
+// SetHeader заменяет все значения HTTP-заголовка key одним значением value.
+func (c *Client) SetHeader(key, value string) *Client {
+	c.ownHeaders().Set(key, value)
+
+	return c
+}
+
+// DelHeader удаляет HTTP-заголовок key.
+func (c *Client) DelHeader(key string) *Client {
+	c.ownHeaders().Del(key)
+
+	return c
+}
+
+// HeaderStruct задает HTTP-заголовки из полей структуры с тегом `header:"X-Name[,omitempty]"`.
+// Значения заменяют уже заданные заголовки с тем же именем. Поддерживаются строки, bool, числа, []string,
+// time.Time (HTTP-date), time.Duration (секунды), encoding.TextMarshaler и fmt.Stringer.
+// Ошибка преобразования вернется из следующего запроса.
+func (c *Client) HeaderStruct(v any) *Client {
+	if err := encodeHeaders(c.ownHeaders(), v); err != nil {
+		c.err = err
+	}
+
+	return c
+}
+
+// BasicAuth задает заголовок Authorization для HTTP Basic-аутентификации (RFC 7617).
+// Как и Header, действует до вызова Reset.
+func (c *Client) BasicAuth(user, password string) *Client {
+	c.token = nil
+	c.ownHeaders().Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(user+":"+password)))
+
+	return c
+}
+
+// BearerToken задает заголовок Authorization: Bearer <token>.
+// Как и Header, действует до вызова Reset.
+func (c *Client) BearerToken(token string) *Client {
+	c.token = nil
+	c.ownHeaders().Set("Authorization", "Bearer "+token)
+
+	return c
+}
+
+// BearerTokenFunc задает функцию, которая вызывается перед каждым запросом и возвращает актуальный токен
+// для заголовка Authorization: Bearer <token>, например из кэша с автоматическим обновлением.
+// Функция должна быть безопасна для конкурентного вызова. Пустой токен не выставляет заголовок.
+// В отличие от BearerToken, функция не сбрасывается Reset.
+func (c *Client) BearerTokenFunc(token func() string) *Client {
+	c.token = token
 
 	return c
 }
@@ -86,6 +318,173 @@ func (c *Client) Header(key, value string) *Client {
 // HTTPClient задает кастомный http-клиент (например, с таймаутом или прокси).
 func (c *Client) HTTPClient(client httpClient) *Client {
 	c.client = client
+	if c.compat.HTTP1 {
+		c.client = http1Only(client)
+	}
+
+	return c
+}
+
+// WithHTTPClient задает http-клиент только для следующего запроса, например с увеличенным таймаутом
+// для одного тяжелого эндпоинта. После успешного запроса снова используется клиент из HTTPClient.
+func (c *Client) WithHTTPClient(client httpClient) *Client {
+	c.once = client
+
+	return c
+}
+
+// Decrypt задает расшифровщик тел успешных ответов, например &JWE{Key: ...}.
+// Если расшифровщик задан, Raw, Body и Into работают уже с открытым текстом.
+func (c *Client) Decrypt(decrypter Decrypter) *Client {
+	c.decrypter = decrypter
+
+	return c
+}
+
+// Seal задает цепочку защиты тела запроса, которая применяется по порядку, например
+// Seal(&JWS{...}, &JWE{...}) сначала подписывает тело, а затем шифрует результат.
+// Применяется только к запросам с телом (метод Body).
+func (c *Client) Seal(sealers ...Sealer) *Client {
+	c.sealers = sealers
+
+	return c
+}
+
+// DecodeFallbacks задает цепочку декодеров, которые Into пробует по порядку, пока один из них не сработает.
+// Полезно, когда сервер не указывает Content-Type или указывает его неверно, например DecodeFallbacks(JSON, XML, Text).
+// Без цепочки Into декодирует тело как JSON.
+func (c *Client) DecodeFallbacks(decoders ...Decoder) *Client {
+	c.decoders = decoders
+
+	return c
+}
+
+// NegativeCache включает кэширование ответов 404 и 410 на GET-запросы на время ttl.
+// Повторные запросы того же URL в течение ttl не уходят в сеть и сразу возвращают ту же HTTPError.
+// Это заметно снижает нагрузку при проверках существования ключей, большинства из которых нет.
+// Ключ кэша — полный URL запроса, заголовки не учитываются, поэтому у каждого клона из Clone свой кэш.
+// ttl <= 0 выключает кэш.
+func (c *Client) NegativeCache(ttl time.Duration) *Client {
+	c.negative = nil
+	if ttl > 0 {
+		c.negative = newNegativeCache(ttl)
+	}
+
+	return c
+}
+
+// Precheck задает проверку, которая вызывается перед отправкой каждого запроса.
+// Если check возвращает false, запрос не уходит в сеть, а Response сразу содержит HTTPError
+// со статусом 404 Not Found. Так можно пропускать заведомо отсутствующие ключи,
+// сверяясь с bloom-фильтром или локальным индексом.
+func (c *Client) Precheck(check func(req *http.Request) bool) *Client {
+	c.precheck = check
+
+	return c
+}
+
+// RetryOnReset включает или выключает однократный повтор идемпотентных запросов (GET, HEAD, OPTIONS,
+// TRACE, PUT, DELETE), если переиспользованное keep-alive соединение оказалось разорвано сервером
+// (ECONNRESET или EOF). Повтор уходит по новому соединению. Выключено по умолчанию. Если задана RetryPolicy,
+// такой повтор расходует одну из ее MaxRetries, а после исчерпания повторов не выполняется.
+func (c *Client) RetryOnReset(enabled bool) *Client {
+	c.resetRetry = enabled
+
+	return c
+}
+
+// Retry включает до n повторов неудачных запросов с настройками RetryPolicy по умолчанию:
+// экспоненциальная задержка с jitter, повтор при временных сетевых ошибках и ответах 502, 503 и 504.
+// Повторы выключены по умолчанию.
+func (c *Client) Retry(n int) *Client {
+	c.retry = RetryPolicy{MaxRetries: n}
+
+	return c
+}
+
+// RetryPolicy задает политику повторов неудачных запросов.
+func (c *Client) RetryPolicy(policy RetryPolicy) *Client {
+	c.retry = policy
+
+	return c
+}
+
+// ContentEncoding сжимает тела следующих запросов кодеком encoding ("gzip", "deflate" или кодеком,
+// зарегистрированным через RegisterCompressor) и выставляет Content-Encoding.
+// Пустая строка выключает сжатие.
+func (c *Client) ContentEncoding(encoding string) *Client {
+	c.encoding = encoding
+
+	return c
+}
+
+// AcceptEncoding объявляет серверу поддерживаемые кодировки в Accept-Encoding и распаковывает ответы,
+// сжатые одной из них. Кодеки берутся из RegisterCompressor.
+// Без AcceptEncoding сжатием ответов управляет транспорт net/http.
+func (c *Client) AcceptEncoding(encodings ...string) *Client {
+	c.accept = encodings
+
+	return c
+}
+
+// CloseConnection запрещает переиспользование соединения для следующих запросов:
+// выставляется Connection: close, и соединение закрывается после ответа.
+// Полезно для эндпоинтов за балансировщиками, которые некорректно обрабатывают keep-alive.
+// Действует до вызова Reset.
+func (c *Client) CloseConnection(enabled bool) *Client {
+	c.closeConn = enabled
+
+	return c
+}
+
+// DisableCompression запрашивает ответы следующих запросов без сжатия (Accept-Encoding: identity),
+// отключая прозрачный gzip транспорта net/http и AcceptEncoding. Полезно, когда нужна точная длина тела
+// из Content-Length для индикации прогресса или контрольная сумма передаваемых байтов.
+// Действует до вызова Reset.
+func (c *Client) DisableCompression() *Client {
+	c.identity = true
+
+	return c
+}
+
+// CostCenter помечает все запросы клиента тегом центра затрат, чтобы расходы на сторонние API
+// можно было отнести к внутренним продуктам. Тег передается в заголовке DefaultCostCenterHeader
+// (или в заголовке, заданном CostCenterHeader) и в метку RequestLabels.CostCenter для LabeledMetrics.
+// В отличие от Header, тег не сбрасывается Reset.
+func (c *Client) CostCenter(tag string) *Client {
+	c.costCenter.tag = tag
+
+	return c
+}
+
+// CostCenterHeader задает имя заголовка для тега CostCenter.
+func (c *Client) CostCenterHeader(name string) *Client {
+	c.costCenter.header = name
+
+	return c
+}
+
+// Quota подключает локальный учет квоты, например &Quota{Limit: 10000, Window: 24 * time.Hour, Block: true},
+// чтобы одна вышедшая из-под контроля задача не израсходовала месячную квоту платного API.
+// Одну Quota можно разделять между несколькими клиентами.
+func (c *Client) Quota(q *Quota) *Client {
+	c.quota = q
+
+	return c
+}
+
+// Throttle подключает адаптивный ограничитель скорости: перед каждым запросом клиент ждет своей очереди,
+// а ответы 429 снижают скорость, например Throttle(&AdaptiveLimiter{Rate: 50}).
+func (c *Client) Throttle(l *AdaptiveLimiter) *Client {
+	c.limiter = l
+
+	return c
+}
+
+// ClockSkew подключает сравнение заголовка Date ответов с локальными часами,
+// например ClockSkew(&ClockSkew{OnDrift: func(host string, d time.Duration) { ... }}).
+func (c *Client) ClockSkew(s *ClockSkew) *Client {
+	c.clock = s
 
 	return c
 }
@@ -98,11 +497,13 @@ func (c *Client) Body(body any) *Client {
 	return c
 }
 
-// Reset очищает все query-параметры, заголовки и тело клиента.
+// Reset очищает все query-параметры, заголовки, тело клиента, разовый http-клиент, CloseConnection
+// и отложенную ошибку HeaderStruct.
 func (c *Client) Reset() *Client {
-	c.params = make(url.Values)
-	c.headers = make(http.Header)
-	c.body = nil
+	c.requestState = requestState{
+		params:  make(url.Values),
+		headers: make(http.Header),
+	}
 
 	return c
 }
@@ -112,7 +513,7 @@ func (c *Client) Reset() *Client {
 // Если baseURL не задан, path должен быть абсолютным URL.
 // Возвращает Response, оборачивающий http.Response и ошибку.
 func (c *Client) Get(ctx context.Context, path string) *Response {
-	return c.do(ctx, http.MethodGet, path)
+	return c.do(ctx, http.MethodGet, path, &c.requestState)
 }
 
 // Post выполняет HTTP POST-запрос по указанному пути или URL.
@@ -121,91 +522,325 @@ func (c *Client) Get(ctx context.Context, path string) *Response {
 // Если baseURL не задан, path должен быть абсолютным URL.
 // Возвращает Response, оборачивающий http.Response и ошибку.
 func (c *Client) Post(ctx context.Context, path string) *Response {
-	return c.do(ctx, http.MethodPost, path)
+	return c.do(ctx, http.MethodPost, path, &c.requestState)
 }
 
-// do выполняет HTTP-запрос с любым методом (GET, POST и др.).
-func (c *Client) do(ctx context.Context, method, path string) *Response { //nolint:cyclop
-	fullURL, err := c.fullURL(path)
+// Do выполняет HTTP-запрос с произвольным методом: PUT, PATCH, DELETE или нестандартными методами
+// WebDAV и CDN API (PROPFIND, REPORT, PURGE). Работает так же, как Get и Post:
+// применяются query-параметры, заголовки и тело, заданное через Body.
+// Если baseURL не задан, path должен быть абсолютным URL.
+func (c *Client) Do(ctx context.Context, method, path string) *Response {
+	return c.do(ctx, method, path, &c.requestState)
+}
+
+// do выполняет HTTP-запрос с любым методом (GET, POST и др.) с параметрами r.
+func (c *Client) do(ctx context.Context, method, path string, r *requestState) *Response {
+	resp := c.exchange(ctx, method, path, r)
+	if r.follow {
+		return c.followLocation(ctx, r, resp)
+	}
+
+	return resp
+}
+
+// exchange отправляет запрос с параметрами r и получает ответ, включая повторы и повторную авторизацию.
+func (c *Client) exchange(ctx context.Context, method, path string, r *requestState) *Response { //nolint:cyclop
+	if r.err != nil {
+		return &Response{err: r.err}
+	}
+
+	ctx, cancel := c.withDefaultTimeout(ctx)
+	defaultTimeout := cancel != nil
+
+	if r.op != "" {
+		ctx, cancel = c.ops.track(ctx, r.op, cancel)
+	}
+
+	ctx = c.withRequestID(ctx)
+
+	defer func() {
+		if cancel != nil {
+			cancel()
+		}
+	}()
+
+	prof := c.newProfiler(method)
+	defer prof.release()
+
+	build := prof.start()
+
+	fullURL, err := c.fullURL(path, r)
 	if err != nil {
 		return &Response{err: err}
 	}
 
-	var body io.Reader
-	if c.body != nil {
-		b, err := json.Marshal(c.body)
-		if err != nil {
-			return &Response{err: err}
-		}
+	prof.setURL(fullURL)
 
-		body = bytes.NewReader(b)
+	if c.negative != nil && method == http.MethodGet {
+		if e, ok := c.negative.get(fullURL); ok {
+			return &Response{err: e.httpError(c, method, fullURL)}
+		}
 	}
 
-	req, err := http.NewRequestWithContext(ctx, method, fullURL, body)
+	req, err := c.newRequest(ctx, method, fullURL, r)
 	if err != nil {
 		return &Response{err: err}
 	}
 
-	// Если есть body, Content-Type JSON по умолчанию (если не переопределили)
-	if c.body != nil && req.Header.Get("Content-Type") == "" {
-		req.Header.Set("Content-Type", "application/json")
+	prof.end(PhaseBuild, build)
+
+	if c.precheck != nil && !c.precheck(req) {
+		return &Response{err: notFound(method, fullURL)}
 	}
 
-	for k, v := range c.headers {
-		for _, vv := range v {
-			req.Header.Add(k, vv)
-		}
+	client := c.client
+	if r.once != nil {
+		client = r.once
+	}
+
+	send := prof.start()
+
+	ctx, trace := withTrace(req.Context())
+	req = req.WithContext(ctx)
+
+	mt := c.newMeter(req)
+	defer mt.release()
+
+	resp, err := c.execute(client, req)
+	if err == nil && resp.StatusCode == http.StatusUnauthorized && c.relogin != nil {
+		resp, err = c.reauth(client, req, r, resp)
+	}
+
+	if err == nil && c.renegotiable(resp) {
+		resp, err = c.renegotiate(client, req, r, resp)
+	}
+
+	trace.done()
+	mt.received(resp)
+	prof.end(PhaseSend, send)
+
+	if err != nil && c.offline != nil {
+		err = c.offline.enqueue(req, err)
 	}
 
-	resp, err := c.client.Do(req)
 	if err != nil {
-		return &Response{err: err}
+		return &Response{err: err, trace: trace}
 	}
 
+	noContent := bodiless(method, resp.StatusCode)
+
 	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
 		defer resp.Body.Close()
 
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return &Response{err: err}
+		var body []byte
+		if !noContent {
+			if body, err = io.ReadAll(resp.Body); err != nil {
+				return &Response{err: err, trace: trace}
+			}
+		}
+
+		mt.read(len(body))
+
+		if c.negative != nil && c.negative.cacheable(method, resp.StatusCode) {
+			c.negative.put(fullURL, resp, body, req.Header.Get(c.requestIDHeader()))
 		}
 
 		return &Response{
-			err: &HTTPError{
+			trace:     trace,
+			noContent: noContent,
+			sent:      resp.Request,
+			err: c.withAPIError(&HTTPError{
 				StatusCode: resp.StatusCode,
 				Status:     resp.Status,
 				Method:     method,
 				URL:        fullURL,
+				Header:     resp.Header,
 				Body:       body,
-			},
+				RequestID:  req.Header.Get(c.requestIDHeader()),
+			}),
+		}
+	}
+
+	if c.decrypter != nil && !noContent {
+		if err := c.decrypt(resp); err != nil {
+			return &Response{err: err, trace: trace}
+		}
+	}
+
+	// Сбросить body и разовый http-клиент, чтобы они не попали случайно в следующий запрос.
+	// Пустые поля не перезаписываются, чтобы запросы без них не изменяли клиент.
+	if r.body != nil {
+		r.body = nil
+	}
+
+	if r.once != nil {
+		r.once = nil
+	}
+
+	if noContent {
+		// Тела нет, поэтому соединение и контекст запроса освобождаются сразу, без оберток тела
+		resp.Body.Close()
+		resp.Body = http.NoBody
+
+		return &Response{
+			resp: resp, decoders: c.decoders, codecs: c.codecs, defaultTimeout: defaultTimeout, trace: trace,
+			noContent: true,
+		}
+	}
+
+	if c.spool != nil {
+		body, err := c.spool.spool(resp.Body)
+		if err != nil {
+			return &Response{err: err, trace: trace}
+		}
+
+		// Тело прочитано целиком, поэтому контекст запроса больше не нужен
+		resp.Body = body
+	}
+
+	if cancel != nil && c.spool == nil {
+		// Контекст DefaultTimeout и Op нужен до конца чтения тела
+		resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+		cancel = nil
+	}
+
+	resp.Body = mt.wrap(prof.wrap(resp.Body))
+
+	return &Response{
+		resp: resp, decoders: c.decoders, codecs: c.codecs, defaultTimeout: defaultTimeout, prof: prof, trace: trace,
+	}
+}
+
+// authorize выставляет заголовок Authorization из BearerTokenFunc, если она задана.
+func (c *Client) authorize(h http.Header) {
+	if c.token == nil {
+		return
+	}
+
+	if token := c.token(); token != "" {
+		h.Set("Authorization", "Bearer "+token)
+	}
+}
+
+// newRequest собирает *http.Request с телом, заголовками и настройками клиента и параметров r.
+func (c *Client) newRequest(ctx context.Context, method, fullURL string, r *requestState) (*http.Request, error) {
+	var (
+		body   io.Reader
+		sealed http.Header
+	)
+
+	if r.body != nil {
+		sealed = make(http.Header)
+
+		var err error
+		if body, err = c.encodeBody(r.body, sealed); err != nil {
+			return nil, err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, fullURL, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if stream, ok := body.(*jsonStream); ok {
+		req.GetBody = func() (io.ReadCloser, error) { return newJSONStream(stream.v), nil }
+	}
+
+	// Content-Type тела используется по умолчанию, если его не переопределили через Header
+	if _, ok := r.headers["Content-Type"]; ok {
+		sealed.Del("Content-Type")
+	}
+
+	for k, v := range sealed {
+		req.Header[k] = v
+	}
+
+	// Ключи копируются как есть, чтобы не потерять регистр заголовков из HeaderExact. Срезы значений
+	// не копируются: их емкость ограничена длиной, поэтому Add в запросе не затронет заголовки r
+	for k, v := range r.headers {
+		if cur, ok := req.Header[k]; ok {
+			req.Header[k] = append(cur, v...)
+		} else {
+			req.Header[k] = v[:len(v):len(v)]
 		}
 	}
 
-	// Сбросить body, чтобы оно не попало случайно в следующий запрос
-	c.body = nil
+	req.Close = r.closeConn
+
+	if (r.identity || c.compat.Identity) && req.Header.Get("Accept-Encoding") == "" {
+		req.Header.Set("Accept-Encoding", "identity")
+	}
+
+	if len(c.accept) != 0 && req.Header.Get("Accept-Encoding") == "" {
+		req.Header.Set("Accept-Encoding", strings.Join(c.accept, ", "))
+	}
+
+	if r.resume != "" {
+		applyResume(req.Header, r.resume)
+	}
+
+	c.applyAccept(req.Header)
+	c.costCenter.apply(req.Header)
+	c.authorize(req.Header)
+	c.applyLocale(req)
+	c.applyRequestID(req)
 
-	return &Response{resp: resp}
+	if err := c.compat.fixLength(req); err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// notFound возвращает синтетическую ошибку 404 для запросов, отклоненных Precheck.
+func notFound(method, url string) *HTTPError {
+	return &HTTPError{
+		StatusCode: http.StatusNotFound,
+		Status:     "404 Not Found",
+		Method:     method,
+		URL:        url,
+		Body:       []byte{},
+	}
+}
+
+// bodiless сообщает, что у ответа на запрос method с кодом status не может быть тела (RFC 9110).
+func bodiless(method string, status int) bool {
+	return method == http.MethodHead || status == http.StatusNoContent || status == http.StatusNotModified
+}
+
+// decrypt заменяет тело ответа на расшифрованное.
+func (c *Client) decrypt(resp *http.Response) error {
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	plaintext, err := c.decrypter.Decrypt(data)
+	if err != nil {
+		return err
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(plaintext))
+	resp.ContentLength = int64(len(plaintext))
+	resp.Header.Del("Content-Length")
+
+	return nil
 }
 
 // fullURL формирует финальный URL с учетом baseURL, path и query-параметров.
-// Если baseURL пустой, path должен быть абсолютным URL.
-// Query-параметры из path будут дополнены параметрами из клиента (Query).
-func (c *Client) fullURL(path string) (string, error) {
-	if c.baseURL == "" {
+// Если baseURL пустой или path — абсолютный URL со схемой и хостом, path используется как есть.
+// Query-параметры из path будут дополнены параметрами из r (Query и RawQuery).
+func (c *Client) fullURL(path string, r *requestState) (string, error) {
+	if c.baseURL == "" || isAbsURL(path) {
 		u, err := url.Parse(path)
 		if err != nil {
 			return "", fmt.Errorf("invalid URL: %w", err)
 		}
 
-		q := u.Query()
-
-		for k, vals := range c.params {
-			for _, v := range vals {
-				q.Add(k, v)
-			}
-		}
-
-		u.RawQuery = q.Encode()
+		u.RawQuery = r.encodeQuery(u.RawQuery, c.ordered)
 
 		return u.String(), nil
 	}
@@ -216,7 +851,14 @@ func (c *Client) fullURL(path string) (string, error) {
 	}
 
 	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + strings.TrimPrefix(path, "/")
-	u.RawQuery = c.params.Encode()
+	u.RawQuery = r.encodeQuery("", c.ordered)
 
 	return u.String(), nil
 }
+
+// isAbsURL сообщает, что path — абсолютный URL со схемой и хостом, который не нужно соединять с baseURL.
+func isAbsURL(path string) bool {
+	u, err := url.Parse(path)
+
+	return err == nil && u.IsAbs() && u.Host != ""
+}
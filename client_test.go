@@ -1,9 +1,25 @@
 package fluent_test
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"encoding/json"
+	"encoding/xml"
 	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
 	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -135,3 +151,2010 @@ func TestJSONPlaceholder_Error_IncludesBodySnippet(t *testing.T) {
 		t.Fatal("expected Body to be non-nil")
 	}
 }
+
+type requestID string
+
+func (id requestID) String() string { return "req-" + string(id) }
+
+func TestClient_HeaderStruct(t *testing.T) {
+	t.Parallel()
+
+	var got http.Header
+
+	srv := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		got = r.Header
+	}))
+	t.Cleanup(srv.Close)
+
+	err := fluent.New().
+		Header("X-Channel", "web").
+		HeaderStruct(struct {
+			Channel   string        `header:"X-Channel"`
+			RequestID requestID     `header:"X-Request-Id"`
+			Timeout   time.Duration `header:"X-Timeout"`
+			Scopes    []string      `header:"X-Scope"`
+			Optional  string        `header:"X-Optional,omitempty"`
+		}{
+			Channel:   "mobile",
+			RequestID: "1",
+			Timeout:   5 * time.Second,
+			Scopes:    []string{"read", "write"},
+		}).
+		Get(context.Background(), srv.URL).
+		Error()
+	if err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+
+	if got.Get("X-Channel") != "mobile" || len(got.Values("X-Channel")) != 1 {
+		t.Fatalf("expected X-Channel to be replaced, got %q", got.Values("X-Channel"))
+	}
+
+	if got.Get("X-Request-Id") != "req-1" || got.Get("X-Timeout") != "5" {
+		t.Fatalf("unexpected headers: %v", got)
+	}
+
+	if len(got.Values("X-Scope")) != 2 {
+		t.Fatalf("expected 2 X-Scope values, got %q", got.Values("X-Scope"))
+	}
+
+	if _, ok := got["X-Optional"]; ok {
+		t.Fatal("expected X-Optional to be omitted")
+	}
+}
+
+func TestClient_HeaderStruct_InvalidValue(t *testing.T) {
+	t.Parallel()
+
+	err := fluent.New().
+		HeaderStruct(struct {
+			Bad map[string]string `header:"X-Bad"`
+		}{Bad: map[string]string{}}).
+		Get(context.Background(), "http://127.0.0.1:0").
+		Error()
+	if !errors.Is(err, fluent.ErrUnsupportedTarget) {
+		t.Fatalf("expected ErrUnsupportedTarget, got: %v", err)
+	}
+}
+
+type countingClient struct {
+	calls int
+}
+
+func (c *countingClient) Do(req *http.Request) (*http.Response, error) {
+	c.calls++
+
+	return http.DefaultClient.Do(req)
+}
+
+func TestClient_WithHTTPClient_OnlyNextRequest(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	t.Cleanup(srv.Close)
+
+	base, special := &countingClient{}, &countingClient{}
+	c := fluent.New().BaseURL(srv.URL).HTTPClient(base)
+
+	if err := c.WithHTTPClient(special).Get(context.Background(), "/export").Error(); err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+
+	if err := c.Get(context.Background(), "/posts").Error(); err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+
+	if special.calls != 1 || base.calls != 1 {
+		t.Fatalf("expected one call per client, got special=%d base=%d", special.calls, base.calls)
+	}
+}
+
+func TestClient_NegativeCache(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		http.NotFound(w, nil)
+	}))
+	t.Cleanup(srv.Close)
+
+	c := fluent.New().BaseURL(srv.URL).NegativeCache(time.Minute)
+
+	for range 3 {
+		var he *fluent.HTTPError
+		if err := c.Get(context.Background(), "/keys/missing").Error(); !errors.As(err, &he) || he.StatusCode != http.StatusNotFound {
+			t.Fatalf("expected 404 HTTPError, got: %v", err)
+		}
+	}
+
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("expected 1 upstream call, got %d", n)
+	}
+}
+
+func TestClient_NegativeCache_ErrorShape(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"type":"about:blank","title":"Not Found","status":404,"detail":"no such key"}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	c := fluent.New().BaseURL(srv.URL).RequestID("").NegativeCache(time.Minute)
+
+	errs := make([]*fluent.HTTPError, 2)
+
+	for i := range errs {
+		if err := c.Get(context.Background(), "/keys/missing").Error(); !errors.As(err, &errs[i]) {
+			t.Fatalf("expected HTTPError, got: %v", err)
+		}
+	}
+
+	live, cached := errs[0], errs[1]
+
+	if cached.RequestID == "" || cached.RequestID != live.RequestID {
+		t.Fatalf("expected cached RequestID %q, got %q", live.RequestID, cached.RequestID)
+	}
+
+	if cached.Problem == nil || cached.Problem.Detail != live.Problem.Detail {
+		t.Fatalf("expected cached Problem %+v, got %+v", live.Problem, cached.Problem)
+	}
+
+	var problem *fluent.ProblemDetails
+	if !errors.As(error(cached), &problem) || !errors.Is(cached, fluent.ErrNotOK) {
+		t.Fatalf("expected cached error to expose Problem and ErrNotOK, got: %v", cached)
+	}
+}
+
+func TestClient_NegativeCache_Clone(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer b" {
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	base := fluent.New().BaseURL(srv.URL).NegativeCache(time.Minute)
+
+	if err := base.Clone().BearerToken("a").Get(context.Background(), "/keys/1").Error(); err == nil {
+		t.Fatal("expected 404 for tenant a")
+	}
+
+	if err := base.Clone().BearerToken("b").Get(context.Background(), "/keys/1").Error(); err != nil {
+		t.Fatalf("expected tenant b not to see the cached 404, got: %v", err)
+	}
+}
+
+func TestClient_Precheck_SkipsKnownMissing(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		calls.Add(1)
+	}))
+	t.Cleanup(srv.Close)
+
+	known := map[string]bool{"/keys/1": true}
+
+	c := fluent.New().
+		BaseURL(srv.URL).
+		Precheck(func(req *http.Request) bool { return known[req.URL.Path] })
+
+	if err := c.Get(context.Background(), "/keys/1").Error(); err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+
+	var he *fluent.HTTPError
+	if err := c.Get(context.Background(), "/keys/2").Error(); !errors.As(err, &he) || he.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected synthetic 404, got: %v", err)
+	}
+
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("expected 1 upstream call, got %d", n)
+	}
+}
+
+func TestClient_CloseConnection(t *testing.T) {
+	t.Parallel()
+
+	var closes []bool
+
+	srv := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		closes = append(closes, r.Close)
+	}))
+	t.Cleanup(srv.Close)
+
+	c := fluent.New().BaseURL(srv.URL)
+
+	for _, f := range []func(){func() { c.CloseConnection(true) }, func() { c.Reset() }} {
+		f()
+
+		if err := c.Get(context.Background(), "/").Error(); err != nil {
+			t.Fatalf("Get returned error: %v", err)
+		}
+	}
+
+	if len(closes) != 2 || !closes[0] || closes[1] {
+		t.Fatalf("expected Connection: close only before Reset, got %v", closes)
+	}
+}
+
+func TestClient_CostCenter(t *testing.T) {
+	t.Parallel()
+
+	var got []string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get(fluent.DefaultCostCenterHeader), r.Header.Get("X-Billing-Tag"))
+	}))
+	t.Cleanup(srv.Close)
+
+	var labels []string
+
+	c := fluent.New().BaseURL(srv.URL).CostCenter("search").Metrics(labeledMetrics(func(l fluent.RequestLabels) {
+		labels = append(labels, l.CostCenter)
+	}))
+
+	if _, err := c.Reset().Get(context.Background(), "/").Raw(); err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+
+	if _, err := c.CostCenterHeader("X-Billing-Tag").Get(context.Background(), "/").Raw(); err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+
+	if strings.Join(got, ",") != "search,,,search" {
+		t.Fatalf("unexpected cost center headers: %q", got)
+	}
+
+	if strings.Join(labels, ",") != "search,search" {
+		t.Fatalf("unexpected cost center labels: %q", labels)
+	}
+}
+
+// labeledMetrics передает в функцию метки каждого запроса.
+type labeledMetrics func(labels fluent.RequestLabels)
+
+func (f labeledMetrics) ObserveRequest(string, string, int, time.Duration, int64) {
+	panic("ObserveRequestLabels must be used")
+}
+
+func (f labeledMetrics) ObserveRequestLabels(_, _ string, _ int, _ time.Duration, _ int64, labels fluent.RequestLabels) {
+	f(labels)
+}
+
+func TestClient_Quota(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	t.Cleanup(srv.Close)
+
+	var exceeded []int
+
+	q := &fluent.Quota{
+		Limit:           2,
+		Window:          time.Hour,
+		Key:             func(*http.Request) string { return "key-1" },
+		OnQuotaExceeded: func(_ string, used int) { exceeded = append(exceeded, used) },
+		Block:           true,
+	}
+
+	c := fluent.New().BaseURL(srv.URL).Quota(q)
+
+	for i := range 3 {
+		err := c.Get(context.Background(), "/").Error()
+		if i < 2 && err != nil {
+			t.Fatalf("request %d returned error: %v", i, err)
+		}
+
+		if i == 2 && !errors.Is(err, fluent.ErrQuotaExceeded) {
+			t.Fatalf("expected ErrQuotaExceeded, got: %v", err)
+		}
+	}
+
+	if q.Used("key-1") != 2 || len(exceeded) != 1 || exceeded[0] != 3 {
+		t.Fatalf("unexpected quota state: used=%d exceeded=%v", q.Used("key-1"), exceeded)
+	}
+}
+
+func TestClient_Throttle_AIMD(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	l := &fluent.AdaptiveLimiter{Rate: 100, Increase: 10}
+	c := fluent.New().BaseURL(srv.URL).Throttle(l)
+
+	_ = c.Get(context.Background(), "/").Error()
+
+	if r := l.CurrentRate(); r != 50 {
+		t.Fatalf("expected rate to halve to 50, got %v", r)
+	}
+
+	if err := c.Get(context.Background(), "/").Error(); err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+
+	if r := l.CurrentRate(); r <= 50 || r > 100 {
+		t.Fatalf("expected rate to ramp up above 50, got %v", r)
+	}
+}
+
+func TestAdaptiveLimiter_Defaults(t *testing.T) {
+	t.Parallel()
+
+	for name, tt := range map[string]struct {
+		limiter *fluent.AdaptiveLimiter
+		want    float64
+	}{
+		"zero value":    {&fluent.AdaptiveLimiter{}, fluent.DefaultAdaptiveRate},
+		"max below min": {&fluent.AdaptiveLimiter{Rate: 5, Min: 2, Max: 1}, 2},
+	} {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+
+		for range 2 {
+			if err := tt.limiter.Wait(ctx); err != nil {
+				t.Fatalf("%s: Wait returned error: %v", name, err)
+			}
+		}
+
+		cancel()
+
+		if r := tt.limiter.CurrentRate(); r != tt.want {
+			t.Fatalf("%s: expected rate %v, got %v", name, tt.want, r)
+		}
+	}
+}
+
+func TestClient_ClockSkew(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Date", time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat))
+	}))
+	t.Cleanup(srv.Close)
+
+	var drift time.Duration
+
+	skew := &fluent.ClockSkew{OnDrift: func(_ string, d time.Duration) { drift = d }}
+
+	if err := fluent.New().ClockSkew(skew).Get(context.Background(), srv.URL).Error(); err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+
+	if drift > -59*time.Second || drift < -61*time.Second || skew.Offset() != drift {
+		t.Fatalf("expected about -1m drift, got %v (offset %v)", drift, skew.Offset())
+	}
+}
+
+func TestClient_Do_CustomMethod(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_, _ = w.Write([]byte(r.Method + " " + string(body)))
+	}))
+	t.Cleanup(srv.Close)
+
+	got, err := fluent.New().
+		BaseURL(srv.URL).
+		Body(map[string]any{"depth": 1}).
+		Do(context.Background(), "PROPFIND", "/files").
+		Raw()
+	if err != nil {
+		t.Fatalf("Do returned error: %v", err)
+	}
+
+	if string(got) != `PROPFIND {"depth":1}` {
+		t.Fatalf("unexpected response: %q", got)
+	}
+}
+
+func TestClient_Retry_ReplaysBody(t *testing.T) {
+	t.Parallel()
+
+	var (
+		calls  atomic.Int32
+		bodies []string
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	err := fluent.New().
+		BaseURL(srv.URL).
+		RetryPolicy(fluent.RetryPolicy{MaxRetries: 3, MinBackoff: time.Millisecond}).
+		Body(map[string]any{"id": 1}).
+		Post(context.Background(), "/jobs").
+		Error()
+	if err != nil {
+		t.Fatalf("Post returned error: %v", err)
+	}
+
+	if len(bodies) != 3 || bodies[0] != `{"id":1}` || bodies[2] != bodies[0] {
+		t.Fatalf("expected the body to be replayed 3 times, got %q", bodies)
+	}
+}
+
+func TestClient_Retry_RetryAfterCap(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Retry-After", r.URL.Query().Get("after"))
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(srv.Close)
+
+	c := fluent.New().BaseURL(srv.URL).RetryPolicy(fluent.RetryPolicy{MaxRetries: 3, MaxBackoff: time.Second})
+
+	start := time.Now()
+
+	var httpErr *fluent.HTTPError
+	if err := c.Request().Query("after", "86400").Get(context.Background(), "/").Error(); !errors.As(err, &httpErr) ||
+		httpErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 to be returned, got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	if err := c.Request().Query("after", "1").Get(ctx, "/").Error(); !errors.As(err, &httpErr) {
+		t.Fatalf("expected 503 when Retry-After exceeds the deadline, got %v", err)
+	}
+
+	if calls.Load() != 2 || time.Since(start) > 400*time.Millisecond {
+		t.Fatalf("expected no retries, got %d calls in %v", calls.Load(), time.Since(start))
+	}
+}
+
+func TestClient_Retry_BodyReads(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if calls.Add(1) == 1 {
+			// Обещаем больше байт, чем отправляем, чтобы чтение тела оборвалось
+			w.Header().Set("Content-Length", "100")
+			_, _ = w.Write([]byte(`{"id"`))
+
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"id":1}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	got, err := fluent.New().
+		RetryPolicy(fluent.RetryPolicy{MaxRetries: 1, MinBackoff: time.Millisecond, BodyReads: true}).
+		Get(context.Background(), srv.URL).
+		Raw()
+	if err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+
+	if string(got) != `{"id":1}` || calls.Load() != 2 {
+		t.Fatalf("expected retry after truncated body, got %q after %d calls", got, calls.Load())
+	}
+}
+
+func TestClient_Retry_OffByDefault(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	t.Cleanup(srv.Close)
+
+	if err := fluent.New().Get(context.Background(), srv.URL).Error(); !errors.Is(err, fluent.ErrNotOK) {
+		t.Fatalf("expected ErrNotOK, got: %v", err)
+	}
+
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("expected 1 call, got %d", n)
+	}
+}
+
+func TestClient_Use_Order(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Header.Get("X-Trace")))
+	}))
+	t.Cleanup(srv.Close)
+
+	var events []string
+
+	layer := func(name string) fluent.Middleware {
+		return func(next fluent.RoundFunc) fluent.RoundFunc {
+			return func(req *http.Request) (*http.Response, error) {
+				events = append(events, name+">")
+
+				req = req.Clone(req.Context())
+				req.Header.Add("X-Trace", name)
+
+				resp, err := next(req)
+
+				events = append(events, "<"+name)
+
+				return resp, err
+			}
+		}
+	}
+
+	got, err := fluent.New().Use(layer("a"), layer("b")).Get(context.Background(), srv.URL).Raw()
+	if err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+
+	if string(got) != "a" || strings.Join(events, " ") != "a> b> <b <a" {
+		t.Fatalf("unexpected middleware order: body=%q events=%v", got, events)
+	}
+}
+
+func TestClient_Request_Concurrent(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_, _ = w.Write([]byte(r.Header.Get("X-Tenant") + " " + r.URL.Query().Get("n") + " " + string(body)))
+	}))
+	t.Cleanup(srv.Close)
+
+	c := fluent.New().BaseURL(srv.URL).Header("X-Tenant", "acme")
+
+	var wg sync.WaitGroup
+
+	for i := range 20 {
+		wg.Go(func() {
+			n := strconv.Itoa(i)
+
+			got, err := c.Request().Query("n", n).Body(n).Post(context.Background(), "/").Raw()
+			if err != nil {
+				t.Errorf("Post returned error: %v", err)
+
+				return
+			}
+
+			if want := "acme " + n + ` "` + n + `"`; string(got) != want {
+				t.Errorf("expected %q, got %q", want, got)
+			}
+		})
+	}
+
+	wg.Wait()
+
+	got, err := c.Get(context.Background(), "/").Raw()
+	if err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+
+	if string(got) != "acme  " {
+		t.Fatalf("expected client to stay unchanged, got %q", got)
+	}
+}
+
+func TestClient_Request_CopyOnWrite(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.URL.RawQuery + " " + strings.Join(r.Header.Values("X-Mode"), ",")))
+	}))
+	t.Cleanup(srv.Close)
+
+	c := fluent.New().BaseURL(srv.URL).Query("page", "1").Header("X-Mode", "a")
+
+	shared := c.Request()
+	own := c.Request().SetQuery("page", "2").Header("X-Mode", "b")
+
+	c.Query("sort", "name").Header("X-Mode", "c")
+
+	tests := []struct {
+		name string
+		req  *fluent.Request
+		want string
+	}{
+		{name: "shared with client", req: shared, want: "page=1 a"},
+		{name: "own copy", req: own, want: "page=2 a,b"},
+		{name: "after client change", req: c.Request(), want: "page=1&sort=name a,c"},
+	}
+
+	for _, tt := range tests {
+		got, err := tt.req.Get(context.Background(), "/").Raw()
+		if err != nil {
+			t.Fatalf("%s: Get returned error: %v", tt.name, err)
+		}
+
+		if string(got) != tt.want {
+			t.Fatalf("%s: expected %q, got %q", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestClient_SetAndDel(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.URL.RawQuery + " " + strings.Join(r.Header.Values("X-Mode"), ",") + " " + r.Header.Get("X-Debug")))
+	}))
+	t.Cleanup(srv.Close)
+
+	c := fluent.New().
+		BaseURL(srv.URL).
+		Query("page", "1").
+		Query("sort", "name").
+		Header("X-Mode", "a").
+		Header("X-Mode", "b").
+		Header("X-Debug", "1")
+
+	got, err := c.SetQuery("page", "2").
+		DelQuery("sort").
+		SetHeader("X-Mode", "c").
+		DelHeader("X-Debug").
+		Get(context.Background(), "/").
+		Raw()
+	if err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+
+	if string(got) != "page=2 c " {
+		t.Fatalf("unexpected request: %q", got)
+	}
+}
+
+func TestClient_Clone(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.URL.Path + "?" + r.URL.RawQuery + " " + strings.Join(r.Header.Values("X-Auth"), ",")))
+	}))
+	t.Cleanup(srv.Close)
+
+	base := fluent.New().BaseURL(srv.URL).Header("X-Auth", "token").Query("v", "1")
+
+	orders := base.Clone().BaseURL(srv.URL+"/orders").Header("X-Auth", "extra").SetQuery("v", "2")
+
+	got, err := orders.Get(context.Background(), "/").Raw()
+	if err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+
+	if string(got) != "/orders/?v=2 token,extra" {
+		t.Fatalf("unexpected clone request: %q", got)
+	}
+
+	got, err = base.Get(context.Background(), "/").Raw()
+	if err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+
+	if string(got) != "/?v=1 token" {
+		t.Fatalf("expected base client to stay unchanged, got %q", got)
+	}
+}
+
+func TestClient_HeaderExact(t *testing.T) {
+	t.Parallel()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen returned error: %v", err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+
+	raw := make(chan string, 1)
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var sb strings.Builder
+
+		br := bufio.NewReader(conn)
+		for {
+			line, err := br.ReadString('\n')
+			if err != nil || line == "\r\n" {
+				break
+			}
+
+			sb.WriteString(line)
+		}
+
+		raw <- sb.String()
+
+		_, _ = conn.Write([]byte("HTTP/1.1 204 No Content\r\nConnection: close\r\n\r\n"))
+	}()
+
+	err = fluent.New().
+		HeaderExact("x-api-KEY", "secret").
+		Header("x-trace", "1").
+		Get(context.Background(), "http://"+ln.Addr().String()+"/").
+		Error()
+	if err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+
+	got := <-raw
+	if !strings.Contains(got, "\r\nx-api-KEY: secret\r\n") || !strings.Contains(got, "\r\nX-Trace: 1\r\n") {
+		t.Fatalf("unexpected header casing:\n%s", got)
+	}
+}
+
+func TestClient_Auth(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Header.Get("Authorization")))
+	}))
+	t.Cleanup(srv.Close)
+
+	got, err := fluent.New().BasicAuth("Aladdin", "open sesame").Get(context.Background(), srv.URL).Raw()
+	if err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+
+	if string(got) != "Basic QWxhZGRpbjpvcGVuIHNlc2FtZQ==" {
+		t.Fatalf("unexpected basic auth: %q", got)
+	}
+
+	got, err = fluent.New().BearerToken("abc").Get(context.Background(), srv.URL).Raw()
+	if err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+
+	if string(got) != "Bearer abc" {
+		t.Fatalf("unexpected bearer token: %q", got)
+	}
+
+	var n atomic.Int32
+
+	c := fluent.New().BearerTokenFunc(func() string { return "t" + strconv.Itoa(int(n.Add(1))) })
+
+	for _, want := range []string{"Bearer t1", "Bearer t2"} {
+		got, err := c.Get(context.Background(), srv.URL).Raw()
+		if err != nil {
+			t.Fatalf("Get returned error: %v", err)
+		}
+
+		if string(got) != want {
+			t.Fatalf("expected %q, got %q", want, got)
+		}
+	}
+}
+
+func TestClient_RawQuery(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.URL.RawQuery))
+	}))
+	t.Cleanup(srv.Close)
+
+	got, err := fluent.New().
+		BaseURL(srv.URL).
+		RawQuery("z=1&a=%7e+b").
+		Query("sig", "x y").
+		Get(context.Background(), "/").
+		Raw()
+	if err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+
+	if string(got) != "z=1&a=%7e+b&sig=x+y" {
+		t.Fatalf("unexpected query: %q", got)
+	}
+}
+
+func TestClient_OrderedQuery(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.URL.RawQuery))
+	}))
+	t.Cleanup(srv.Close)
+
+	got, err := fluent.New().
+		OrderedQuery(true).
+		Query("z", "1").
+		Query("b", "x y").
+		Query("z", "2").
+		Query("drop", "1").
+		SetQuery("b", "3").
+		DelQuery("drop").
+		Get(context.Background(), srv.URL+"/?y=0&a=0").
+		Raw()
+	if err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+
+	if string(got) != "y=0&a=0&z=1&z=2&b=3" {
+		t.Fatalf("unexpected query: %q", got)
+	}
+}
+
+func TestClient_DefaultTimeout(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			select {
+			case <-time.After(time.Second):
+			case <-r.Context().Done():
+			}
+		}
+
+		_, _ = w.Write([]byte("ok"))
+	}))
+	t.Cleanup(srv.Close)
+
+	c := fluent.New().BaseURL(srv.URL).DefaultTimeout(50 * time.Millisecond)
+
+	if err := c.Get(context.Background(), "/slow").Error(); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected DeadlineExceeded, got: %v", err)
+	}
+
+	resp := c.Get(context.Background(), "/")
+	if got, err := resp.Raw(); err != nil || string(got) != "ok" || !resp.UsedDefaultTimeout() {
+		t.Fatalf("expected body read under default timeout, got %q, %v", got, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	if resp := c.Get(ctx, "/"); resp.Error() != nil || resp.UsedDefaultTimeout() {
+		t.Fatalf("expected caller deadline to win, got %v", resp.Error())
+	}
+}
+
+func TestClient_CancelOp(t *testing.T) {
+	t.Parallel()
+
+	started := make(chan struct{}, 2)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+
+		select {
+		case <-time.After(5 * time.Second):
+		case <-r.Context().Done():
+		}
+
+		_, _ = w.Write([]byte("ok"))
+	}))
+	t.Cleanup(srv.Close)
+
+	c := fluent.New().BaseURL(srv.URL)
+
+	errs := make(chan error, 2)
+
+	for range 2 {
+		go func() {
+			errs <- c.Request().Op("reports.export").Get(context.Background(), "/").Error()
+		}()
+	}
+
+	<-started
+	<-started
+
+	if n := c.CancelOp("reports.export"); n != 2 {
+		t.Fatalf("expected 2 canceled requests, got %d", n)
+	}
+
+	for range 2 {
+		if err := <-errs; !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected context.Canceled, got: %v", err)
+		}
+	}
+
+	if n := c.CancelOp("reports.export"); n != 0 {
+		t.Fatalf("expected no in-flight requests, got %d", n)
+	}
+}
+
+func TestClient_BodyRaw(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_, _ = w.Write([]byte(strings.Join(r.Header.Values("Content-Type"), ",") + " " + string(body)))
+	}))
+	t.Cleanup(srv.Close)
+
+	tests := []struct {
+		name string
+		req  *fluent.Request
+		want string
+	}{
+		{
+			name: "raw",
+			req:  fluent.New().Request().BodyRaw([]byte{'p', 'b'}),
+			want: "application/octet-stream pb",
+		},
+		{
+			name: "string with explicit Content-Type",
+			req:  fluent.New().Request().BodyString("a,b\n").Header("Content-Type", "text/csv"),
+			want: "text/csv a,b\n",
+		},
+		{
+			name: "reader",
+			req:  fluent.New().Request().BodyReader(strings.NewReader("{}\n{}\n"), "application/x-ndjson"),
+			want: "application/x-ndjson {}\n{}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := tt.req.Post(context.Background(), srv.URL).Raw()
+			if err != nil {
+				t.Fatalf("Post returned error: %v", err)
+			}
+
+			if string(got) != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestClient_BodyJSONStream(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var items []map[string]int
+		if err := json.NewDecoder(r.Body).Decode(&items); err != nil || len(items) != 1000 {
+			http.Error(w, "bad body", http.StatusBadRequest)
+
+			return
+		}
+
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+
+			return
+		}
+
+		_, _ = fmt.Fprintf(w, "%s %v %d", r.Header.Get("Content-Type"), r.TransferEncoding, items[999]["id"])
+	}))
+	t.Cleanup(srv.Close)
+
+	items := make([]map[string]int, 1000)
+	for i := range items {
+		items[i] = map[string]int{"id": i}
+	}
+
+	c := fluent.New().RetryPolicy(fluent.RetryPolicy{MaxRetries: 1, MinBackoff: time.Millisecond})
+
+	got, err := c.Request().BodyJSONStream(items).Do(context.Background(), http.MethodPut, srv.URL).Raw()
+	if err != nil {
+		t.Fatalf("Do returned error: %v", err)
+	}
+
+	if want := "application/json [chunked] 999"; string(got) != want || calls.Load() != 2 {
+		t.Fatalf("expected %q after 2 calls, got %q after %d", want, got, calls.Load())
+	}
+}
+
+func TestClient_RequestID(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu  sync.Mutex
+		ids []string
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		ids = append(ids, r.Header.Get("X-Correlation-ID"))
+		first := len(ids) == 1
+		mu.Unlock()
+
+		if first || r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	sent := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return slices.Clone(ids)
+	}
+
+	c := fluent.New().BaseURL(srv.URL).RequestID("X-Correlation-ID").
+		RetryPolicy(fluent.RetryPolicy{MaxRetries: 1, MinBackoff: time.Millisecond})
+
+	if err := c.Get(context.Background(), "/").Error(); err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+
+	uuid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	if ids := sent(); len(ids) != 2 || !uuid.MatchString(ids[0]) || ids[0] != ids[1] {
+		t.Fatalf("expected the same generated id on retry, got %q", ids)
+	}
+
+	ctx := fluent.WithRequestID(context.Background(), "incoming-42")
+
+	var httpErr *fluent.HTTPError
+	if err := c.Get(ctx, "/fail").Error(); !errors.As(err, &httpErr) || httpErr.RequestID != "incoming-42" ||
+		!strings.Contains(err.Error(), "request id incoming-42") {
+		t.Fatalf("expected request id in HTTPError, got %v", err)
+	}
+
+	if ids := sent(); ids[len(ids)-1] != "incoming-42" {
+		t.Fatalf("expected propagated id, got %q", ids)
+	}
+}
+
+type metricsFunc func(method, host string, status int, duration time.Duration, size int64)
+
+func (f metricsFunc) ObserveRequest(method, host string, status int, duration time.Duration, size int64) {
+	f(method, host, status, duration, size)
+}
+
+func TestClient_Metrics(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.Error(w, "not found", http.StatusNotFound)
+
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"id":1}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	var observed []string
+
+	c := fluent.New().BaseURL(srv.URL).Metrics(metricsFunc(func(method, host string, status int, d time.Duration, size int64) {
+		if host != strings.TrimPrefix(srv.URL, "http://") || d <= 0 {
+			t.Errorf("unexpected host %q or duration %v", host, d)
+		}
+
+		observed = append(observed, fmt.Sprintf("%s %d %d", method, status, size))
+	}))
+
+	resp := c.Get(context.Background(), "/")
+	if len(observed) != 0 {
+		t.Fatalf("expected metrics after the body is closed, got %v", observed)
+	}
+
+	if _, err := fluent.Into[struct{ ID int }](resp); err != nil {
+		t.Fatalf("Into returned error: %v", err)
+	}
+
+	_ = c.Get(context.Background(), "/missing").Error()
+
+	if want := []string{"GET 200 8", "GET 404 10"}; !slices.Equal(observed, want) {
+		t.Fatalf("expected %v, got %v", want, observed)
+	}
+}
+
+func TestAccepted(t *testing.T) {
+	t.Parallel()
+
+	var polls atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/jobs":
+			w.Header().Set("Operation-Location", "/ops/1")
+			w.WriteHeader(http.StatusAccepted)
+		case "/ops/1":
+			if polls.Add(1) < 3 {
+				_, _ = w.Write([]byte(`{"status":"Running"}`))
+
+				return
+			}
+
+			_, _ = w.Write([]byte(`{"status":"Succeeded","resourceLocation":"/jobs/1"}`))
+		case "/jobs/1":
+			_, _ = w.Write([]byte(`{"id":1}`))
+		case "/broken":
+			w.Header().Set("Azure-AsyncOperation", "/ops/2")
+			w.WriteHeader(http.StatusAccepted)
+		case "/ops/2":
+			_, _ = w.Write([]byte(`{"status":"Failed","error":{"code":"Quota","message":"quota exceeded"}}`))
+		case "/exports":
+			w.Header().Set("Location", "/exports/status")
+			w.WriteHeader(http.StatusAccepted)
+		case "/exports/status":
+			if r.Header.Get("Authorization") != "Bearer t" {
+				w.WriteHeader(http.StatusUnauthorized)
+
+				return
+			}
+
+			_, _ = w.Write([]byte(`{"id":2}`))
+		case "/done":
+			_, _ = w.Write([]byte(`{"id":3}`))
+		default:
+			w.WriteHeader(http.StatusAccepted)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	c := fluent.New().BaseURL(srv.URL).BearerToken("t")
+	opts := fluent.PollOptions{Interval: time.Millisecond}
+
+	type job struct{ ID int }
+
+	tests := []struct {
+		name    string
+		path    string
+		want    int
+		wantErr error
+	}{
+		{name: "operation monitor", path: "/jobs", want: 1},
+		{name: "failed operation", path: "/broken", wantErr: fluent.ErrOperationFailed},
+		{name: "location", path: "/exports", want: 2},
+		{name: "not accepted", path: "/done", want: 3},
+		{name: "no status URL", path: "/unknown", wantErr: fluent.ErrNoStatusURL},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := fluent.Accepted[job](context.Background(), c, c.Request().Post(context.Background(), tt.path), opts)
+			if !errors.Is(err, tt.wantErr) || got.ID != tt.want {
+				t.Fatalf("expected %d, %v; got %+v, %v", tt.want, tt.wantErr, got, err)
+			}
+		})
+	}
+}
+
+func TestTracker(t *testing.T) {
+	t.Parallel()
+
+	var polls atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/jobs":
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"job":{"id":42}}`))
+		case "/jobs/42":
+			if polls.Add(1) == 1 {
+				_, _ = w.Write([]byte(`{"state":"running"}`))
+
+				return
+			}
+
+			_, _ = w.Write([]byte(`{"state":"done"}`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	var finished []fluent.Job
+
+	store := &fluent.MemoryJobStore{}
+	tracker := &fluent.Tracker{
+		Store:      store,
+		IDPath:     "job.id",
+		StatusPath: "/jobs/%s",
+		Check: func(_ context.Context, job fluent.Job, status *fluent.Response) (bool, error) {
+			s, err := fluent.Into[struct{ State string }](status)
+			if err != nil || s.State != "done" {
+				return false, err
+			}
+
+			finished = append(finished, job)
+
+			return true, nil
+		},
+	}
+
+	c := fluent.New().BaseURL(srv.URL)
+
+	job, err := tracker.SubmitAndTrack(context.Background(), c.Request().Body(map[string]int{"order": 7}), "/jobs", map[string]string{"order": "7"})
+	if err != nil || job.ID != "42" || job.StatusURL != srv.URL+"/jobs/42" {
+		t.Fatalf("unexpected job %+v: %v", job, err)
+	}
+
+	for range 2 {
+		if err := tracker.Reconcile(context.Background(), c); err != nil {
+			t.Fatalf("Reconcile returned error: %v", err)
+		}
+	}
+
+	if jobs, _ := store.List(context.Background()); len(jobs) != 0 || len(finished) != 1 || finished[0].Meta["order"] != "7" {
+		t.Fatalf("expected the job to finish once, got pending %v, finished %v", jobs, finished)
+	}
+
+	if _, err := tracker.SubmitAndTrack(context.Background(), c.Request(), "/jobs/42", nil); !errors.Is(err, fluent.ErrNoJobID) {
+		t.Fatalf("expected ErrNoJobID, got %v", err)
+	}
+}
+
+func TestClient_Logger(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "secret-cookie"})
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte("missing resource"))
+	}))
+	t.Cleanup(srv.Close)
+
+	var buf bytes.Buffer
+
+	c := fluent.New().
+		BearerToken("secret-token").
+		Header("X-Api-Key", "secret-key").
+		Logger(slog.New(slog.NewJSONHandler(&buf, nil)), fluent.LogHeaders(), fluent.LogBodies(7),
+			fluent.RedactHeaders("x-api-key"), fluent.MaskQuery("api_key"))
+
+	err := c.Request().Query("api_key", "secret-query").Query("page", "2").Body(map[string]int{"id": 1}).
+		Post(context.Background(), srv.URL).Error()
+
+	var httpErr *fluent.HTTPError
+	if !errors.As(err, &httpErr) || string(httpErr.Body) != "missing resource" {
+		t.Fatalf("expected the full error body, got %v", err)
+	}
+
+	var entry struct {
+		Level        string
+		URL          string
+		Status       int
+		RequestBody  string `json:"request_body"`
+		ResponseBody string `json:"response_body"`
+	}
+
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("unexpected log %q: %v", buf.String(), err)
+	}
+
+	if entry.Level != "WARN" || entry.Status != http.StatusNotFound || entry.URL != srv.URL+"?api_key=REDACTED&page=2" ||
+		entry.RequestBody != `{"id":1` || entry.ResponseBody != "missing" {
+		t.Fatalf("unexpected log entry %+v", entry)
+	}
+
+	if strings.Contains(buf.String(), "secret") {
+		t.Fatalf("expected secrets to be redacted: %s", buf.String())
+	}
+}
+
+func TestClient_Logger_BodyFiles(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(strings.Repeat("0123456789", 1000)))
+	}))
+	t.Cleanup(srv.Close)
+
+	var buf bytes.Buffer
+
+	c := fluent.New().Logger(slog.New(slog.NewJSONHandler(&buf, nil)), fluent.LogBodies(4), fluent.LogBodyFiles(t.TempDir()))
+
+	got, err := c.Request().BodyString("request body").Post(context.Background(), srv.URL).Raw()
+	if err != nil || len(got) != 10000 {
+		t.Fatalf("expected the full body, got %d bytes: %v", len(got), err)
+	}
+
+	var entry struct {
+		RequestBody      string `json:"request_body"`
+		RequestBodyFile  string `json:"request_body_file"`
+		ResponseBody     string `json:"response_body"`
+		ResponseBodyFile string `json:"response_body_file"`
+	}
+
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("unexpected log %q: %v", buf.String(), err)
+	}
+
+	if entry.RequestBody != "requ" || entry.ResponseBody != "0123" {
+		t.Fatalf("expected truncated bodies, got %+v", entry)
+	}
+
+	for path, want := range map[string]string{entry.RequestBodyFile: "request body", entry.ResponseBodyFile: string(got)} {
+		if data, err := os.ReadFile(path); err != nil || string(data) != want {
+			t.Fatalf("expected %s to hold the full body, got %d bytes: %v", path, len(data), err)
+		}
+	}
+}
+
+func TestClient_Curl(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	var buf bytes.Buffer
+
+	c := fluent.New().BaseURL(srv.URL).Debug(&buf).Header("X-Note", "it's")
+
+	resp := c.Request().Query("q", "a b").Body(map[string]string{"name": "O'Brien"}).Post(context.Background(), "/items")
+	if _, err := resp.Raw(); err != nil {
+		t.Fatalf("Post returned error: %v", err)
+	}
+
+	want := `curl -X POST '` + srv.URL + `/items?q=a+b' -H 'Content-Type: application/json' -H 'X-Note: it'\''s' ` +
+		`--data-binary '{"name":"O'\''Brien"}'`
+
+	if got, err := resp.Curl(); err != nil || got != want {
+		t.Fatalf("expected %q, got %q: %v", want, got, err)
+	}
+
+	if buf.String() != want+"\n" {
+		t.Fatalf("expected debug output %q, got %q", want+"\n", buf.String())
+	}
+
+	if got, _ := c.Get(context.Background(), "/missing").Curl(); got != "curl '"+srv.URL+`/missing' -H 'X-Note: it'\''s'` {
+		t.Fatalf("unexpected curl for error response %q", got)
+	}
+}
+
+func TestClient_RecordHAR(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.Copy(w, r.Body)
+	}))
+	t.Cleanup(srv.Close)
+
+	h := &fluent.HAR{}
+	c := fluent.New().BaseURL(srv.URL).RecordHAR(h)
+
+	if _, err := c.Request().Query("q", "1").Body(map[string]int{"id": 1}).Post(context.Background(), "/items").Raw(); err != nil {
+		t.Fatalf("Post returned error: %v", err)
+	}
+
+	_ = c.Get(context.Background(), "/missing").Error()
+
+	var buf bytes.Buffer
+	if _, err := h.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo returned error: %v", err)
+	}
+
+	var archive struct {
+		Log struct {
+			Version string `json:"version"`
+			Entries []struct {
+				Request struct {
+					Method      string `json:"method"`
+					QueryString []struct {
+						Name  string `json:"name"`
+						Value string `json:"value"`
+					} `json:"queryString"`
+					PostData *struct {
+						Text string `json:"text"`
+					} `json:"postData"`
+				} `json:"request"`
+				Response struct {
+					Status  int `json:"status"`
+					Content struct {
+						MimeType string `json:"mimeType"`
+						Text     string `json:"text"`
+					} `json:"content"`
+				} `json:"response"`
+				Timings struct {
+					Wait float64 `json:"wait"`
+				} `json:"timings"`
+			} `json:"entries"`
+		} `json:"log"`
+	}
+
+	if err := json.Unmarshal(buf.Bytes(), &archive); err != nil {
+		t.Fatalf("invalid HAR: %v", err)
+	}
+
+	if archive.Log.Version != "1.2" || len(archive.Log.Entries) != 2 {
+		t.Fatalf("expected HAR 1.2 with 2 entries, got %q with %d", archive.Log.Version, len(archive.Log.Entries))
+	}
+
+	post, missing := archive.Log.Entries[0], archive.Log.Entries[1]
+
+	if post.Request.Method != http.MethodPost || post.Request.PostData == nil || post.Request.PostData.Text != `{"id":1}` ||
+		len(post.Request.QueryString) != 1 || post.Request.QueryString[0].Value != "1" {
+		t.Fatalf("unexpected request entry %+v", post.Request)
+	}
+
+	if post.Response.Status != http.StatusOK || post.Response.Content.Text != `{"id":1}` ||
+		post.Response.Content.MimeType != "application/json" || post.Timings.Wait < 0 {
+		t.Fatalf("unexpected response entry %+v", post.Response)
+	}
+
+	if missing.Response.Status != http.StatusNotFound {
+		t.Fatalf("expected 404 entry, got %d", missing.Response.Status)
+	}
+}
+
+func TestClient_Negotiate(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+
+		if r.Header.Get("Accept") != "text/csv" {
+			w.WriteHeader(http.StatusNotAcceptable)
+
+			return
+		}
+
+		if r.Method == http.MethodPost && r.Header.Get("Content-Type") != "application/xml" {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+
+			return
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		_, _ = w.Write(body)
+	}))
+	t.Cleanup(srv.Close)
+
+	c := fluent.New().BaseURL(srv.URL).Negotiate(fluent.Negotiation{
+		Accept:       []string{"application/json", "text/csv"},
+		ContentTypes: []string{"application/json", "application/xml"},
+	})
+
+	type item struct {
+		XMLName struct{} `xml:"item"`
+		Name    string   `xml:"name"`
+	}
+
+	got, err := c.Request().Body(item{Name: "a"}).Post(context.Background(), "/items").Raw()
+	if err != nil {
+		t.Fatalf("Post returned error: %v", err)
+	}
+
+	if want := xml.Header + "<item><name>a</name></item>"; string(got) != want || calls.Load() != 3 {
+		t.Fatalf("expected %q after 3 calls, got %q after %d", want, got, calls.Load())
+	}
+
+	var httpErr *fluent.HTTPError
+	if err := c.Request().Header("Accept", "text/html").Get(context.Background(), "/").Error(); err != nil {
+		t.Fatalf("expected renegotiation from an explicit Accept, got %v", err)
+	}
+
+	strict := fluent.New().BaseURL(srv.URL).Negotiate(fluent.Negotiation{Accept: []string{"application/json"}})
+	if err := strict.Get(context.Background(), "/").Error(); !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusNotAcceptable {
+		t.Fatalf("expected 406 when alternatives run out, got %v", err)
+	}
+}
+
+func TestClient_Locale(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Header.Get("Accept-Language")))
+	}))
+	t.Cleanup(srv.Close)
+
+	c := fluent.New().Locale("de-CH", "de", "en")
+
+	got, err := c.Get(context.Background(), srv.URL).Raw()
+	if err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+
+	if string(got) != "de-CH, de;q=0.9, en;q=0.8" {
+		t.Fatalf("unexpected Accept-Language: %q", got)
+	}
+
+	got, err = c.Get(fluent.WithLocale(context.Background(), "fr"), srv.URL).Raw()
+	if err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+
+	if string(got) != "fr" {
+		t.Fatalf("expected locale from context, got %q", got)
+	}
+}
+
+func TestClient_DisableCompression(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Header.Get("Accept-Encoding")))
+	}))
+	t.Cleanup(srv.Close)
+
+	c := fluent.New().AcceptEncoding("gzip")
+
+	got, err := c.Request().DisableCompression().Get(context.Background(), srv.URL).Raw()
+	if err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+
+	if string(got) != "identity" {
+		t.Fatalf("expected identity, got %q", got)
+	}
+
+	if got, _ = c.Get(context.Background(), srv.URL).Raw(); string(got) != "gzip" {
+		t.Fatalf("expected client to keep compression, got %q", got)
+	}
+}
+
+func TestClient_Fields(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.URL.RawQuery))
+	}))
+	t.Cleanup(srv.Close)
+
+	tests := []struct {
+		name    string
+		dialect fluent.FieldsDialect
+		want    string
+	}{
+		{name: "default", want: "fields=id%2Cname"},
+		{name: "select", dialect: fluent.SelectFields, want: "select=id%2Cname"},
+		{name: "json:api", dialect: fluent.JSONAPIFields("users"), want: "fields%5Busers%5D=id%2Cname"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := fluent.New()
+			if tt.dialect != nil {
+				c.FieldsDialect(tt.dialect)
+			}
+
+			got, err := c.Fields("id").Fields("id", "name").Get(context.Background(), srv.URL).Raw()
+			if err != nil {
+				t.Fatalf("Get returned error: %v", err)
+			}
+
+			if string(got) != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestClient_OnUnauthorized(t *testing.T) {
+	t.Parallel()
+
+	var session atomic.Value
+	session.Store("expired")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login" {
+			_, _ = w.Write([]byte("fresh"))
+
+			return
+		}
+
+		if r.Header.Get("Authorization") != "Bearer fresh" {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		_, _ = w.Write(body)
+	}))
+	t.Cleanup(srv.Close)
+
+	var logins atomic.Int32
+
+	c := fluent.New().BaseURL(srv.URL)
+	c.BearerTokenFunc(func() string { return session.Load().(string) }).
+		OnUnauthorized(func(ctx context.Context) error {
+			logins.Add(1)
+
+			token, err := fluent.New().BaseURL(srv.URL).Post(ctx, "/login").Text()
+			if err != nil {
+				return err
+			}
+
+			session.Store(token)
+
+			return nil
+		})
+
+	got, err := c.Body(map[string]int{"n": 1}).Post(context.Background(), "/orders").Raw()
+	if err != nil {
+		t.Fatalf("Post returned error: %v", err)
+	}
+
+	if string(got) != `{"n":1}` || logins.Load() != 1 {
+		t.Fatalf("expected one re-login and replayed body, got %q after %d logins", got, logins.Load())
+	}
+}
+
+type apiError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *apiError) Error() string { return e.Code + ": " + e.Message }
+
+func TestClient_ErrorType(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+		_, _ = w.Write([]byte(`{"code":"duplicate","message":"order exists"}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	err := fluent.New().ErrorType(&apiError{}).Get(context.Background(), srv.URL).Error()
+
+	var apiErr *apiError
+	if !errors.As(err, &apiErr) || apiErr.Code != "duplicate" {
+		t.Fatalf("expected *apiError, got: %v", err)
+	}
+
+	if !errors.Is(err, fluent.ErrNotOK) {
+		t.Fatalf("expected ErrNotOK, got: %v", err)
+	}
+
+	var httpErr *fluent.HTTPError
+	if !errors.As(err, &httpErr) || errors.Unwrap(httpErr) != fluent.ErrNotOK { //nolint:errorlint
+		t.Fatalf("expected HTTPError to unwrap to ErrNotOK, got: %v", err)
+	}
+}
+
+func TestClient_ProblemDetails(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"type":"https://example.com/probs/out-of-credit","title":"You do not have enough credit.",` +
+			`"status":403,"detail":"Your current balance is 30, but that costs 50.","balance":30}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	err := fluent.New().Get(context.Background(), srv.URL).Error()
+
+	var problem *fluent.ProblemDetails
+	if !errors.As(err, &problem) {
+		t.Fatalf("expected *ProblemDetails, got: %v", err)
+	}
+
+	if problem.Type != "https://example.com/probs/out-of-credit" || problem.Status != http.StatusForbidden ||
+		problem.Extensions["balance"] != float64(30) {
+		t.Fatalf("unexpected problem: %+v", problem)
+	}
+
+	var httpErr *fluent.HTTPError
+	if !errors.As(err, &httpErr) || httpErr.Problem != problem || !errors.Is(err, fluent.ErrNotOK) {
+		t.Fatalf("expected HTTPError with Problem, got: %v", err)
+	}
+}
+
+func TestClient_MaxBandwidth(t *testing.T) {
+	t.Parallel()
+
+	payload := strings.Repeat("x", 3000)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		_, _ = w.Write([]byte(payload))
+	}))
+	t.Cleanup(srv.Close)
+
+	c := fluent.New().MaxBandwidth(10000)
+
+	start := time.Now()
+
+	data, err := c.Body(payload).Post(context.Background(), srv.URL).Raw()
+	if err != nil || len(data) != len(payload) {
+		t.Fatalf("unexpected response (%d bytes): %v", len(data), err)
+	}
+
+	// 6000 байт в обе стороны при 10000 байт/с: первая порция проходит сразу, остальные ждут
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Fatalf("expected transfer to be throttled, took %v", elapsed)
+	}
+}
+
+func TestClient_Paginate(t *testing.T) {
+	t.Parallel()
+
+	var srv *httptest.Server
+
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page == 0 {
+			page = 1
+		}
+
+		if r.URL.Query()["per_page"][0] != "2" || len(r.URL.Query()["per_page"]) != 1 ||
+			r.URL.Query().Get("api_key") != "k" || len(r.URL.Query()["api_key"]) != 1 {
+			t.Errorf("unexpected query %s", r.URL.RawQuery)
+		}
+
+		if page < 3 {
+			next := fmt.Sprintf("/items?page=%d&per_page=2", page+1)
+			if page == 2 {
+				next = srv.URL + next
+			}
+
+			w.Header().Add("Link", fmt.Sprintf(`<%s/items?page=3&per_page=2>; rel="last", <%s>; rel="next"`, srv.URL, next))
+		}
+
+		_, _ = fmt.Fprintf(w, "[%d,%d]", page*2-1, page*2)
+	}))
+	t.Cleanup(srv.Close)
+
+	c := fluent.New().BaseURL(srv.URL).Query("api_key", "k")
+
+	var items []int
+
+	err := c.Request().Query("per_page", "2").Paginate(context.Background(), "/items", func(page *fluent.Response) error {
+		batch, err := fluent.Into[[]int](page)
+		items = append(items, batch...)
+
+		return err
+	})
+	if err != nil || !slices.Equal(items, []int{1, 2, 3, 4, 5, 6}) {
+		t.Fatalf("unexpected items %v: %v", items, err)
+	}
+
+	err = c.MaxPages(2).Request().Query("per_page", "2").Paginate(context.Background(), "/items",
+		func(*fluent.Response) error { return nil })
+	if !errors.Is(err, fluent.ErrTooManyPages) {
+		t.Fatalf("expected ErrTooManyPages, got %v", err)
+	}
+}
+
+func TestClient_Paginate_Prefetch(t *testing.T) {
+	t.Parallel()
+
+	requested := make(chan int, 10)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		requested <- page
+
+		if page < 3 {
+			w.Header().Set("Link", fmt.Sprintf(`</items?page=%d>; rel="next"`, page+1))
+			w.Header().Set("X-Next", strconv.Itoa(page+1))
+		}
+
+		_, _ = fmt.Fprintf(w, "[%d]", page)
+	}))
+	t.Cleanup(srv.Close)
+
+	c := fluent.New().BaseURL(srv.URL)
+
+	// Пока обрабатывается страница, следующая уже запрошена
+	waitNext := func(page int) error {
+		for {
+			select {
+			case got := <-requested:
+				if got == page+1 {
+					return nil
+				}
+			case <-time.After(2 * time.Second):
+				return fmt.Errorf("page %d was not prefetched", page+1)
+			}
+		}
+	}
+
+	var items []int
+
+	err := c.Request().Prefetch(1).Paginate(context.Background(), "/items", func(page *fluent.Response) error {
+		batch, err := fluent.Into[[]int](page)
+		if err != nil {
+			return err
+		}
+
+		items = append(items, batch...)
+
+		if batch[0] < 3 {
+			return waitNext(batch[0])
+		}
+
+		return nil
+	})
+	if err != nil || !slices.Equal(items, []int{0, 1, 2, 3}) {
+		t.Fatalf("unexpected items %v: %v", items, err)
+	}
+
+	p := fluent.Pager[int]{Param: "page", Next: fluent.CursorFromHeader("X-Next")}
+	items = nil
+
+	err = p.Each(context.Background(), c.Request().Prefetch(2), "/items", func(item int) error {
+		items = append(items, item)
+
+		if item < 3 {
+			return waitNext(item)
+		}
+
+		return nil
+	})
+	if err != nil || !slices.Equal(items, []int{0, 1, 2, 3}) {
+		t.Fatalf("unexpected pager items %v: %v", items, err)
+	}
+
+	for item := range fluent.Items[int](context.Background(), c.Prefetch(1), "/items") {
+		if item == 1 {
+			break
+		}
+	}
+}
+
+func TestPager(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/cursor":
+			next := map[string]string{"": "b", "b": "c", "c": ""}[r.URL.Query().Get("cursor")]
+			w.Header().Set("X-Next", next)
+			_, _ = fmt.Fprintf(w, `{"data":[%q],"meta":{"next":%q}}`, r.URL.Query().Get("cursor"), next)
+		case "/offset":
+			offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+			items := []int{1, 2, 3, 4, 5}[min(offset, 5):min(offset+2, 5)]
+			_ = json.NewEncoder(w).Encode(items)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	c := fluent.New().BaseURL(srv.URL)
+
+	for _, next := range []fluent.NextCursor{fluent.CursorFromJSON("meta.next"), fluent.CursorFromHeader("X-Next")} {
+		p := fluent.Pager[string]{Param: "cursor", Next: next, ItemsPath: "data"}
+
+		items, err := p.All(context.Background(), c.Request(), "/cursor")
+		if err != nil || !slices.Equal(items, []string{"", "b", "c"}) {
+			t.Fatalf("unexpected cursor items %q: %v", items, err)
+		}
+	}
+
+	p := fluent.Pager[int]{Param: "offset", Next: fluent.OffsetCursor()}
+
+	var items []int
+
+	err := p.Each(context.Background(), c.Request().Query("offset", "1"), "/offset", func(item int) error {
+		items = append(items, item)
+
+		return nil
+	})
+	if err != nil || !slices.Equal(items, []int{2, 3, 4, 5}) {
+		t.Fatalf("unexpected offset items %v: %v", items, err)
+	}
+}
+
+func TestItems(t *testing.T) {
+	t.Parallel()
+
+	var hits atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+
+		if r.URL.Path == "/broken" {
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page < 3 {
+			w.Header().Set("Link", fmt.Sprintf(`</posts?page=%d>; rel="next"`, page+1))
+		}
+
+		_, _ = fmt.Fprintf(w, "[%d,%d]", page*2, page*2+1)
+	}))
+	t.Cleanup(srv.Close)
+
+	c := fluent.New().BaseURL(srv.URL)
+
+	var all []int
+
+	for item, err := range fluent.Items[int](context.Background(), c, "/posts") {
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		all = append(all, item)
+	}
+
+	if !slices.Equal(all, []int{0, 1, 2, 3, 4, 5, 6, 7}) {
+		t.Fatalf("unexpected items %v", all)
+	}
+
+	hits.Store(0)
+
+	for item := range fluent.Items[int](context.Background(), c, "/posts") {
+		if item == 2 {
+			break
+		}
+	}
+
+	if hits.Load() != 2 {
+		t.Fatalf("expected pages to be fetched lazily, got %d requests", hits.Load())
+	}
+
+	var lastErr error
+	for _, err := range fluent.Items[int](context.Background(), c, "/broken") {
+		lastErr = err
+	}
+
+	if !errors.Is(lastErr, fluent.ErrNotOK) {
+		t.Fatalf("expected ErrNotOK, got %v", lastErr)
+	}
+}
+
+func TestClient_Profile(t *testing.T) {
+	t.Parallel()
+
+	srv := serve(t, `{"id":1,"title":"profiled"}`)
+
+	var profiles []fluent.RequestProfile
+
+	c := fluent.New().Profile(func(p fluent.RequestProfile) { profiles = append(profiles, p) })
+
+	if _, err := fluent.Into[Post](c.Get(context.Background(), srv.URL)); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(profiles) != 1 || profiles[0].Method != http.MethodGet || profiles[0].URL != srv.URL {
+		t.Fatalf("unexpected profiles %+v", profiles)
+	}
+
+	var phases []fluent.Phase
+	for _, p := range profiles[0].Phases {
+		phases = append(phases, p.Phase)
+	}
+
+	want := []fluent.Phase{fluent.PhaseBuild, fluent.PhaseSend, fluent.PhaseDecode, fluent.PhaseRead}
+	if !slices.Equal(phases, want) {
+		t.Fatalf("unexpected phases %v", phases)
+	}
+
+	if _, err := c.Get(context.Background(), srv.URL).Raw(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(profiles) != 2 || len(profiles[1].Phases) != 3 || profiles[1].Phases[2].Phase != fluent.PhaseRead {
+		t.Fatalf("expected build, send and read phases for Raw, got %+v", profiles)
+	}
+}
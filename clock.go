@@ -0,0 +1,68 @@
+package fluent
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultDriftThreshold — порог расхождения часов, после которого ClockSkew вызывает OnDrift.
+// Заголовок Date имеет точность в одну секунду, поэтому меньшие расхождения неотличимы от шума.
+const DefaultDriftThreshold = 2 * time.Second
+
+// ClockSkew сравнивает заголовок Date ответов с локальными часами и запоминает расхождение.
+// Подписи с временной меткой (SigV4, HMAC) перестают проходить проверку, когда часы расходятся,
+// и без явного сигнала причину таких ошибок сложно найти.
+//
+// ClockSkew безопасен для конкурентного использования и может разделяться несколькими клиентами.
+type ClockSkew struct {
+	// Threshold — расхождение по модулю, начиная с которого вызывается OnDrift. По умолчанию DefaultDriftThreshold.
+	Threshold time.Duration
+	// OnDrift вызывается, если расхождение с сервером host превысило Threshold.
+	// Положительное drift означает, что часы сервера спешат относительно локальных.
+	OnDrift func(host string, drift time.Duration)
+
+	mu     sync.Mutex
+	offset time.Duration
+}
+
+// Offset возвращает последнее измеренное расхождение: время сервера минус локальное время.
+func (s *ClockSkew) Offset() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.offset
+}
+
+// Now возвращает локальное время, скорректированное на измеренное расхождение с сервером.
+func (s *ClockSkew) Now() time.Time {
+	return time.Now().Add(s.Offset())
+}
+
+// Observe измеряет расхождение по заголовку Date ответа.
+func (s *ClockSkew) Observe(resp *http.Response) {
+	date, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return
+	}
+
+	drift := date.Sub(time.Now().Truncate(time.Second))
+
+	s.mu.Lock()
+	s.offset = drift
+	s.mu.Unlock()
+
+	threshold := s.Threshold
+	if threshold <= 0 {
+		threshold = DefaultDriftThreshold
+	}
+
+	if s.OnDrift != nil && (drift >= threshold || drift <= -threshold) {
+		host := ""
+		if resp.Request != nil {
+			host = resp.Request.URL.Host
+		}
+
+		s.OnDrift(host, drift)
+	}
+}
@@ -0,0 +1,130 @@
+// Command fluentctl выполняет HTTP-запросы через библиотеку fluent.
+//
+// Использование:
+//
+//	fluentctl [-X METHOD] [-H "Key: Value"]... [-q key=value]... [-d JSON] [-timeout 30s] [-retry N]
+//		[-token TOKEN | -u user:password] [-pretty | -table cols] URL
+//
+// Примеры:
+//
+//	fluentctl -q userId=1 -pretty https://jsonplaceholder.typicode.com/posts
+//	fluentctl -table id,title https://jsonplaceholder.typicode.com/posts
+//	fluentctl -X POST -d '{"title":"foo"}' https://jsonplaceholder.typicode.com/posts
+//	fluentctl -retry 3 -token "$TOKEN" https://api.example.com/me
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/devem-tech/fluent"
+)
+
+var errUsage = errors.New("usage")
+
+// pairs — повторяемый флаг вида -H "Key: Value" или -q key=value.
+type pairs struct {
+	sep    string
+	values [][2]string
+}
+
+func (p *pairs) String() string {
+	return fmt.Sprint(p.values)
+}
+
+func (p *pairs) Set(s string) error {
+	k, v, ok := strings.Cut(s, p.sep)
+	if !ok {
+		return fmt.Errorf("%w: expected key%svalue, got %q", errUsage, p.sep, s)
+	}
+
+	p.values = append(p.values, [2]string{strings.TrimSpace(k), strings.TrimSpace(v)})
+
+	return nil
+}
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "fluentctl:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("fluentctl", flag.ContinueOnError)
+
+	headers := &pairs{sep: ":"}
+	query := &pairs{sep: "="}
+
+	method := fs.String("X", http.MethodGet, "HTTP method")
+	data := fs.String("d", "", "JSON request body")
+	timeout := fs.Duration("timeout", 30*time.Second, "request timeout") //nolint:mnd
+	pretty := fs.Bool("pretty", false, "pretty-print JSON responses")
+	table := fs.String("table", "", "render a JSON array as a table with the given comma-separated columns")
+	retry := fs.Int("retry", 0, "retry failed requests up to N times with exponential backoff")
+	token := fs.String("token", "", "bearer token for the Authorization header")
+	user := fs.String("u", "", "basic auth credentials user:password")
+
+	fs.Var(headers, "H", "request header \"Key: Value\" (repeatable)")
+	fs.Var(query, "q", "query parameter key=value (repeatable)")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() != 1 {
+		return fmt.Errorf("%w: fluentctl [flags] URL", errUsage)
+	}
+
+	if *token != "" && *user != "" {
+		return fmt.Errorf("%w: -token and -u are mutually exclusive", errUsage)
+	}
+
+	c := fluent.New().HTTPClient(&http.Client{Timeout: *timeout}).Retry(*retry)
+
+	switch {
+	case *token != "":
+		c.BearerToken(*token)
+	case *user != "":
+		name, password, _ := strings.Cut(*user, ":")
+		c.BasicAuth(name, password)
+	}
+
+	for _, h := range headers.values {
+		c.Header(h[0], h[1])
+	}
+
+	for _, q := range query.values {
+		c.Query(q[0], q[1])
+	}
+
+	if *data != "" {
+		c.Body(json.RawMessage(*data))
+	}
+
+	resp := c.Do(context.Background(), strings.ToUpper(*method), fs.Arg(0))
+
+	switch {
+	case *table != "":
+		return resp.Table(out, strings.Split(*table, ",")...)
+	case *pretty:
+		return resp.PrettyJSON(out)
+	}
+
+	body, err := resp.Raw()
+	if err != nil {
+		return err
+	}
+
+	_, err = out.Write(body)
+
+	return err
+}
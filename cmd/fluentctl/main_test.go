@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/echo":
+			body, _ := io.ReadAll(r.Body)
+			_, _ = io.WriteString(w, r.Method+" "+r.URL.RawQuery+" "+r.Header.Get("X-Trace")+" "+
+				r.Header.Get("Authorization")+" "+string(body))
+		case "/flaky":
+			if calls.Add(1) < 2 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+
+				return
+			}
+
+			_, _ = io.WriteString(w, "ok")
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = io.WriteString(w, `[{"id":1,"title":"foo"},{"id":2,"title":"bar"}]`)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	tests := map[string]struct {
+		args []string
+		want string
+	}{
+		"headers, query and body": {
+			args: []string{"-X", "post", "-H", "X-Trace: abc", "-q", "a=1", "-q", "b=2", "-d", `{"x":1}`, srv.URL + "/echo"},
+			want: `POST a=1&b=2 abc  {"x":1}`,
+		},
+		"bearer token": {
+			args: []string{"-token", "secret", srv.URL + "/echo"},
+			want: "GET   Bearer secret ",
+		},
+		"basic auth": {
+			args: []string{"-u", "ann:pw", srv.URL + "/echo"},
+			want: "GET   Basic YW5uOnB3 ",
+		},
+		"retry": {
+			args: []string{"-retry", "2", srv.URL + "/flaky"},
+			want: "ok",
+		},
+		"pretty": {
+			args: []string{"-pretty", srv.URL + "/posts"},
+			want: "[\n  {\n    \"id\": 1,\n    \"title\": \"foo\"\n  },\n  {\n    \"id\": 2,\n    \"title\": \"bar\"\n  }\n]\n",
+		},
+		"table": {
+			args: []string{"-table", "id,title", srv.URL + "/posts"},
+			want: "ID  TITLE\n1   foo\n2   bar\n",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var out bytes.Buffer
+
+			if err := run(tt.args, &out); err != nil {
+				t.Fatalf("run returned error: %v", err)
+			}
+
+			if out.String() != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, out.String())
+			}
+		})
+	}
+}
+
+func TestRun_Usage(t *testing.T) {
+	t.Parallel()
+
+	for _, args := range [][]string{
+		{},
+		{"-token", "t", "-u", "ann:pw", "http://example.com"},
+	} {
+		if err := run(args, io.Discard); !errors.Is(err, errUsage) {
+			t.Fatalf("run(%q): expected errUsage, got %v", args, err)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package fluent
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"mime"
+)
+
+// ErrUnknownMediaType возвращается, если для media type тела запроса не зарегистрирован Codec.
+var ErrUnknownMediaType = errors.New("unknown media type")
+
+// Codec сериализует тела запросов и декодирует тела ответов одного media type,
+// например CBOR, msgpack или application/vnd.api+json.
+type Codec interface {
+	// MediaType возвращает media type без параметров, например "application/cbor".
+	MediaType() string
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// codecBody — тело запроса, которое сериализуется кодеком из RegisterCodec.
+type codecBody struct {
+	v         any
+	mediaType string
+}
+
+// RegisterCodec регистрирует кодек клиента. Into декодирует ответы с Content-Type кодека через него,
+// а BodyAs сериализует им тела запросов. Кодек заменяет встроенное декодирование этого media type.
+func (c *Client) RegisterCodec(codec Codec) *Client {
+	codecs := maps.Clone(c.codecs)
+	if codecs == nil {
+		codecs = make(map[string]Codec)
+	}
+
+	codecs[codec.MediaType()] = codec
+	c.codecs = codecs
+
+	return c
+}
+
+// BodyAs задает тело запроса, которое будет сериализовано кодеком mediaType из RegisterCodec
+// и отправлено с Content-Type: mediaType.
+func (c *Client) BodyAs(mediaType string, body any) *Client {
+	c.body = codecBody{v: body, mediaType: mediaType}
+
+	return c
+}
+
+// BodyAs задает тело запроса для кодека mediaType, так же как Client.BodyAs.
+func (r *Request) BodyAs(mediaType string, body any) *Request {
+	r.body = codecBody{v: body, mediaType: mediaType}
+
+	return r
+}
+
+// marshal сериализует тело кодеком клиента.
+func (c *Client) marshal(body codecBody) ([]byte, error) {
+	codec, ok := c.codecs[body.mediaType]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownMediaType, body.mediaType)
+	}
+
+	return codec.Marshal(body.v)
+}
+
+// decoder возвращает декодер для Content-Type ответа: кодек клиента, встроенный декодер
+// или nil, если тело декодируется как JSON.
+func (r *Response) decoder() Decoder {
+	contentType := r.resp.Header.Get("Content-Type")
+
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		if codec, ok := r.codecs[mediaType]; ok {
+			return codec.Unmarshal
+		}
+	}
+
+	return decoderFor(contentType)
+}
@@ -0,0 +1,126 @@
+package fluent
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+)
+
+// Compat — настройки совместимости со старыми серверами, например встроенными веб-серверами устройств,
+// которые понимают только HTTP/1.0 или HTTP/1.1 и ломаются на современных значениях по умолчанию.
+type Compat struct {
+	// HTTP1 отключает HTTP/2: запросы уходят по HTTP/1.1 даже к серверам, которые предлагают h2 в ALPN,
+	// но реализуют его с ошибками.
+	HTTP1 bool
+	// ContentLength отправляет тела запросов с заголовком Content-Length вместо Transfer-Encoding: chunked,
+	// который не понимают серверы HTTP/1.0. Потоковые тела (BodyReader, BodyJSONStream) для этого
+	// читаются в память целиком.
+	ContentLength bool
+	// Identity запрашивает ответы без сжатия (Accept-Encoding: identity), так же как DisableCompression,
+	// но без сброса в Reset.
+	Identity bool
+	// TolerateTruncated считает обрыв соединения до конца тела ответа, объявленного в Content-Length,
+	// нормальным концом тела: устройства, которые неверно считают длину ответа, иначе приводят
+	// к io.ErrUnexpectedEOF. Проверку полноты ответа при этом берет на себя вызывающий код.
+	TolerateTruncated bool
+}
+
+// Compat включает настройки совместимости opts для всех запросов клиента:
+//
+//	c.Compat(fluent.Compat{HTTP1: true, ContentLength: true, Identity: true})
+//
+// HTTP1 применяется к транспорту *http.Client из HTTPClient (или http.DefaultTransport): клиент получает
+// его копию без HTTP/2, а исходный транспорт не меняется. Для http-клиентов другого типа HTTP1 не действует.
+func (c *Client) Compat(opts Compat) *Client {
+	c.compat = opts
+
+	if opts.HTTP1 {
+		c.client = http1Only(c.client)
+	}
+
+	return c
+}
+
+// http1Only возвращает копию client, транспорт которой использует только HTTP/1.1.
+func http1Only(client httpClient) httpClient {
+	hc, ok := client.(*http.Client)
+	if !ok || hc == nil {
+		return client
+	}
+
+	rt := hc.Transport
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+
+	t, ok := rt.(*http.Transport)
+	if !ok {
+		return client
+	}
+
+	t = t.Clone()
+	t.Protocols = new(http.Protocols)
+	t.Protocols.SetHTTP1(true)
+
+	// Явно заданный в TLSClientConfig ALPN h2 иначе согласует HTTP/2 вопреки Protocols
+	if t.TLSClientConfig != nil {
+		t.TLSClientConfig.NextProtos = slices.DeleteFunc(slices.Clone(t.TLSClientConfig.NextProtos), func(p string) bool {
+			return p == "h2"
+		})
+	}
+
+	cp := *hc
+	cp.Transport = t
+
+	return &cp
+}
+
+// fixLength читает тело запроса неизвестной длины в память, чтобы отправить его с Content-Length.
+func (o Compat) fixLength(req *http.Request) error {
+	if !o.ContentLength || req.Body == nil || req.Body == http.NoBody || req.ContentLength > 0 {
+		return nil
+	}
+
+	data, err := io.ReadAll(req.Body)
+	req.Body.Close()
+
+	if err != nil {
+		return err
+	}
+
+	req.ContentLength = int64(len(data))
+	req.TransferEncoding = nil
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+	req.Body, _ = req.GetBody()
+
+	if len(data) == 0 {
+		req.Body = http.NoBody
+	}
+
+	return nil
+}
+
+// tolerate оборачивает тело ответа, если включен TolerateTruncated.
+func (o Compat) tolerate(resp *http.Response) {
+	if o.TolerateTruncated {
+		resp.Body = truncatedBody{resp.Body}
+	}
+}
+
+// truncatedBody заменяет io.ErrUnexpectedEOF на io.EOF.
+type truncatedBody struct {
+	io.ReadCloser
+}
+
+func (b truncatedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		err = io.EOF
+	}
+
+	return n, err
+}
@@ -0,0 +1,154 @@
+package fluent
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// ErrUnknownEncoding возвращается, если для Content-Encoding не зарегистрирован Compressor.
+var ErrUnknownEncoding = errors.New("unknown content encoding")
+
+// Compressor — кодек Content-Encoding, например gzip, snappy или lz4.
+type Compressor interface {
+	// Encoding возвращает имя кодировки для заголовков Content-Encoding и Accept-Encoding.
+	Encoding() string
+	// Compress возвращает writer, который сжимает данные в w. Close завершает поток.
+	Compress(w io.Writer) (io.WriteCloser, error)
+	// Decompress возвращает reader, который распаковывает данные из r.
+	Decompress(r io.Reader) (io.ReadCloser, error)
+}
+
+var (
+	compressorsMu sync.RWMutex
+	compressors   = map[string]Compressor{
+		"gzip":    gzipCompressor{},
+		"deflate": deflateCompressor{},
+	}
+)
+
+// RegisterCompressor регистрирует кодек Content-Encoding для всех клиентов.
+// gzip и deflate зарегистрированы по умолчанию; повторная регистрация заменяет кодек.
+func RegisterCompressor(c Compressor) {
+	compressorsMu.Lock()
+	defer compressorsMu.Unlock()
+
+	compressors[strings.ToLower(c.Encoding())] = c
+}
+
+func lookupCompressor(encoding string) (Compressor, error) {
+	compressorsMu.RLock()
+	defer compressorsMu.RUnlock()
+
+	c, ok := compressors[strings.ToLower(strings.TrimSpace(encoding))]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownEncoding, encoding)
+	}
+
+	return c, nil
+}
+
+// compress сжимает тело запроса кодеком encoding.
+func compress(encoding string, body []byte) ([]byte, error) {
+	c, err := lookupCompressor(encoding)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+
+	w, err := c.Compress(&buf)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := w.Write(body); err != nil {
+		return nil, err
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// decompress заменяет тело ответа распакованным, если Content-Encoding входит в accepted.
+func decompress(resp *http.Response, accepted []string) error {
+	encoding := resp.Header.Get("Content-Encoding")
+	if encoding == "" || !containsFold(accepted, encoding) {
+		return nil
+	}
+
+	c, err := lookupCompressor(encoding)
+	if err != nil {
+		return err
+	}
+
+	r, err := c.Decompress(resp.Body)
+	if err != nil {
+		resp.Body.Close()
+
+		return err
+	}
+
+	resp.Body = &decompressedBody{ReadCloser: r, raw: resp.Body}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+
+	return nil
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, strings.TrimSpace(s)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// decompressedBody закрывает и распаковывающий reader, и исходное тело ответа.
+type decompressedBody struct {
+	io.ReadCloser
+
+	raw io.ReadCloser
+}
+
+func (b *decompressedBody) Close() error {
+	return errors.Join(b.ReadCloser.Close(), b.raw.Close())
+}
+
+type gzipCompressor struct{}
+
+func (gzipCompressor) Encoding() string { return "gzip" }
+
+func (gzipCompressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	return gzip.NewWriter(w), nil
+}
+
+func (gzipCompressor) Decompress(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+// deflateCompressor — HTTP "deflate", то есть поток zlib (RFC 9110, раздел 8.4.1.2).
+type deflateCompressor struct{}
+
+func (deflateCompressor) Encoding() string { return "deflate" }
+
+func (deflateCompressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	return zlib.NewWriter(w), nil
+}
+
+func (deflateCompressor) Decompress(r io.Reader) (io.ReadCloser, error) {
+	return zlib.NewReader(r)
+}
@@ -0,0 +1,26 @@
+package fluent
+
+import "net/http"
+
+// DefaultCostCenterHeader — заголовок, в котором по умолчанию передается тег CostCenter.
+const DefaultCostCenterHeader = "X-Cost-Center"
+
+// costCenter — тег центра затрат клиента и заголовок, в котором он передается.
+type costCenter struct {
+	tag    string
+	header string
+}
+
+// apply выставляет заголовок с тегом, если тег задан.
+func (cc costCenter) apply(h http.Header) {
+	if cc.tag == "" {
+		return
+	}
+
+	name := cc.header
+	if name == "" {
+		name = DefaultCostCenterHeader
+	}
+
+	h.Set(name, cc.tag)
+}
@@ -0,0 +1,113 @@
+package fluent
+
+import (
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Debug включает режим отладки: каждая попытка запроса записывается в w как команда curl, которую можно
+// скопировать и выполнить, например чтобы воспроизвести проблему вместе с поставщиком API. Команда
+// строится по окончательному виду запроса — после middleware, Seal, ContentEncoding и подписей Sign.
+// Команда содержит заголовки авторизации как есть, поэтому не включайте Debug в продакшене.
+// nil выключает режим.
+func (c *Client) Debug(w io.Writer) *Client {
+	c.debug = nil
+	if w != nil {
+		c.debug = &curlDumper{w: w}
+	}
+
+	return c
+}
+
+// Curl возвращает запрос, на который получен ответ, как команду curl, в том числе для ответов не 2xx.
+// Тело включается, если его можно перечитать (GetBody); потоковые тела BodyReader опускаются.
+// Если ответа нет (сетевая ошибка), возвращается ошибка запроса.
+func (r *Response) Curl() (string, error) {
+	req := r.sent
+	if r.resp != nil {
+		req = r.resp.Request
+	}
+
+	if req == nil {
+		return "", r.err
+	}
+
+	return curlCommand(req)
+}
+
+// curlDumper записывает команды curl в w по одной за раз.
+type curlDumper struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (d *curlDumper) dump(req *http.Request) {
+	cmd, err := curlCommand(req)
+	if err != nil {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	_, _ = io.WriteString(d.w, cmd+"\n")
+}
+
+// curlCommand форматирует запрос как команду curl с заголовками в алфавитном порядке.
+func curlCommand(req *http.Request) (string, error) {
+	args := []string{"curl"}
+
+	switch req.Method {
+	case http.MethodGet, "":
+	case http.MethodHead:
+		args = append(args, "--head")
+	default:
+		args = append(args, "-X", req.Method)
+	}
+
+	args = append(args, shellQuote(req.URL.String()))
+
+	if req.Host != "" && req.Host != req.URL.Host {
+		args = append(args, "-H", shellQuote("Host: "+req.Host))
+	}
+
+	names := make([]string, 0, len(req.Header))
+	for name := range req.Header {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	for _, name := range names {
+		for _, v := range req.Header[name] {
+			args = append(args, "-H", shellQuote(name+": "+v))
+		}
+	}
+
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return "", err
+		}
+		defer body.Close()
+
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return "", err
+		}
+
+		if len(data) > 0 {
+			args = append(args, "--data-binary", shellQuote(string(data)))
+		}
+	}
+
+	return strings.Join(args, " "), nil
+}
+
+// shellQuote заключает s в одинарные кавычки для POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
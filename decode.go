@@ -0,0 +1,182 @@
+package fluent
+
+import (
+	"encoding"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"strings"
+)
+
+// ErrUnsupportedTarget возвращается декодером, который не умеет заполнять значение переданного типа.
+var ErrUnsupportedTarget = errors.New("unsupported decode target")
+
+// Decoder декодирует тело ответа data в значение v.
+type Decoder func(data []byte, v any) error
+
+var (
+	// JSON декодирует тело как JSON.
+	JSON Decoder = json.Unmarshal
+	// XML декодирует тело как XML.
+	XML Decoder = xml.Unmarshal
+	// Text записывает тело как есть в *string, *[]byte или encoding.TextUnmarshaler.
+	Text Decoder = decodeText
+	// Form декодирует тело application/x-www-form-urlencoded в *url.Values, *map[string][]string
+	// или *map[string]string (первое значение каждого ключа).
+	Form Decoder = decodeForm
+)
+
+// decoderFor возвращает декодер для Content-Type ответа или nil, если тело декодируется как JSON.
+func decoderFor(contentType string) Decoder {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	switch {
+	case mediaType == "application/xml", mediaType == "text/xml", strings.HasSuffix(mediaType, "+xml"):
+		return XML
+	case mediaType == "application/x-www-form-urlencoded":
+		return Form
+	case mediaType == "text/plain":
+		return func(data []byte, v any) error {
+			text, err := decodeCharset(data, contentType)
+			if err != nil {
+				return err
+			}
+
+			return decodeText([]byte(text), v)
+		}
+	default:
+		return nil
+	}
+}
+
+func decodeForm(data []byte, v any) error {
+	values, err := url.ParseQuery(string(data))
+	if err != nil {
+		return err
+	}
+
+	switch t := v.(type) {
+	case *url.Values:
+		*t = values
+	case *map[string][]string:
+		*t = values
+	case *map[string]string:
+		m := make(map[string]string, len(values))
+		for k, vals := range values {
+			m[k] = vals[0]
+		}
+
+		*t = m
+	default:
+		return fmt.Errorf("%w: form: %T", ErrUnsupportedTarget, v)
+	}
+
+	return nil
+}
+
+func decodeText(data []byte, v any) error {
+	switch t := v.(type) {
+	case *string:
+		*t = string(data)
+	case *[]byte:
+		*t = append((*t)[:0], data...)
+	case encoding.TextUnmarshaler:
+		return t.UnmarshalText(data)
+	default:
+		return fmt.Errorf("%w: text: %T", ErrUnsupportedTarget, v)
+	}
+
+	return nil
+}
+
+// decodeFallbacks пробует декодеры по порядку и возвращает результат первого успешного.
+// Каждая попытка декодирует в новое значение, чтобы неудачная не оставляла частично заполненных полей.
+// Если ни один декодер не подошел, возвращаются ошибки всех попыток.
+func decodeFallbacks[T any](decoders []Decoder, data []byte) (T, error) {
+	errs := make([]error, 0, len(decoders))
+
+	for _, decode := range decoders {
+		var res T
+
+		err := decode(data, &res)
+		if err == nil {
+			return res, nil
+		}
+
+		errs = append(errs, err)
+	}
+
+	var zero T
+
+	return zero, errors.Join(errs...)
+}
+
+// DecodeOption настраивает декодирование JSON в Into.
+type DecodeOption func(o *decodeOptions)
+
+type decodeOptions struct {
+	strict     bool
+	useNumber  bool
+	every      int
+	checkpoint func(cp Checkpoint) error
+}
+
+// StrictFields запрещает поля ответа, которых нет в структуре-приемнике, чтобы расхождение схемы
+// на стороне сервера приводило к ошибке, а не к молчаливой потере данных.
+func StrictFields() DecodeOption {
+	return func(o *decodeOptions) { o.strict = true }
+}
+
+// UseNumber декодирует числа в interface{} как json.Number, а не float64, чтобы большие целые
+// не теряли точность.
+func UseNumber() DecodeOption {
+	return func(o *decodeOptions) { o.useNumber = true }
+}
+
+// Checkpoint — позиция в потоке, обработанном Each или IntoChan.
+type Checkpoint struct {
+	// Records — число элементов, прочитанных с начала тела ответа.
+	Records int64
+	// Offset — число байт тела ответа, прочитанных до конца последнего элемента. Чтобы продолжить
+	// обработку после сбоя, запросите поток с этого места, например через Range: bytes=Offset-.
+	Offset int64
+}
+
+// WithCheckpoint вызывает fn после каждых every элементов потока в Each и IntoChan, а также
+// в конце потока, если после последнего вызова были новые элементы. Сохраненная позиция позволяет
+// продолжить обработку длинного потока после сбоя, не перечитывая его с начала. Ошибка fn
+// останавливает чтение. В Each fn вызывается после обработки элементов, в IntoChan — после их
+// отправки в канал. В Into опция не действует.
+func WithCheckpoint(every int, fn func(cp Checkpoint) error) DecodeOption {
+	return func(o *decodeOptions) {
+		o.every = max(every, 1)
+		o.checkpoint = fn
+	}
+}
+
+func newDecodeOptions(opts []DecodeOption) decodeOptions {
+	var o decodeOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+// decoder возвращает json.Decoder для r с учетом опций.
+func (o decodeOptions) decoder(r io.Reader) *json.Decoder {
+	dec := json.NewDecoder(r)
+	if o.strict {
+		dec.DisallowUnknownFields()
+	}
+
+	if o.useNumber {
+		dec.UseNumber()
+	}
+
+	return dec
+}
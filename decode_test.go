@@ -0,0 +1,320 @@
+package fluent_test
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/devem-tech/fluent"
+)
+
+func serve(t *testing.T, body string) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestInto_DecodeFallbacks_XML(t *testing.T) {
+	t.Parallel()
+
+	type item struct {
+		ID   int    `json:"id"   xml:"id"`
+		Name string `json:"name" xml:"name"`
+	}
+
+	srv := serve(t, `<item><id>7</id><name>seven</name></item>`)
+
+	got, err := fluent.Into[item](
+		fluent.New().
+			BaseURL(srv.URL).
+			DecodeFallbacks(fluent.JSON, fluent.XML).
+			Get(context.Background(), "/"),
+	)
+	if err != nil {
+		t.Fatalf("Into returned error: %v", err)
+	}
+
+	if got.ID != 7 || got.Name != "seven" {
+		t.Fatalf("unexpected result: %+v", got)
+	}
+}
+
+func TestInto_DecodeFallbacks_Text(t *testing.T) {
+	t.Parallel()
+
+	srv := serve(t, "pong")
+
+	got, err := fluent.Into[string](
+		fluent.New().
+			BaseURL(srv.URL).
+			DecodeFallbacks(fluent.JSON, fluent.XML, fluent.Text).
+			Get(context.Background(), "/"),
+	)
+	if err != nil {
+		t.Fatalf("Into returned error: %v", err)
+	}
+
+	if got != "pong" {
+		t.Fatalf("expected pong, got %q", got)
+	}
+}
+
+func TestClient_BodyXML_IntoXML(t *testing.T) {
+	t.Parallel()
+
+	type order struct {
+		XMLName xml.Name `xml:"order"`
+		ID      int      `xml:"id,attr"`
+		Item    string   `xml:"item"`
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/xml" {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+
+			return
+		}
+
+		_, _ = io.Copy(w, r.Body)
+	}))
+	t.Cleanup(srv.Close)
+
+	got, err := fluent.IntoXML[order](
+		fluent.New().BodyXML(order{ID: 7, Item: "book"}).Post(context.Background(), srv.URL),
+	)
+	if err != nil {
+		t.Fatalf("IntoXML returned error: %v", err)
+	}
+
+	if got.ID != 7 || got.Item != "book" {
+		t.Fatalf("unexpected order: %+v", got)
+	}
+}
+
+func TestInto_FieldSelectors(t *testing.T) {
+	t.Parallel()
+
+	type user struct {
+		ID        int      `json:"id"`
+		Name      string   `fluent:"json:data.user.name"`
+		FirstRole string   `fluent:"json:data.user.roles.0"`
+		Roles     []string `fluent:"json:data.user.roles"`
+		Missing   string   `fluent:"json:data.user.nickname"`
+	}
+
+	srv := serve(t, `{"id":7,"Missing":"top","data":{"user":{"name":"Ann","roles":["admin","dev"]}}}`)
+
+	got, err := fluent.Into[user](fluent.New().Get(context.Background(), srv.URL))
+	if err != nil {
+		t.Fatalf("Into returned error: %v", err)
+	}
+
+	want := user{ID: 7, Name: "Ann", FirstRole: "admin", Roles: []string{"admin", "dev"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected mapping: %+v", got)
+	}
+}
+
+func TestInto_ContentType(t *testing.T) {
+	t.Parallel()
+
+	type item struct {
+		ID int `json:"id" xml:"id"`
+	}
+
+	serveAs := func(t *testing.T, contentType string, body []byte) *fluent.Response {
+		t.Helper()
+
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", contentType)
+			_, _ = w.Write(body)
+		}))
+		t.Cleanup(srv.Close)
+
+		return fluent.New().Get(context.Background(), srv.URL)
+	}
+
+	t.Run("xml", func(t *testing.T) {
+		t.Parallel()
+
+		got, err := fluent.Into[item](serveAs(t, "application/xml; charset=utf-8", []byte("<item><id>3</id></item>")))
+		if err != nil || got.ID != 3 {
+			t.Fatalf("unexpected result: %+v, %v", got, err)
+		}
+	})
+
+	t.Run("form", func(t *testing.T) {
+		t.Parallel()
+
+		got, err := fluent.Into[map[string]string](serveAs(t, "application/x-www-form-urlencoded", []byte("a=1&b=x+y")))
+		if err != nil || got["a"] != "1" || got["b"] != "x y" {
+			t.Fatalf("unexpected result: %v, %v", got, err)
+		}
+	})
+
+	t.Run("text with charset", func(t *testing.T) {
+		t.Parallel()
+
+		got, err := fluent.Into[string](serveAs(t, "text/plain; charset=iso-8859-1", []byte{'c', 'a', 'f', 0xE9}))
+		if err != nil || got != "café" {
+			t.Fatalf("unexpected result: %q, %v", got, err)
+		}
+	})
+
+	t.Run("json sent as text", func(t *testing.T) {
+		t.Parallel()
+
+		got, err := fluent.Into[item](serveAs(t, "text/plain", []byte(`{"id":5}`)))
+		if err != nil || got.ID != 5 {
+			t.Fatalf("unexpected result: %+v, %v", got, err)
+		}
+	})
+}
+
+// csvCodec — тестовый кодек, который кодирует []string одной строкой CSV.
+type csvCodec struct{}
+
+func (csvCodec) MediaType() string { return "text/csv" }
+
+func (csvCodec) Marshal(v any) ([]byte, error) {
+	return []byte(strings.Join(v.([]string), ",")), nil
+}
+
+func (csvCodec) Unmarshal(data []byte, v any) error {
+	*v.(*[]string) = strings.Split(string(data), ",")
+
+	return nil
+}
+
+func TestClient_RegisterCodec(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", r.Header.Get("Content-Type")+"; charset=utf-8")
+		_, _ = io.Copy(w, r.Body)
+	}))
+	t.Cleanup(srv.Close)
+
+	c := fluent.New().RegisterCodec(csvCodec{})
+
+	got, err := fluent.Into[[]string](c.BodyAs("text/csv", []string{"a", "b"}).Post(context.Background(), srv.URL))
+	if err != nil {
+		t.Fatalf("Into returned error: %v", err)
+	}
+
+	if !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Fatalf("unexpected result: %q", got)
+	}
+
+	err = fluent.New().BodyAs("text/csv", []string{"a"}).Post(context.Background(), srv.URL).Error()
+	if !errors.Is(err, fluent.ErrUnknownMediaType) {
+		t.Fatalf("expected ErrUnknownMediaType, got: %v", err)
+	}
+}
+
+func TestInto_DecodeOptions(t *testing.T) {
+	t.Parallel()
+
+	type item struct {
+		ID int `json:"id"`
+	}
+
+	srv := serve(t, `{"id":1,"extra":true}`)
+
+	if _, err := fluent.Into[item](fluent.New().Get(context.Background(), srv.URL), fluent.StrictFields()); err == nil {
+		t.Fatal("expected unknown field error")
+	}
+
+	srv = serve(t, `{"id":9007199254740993}`)
+
+	got, err := fluent.Into[map[string]any](fluent.New().Get(context.Background(), srv.URL), fluent.UseNumber())
+	if err != nil {
+		t.Fatalf("Into returned error: %v", err)
+	}
+
+	if got["id"] != json.Number("9007199254740993") {
+		t.Fatalf("expected exact number, got %v", got["id"])
+	}
+}
+
+func TestIntoChan_NDJSON(t *testing.T) {
+	t.Parallel()
+
+	type event struct {
+		ID int `json:"id"`
+	}
+
+	srv := serve(t, "{\"id\":1}\n{\"id\":2}\n\n{\"id\":3}\n")
+
+	ch := make(chan event)
+	errc := make(chan error, 1)
+
+	go func() {
+		errc <- fluent.IntoChan(context.Background(), fluent.New().Get(context.Background(), srv.URL), ch)
+	}()
+
+	var ids []int
+	for e := range ch {
+		ids = append(ids, e.ID)
+	}
+
+	if err := <-errc; err != nil || !slices.Equal(ids, []int{1, 2, 3}) {
+		t.Fatalf("unexpected ids %v: %v", ids, err)
+	}
+}
+
+func TestIntoChan_ContextCancel(t *testing.T) {
+	t.Parallel()
+
+	srv := serve(t, "1\n2\n3\n")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := fluent.IntoChan(ctx, fluent.New().Get(context.Background(), srv.URL), make(chan int))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestEach_WithCheckpoint(t *testing.T) {
+	t.Parallel()
+
+	stream := "{\"id\":1}\n{\"id\":2}\n{\"id\":3}\n{\"id\":4}\n{\"id\":5}\n"
+	srv := serve(t, stream)
+
+	var checkpoints []fluent.Checkpoint
+
+	err := fluent.Each(fluent.New().Get(context.Background(), srv.URL), func(struct{ ID int }) error { return nil },
+		fluent.WithCheckpoint(2, func(cp fluent.Checkpoint) error {
+			checkpoints = append(checkpoints, cp)
+
+			return nil
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(checkpoints) != 3 || checkpoints[0].Records != 2 || checkpoints[2].Records != 5 {
+		t.Fatalf("unexpected checkpoints %+v", checkpoints)
+	}
+
+	// Поток продолжается с позиции контрольной точки
+	var next struct{ ID int }
+	if err := json.NewDecoder(strings.NewReader(stream[checkpoints[0].Offset:])).Decode(&next); err != nil || next.ID != 3 {
+		t.Fatalf("unexpected record after checkpoint: %+v, %v", next, err)
+	}
+}
@@ -0,0 +1,67 @@
+package fluent
+
+import (
+	"net/url"
+	"strings"
+)
+
+// FieldsDialect преобразует список полей частичного ответа в query-параметры конкретного API.
+type FieldsDialect func(fields []string) url.Values
+
+var (
+	// GoogleFields — параметр fields=id,name в стиле Google API. Используется по умолчанию.
+	GoogleFields FieldsDialect = paramFields("fields")
+	// SelectFields — параметр select=id,name (PostgREST, OData-подобные API).
+	SelectFields FieldsDialect = paramFields("select")
+)
+
+// JSONAPIFields возвращает диалект sparse fieldsets из JSON:API: fields[resource]=id,name.
+func JSONAPIFields(resource string) FieldsDialect {
+	return paramFields("fields[" + resource + "]")
+}
+
+// paramFields возвращает диалект, который передает поля через запятую в параметре name.
+func paramFields(name string) FieldsDialect {
+	return func(fields []string) url.Values {
+		return url.Values{name: {strings.Join(fields, ",")}}
+	}
+}
+
+// FieldsDialect задает формат параметра частичного ответа для Fields. По умолчанию GoogleFields.
+func (c *Client) FieldsDialect(dialect FieldsDialect) *Client {
+	c.fields = dialect
+
+	return c
+}
+
+// Fields запрашивает частичный ответ только с указанными полями, экономя трафик на списочных эндпоинтах.
+// Параметр формируется диалектом из FieldsDialect и заменяет ранее заданные значения.
+func (c *Client) Fields(fields ...string) *Client {
+	c.ownParams()
+	c.setFields(&c.requestState, fields)
+
+	return c
+}
+
+// Fields запрашивает частичный ответ, так же как Client.Fields.
+func (r *Request) Fields(fields ...string) *Request {
+	r.c.setFields(&r.requestState, fields)
+
+	return r
+}
+
+// setFields записывает параметры частичного ответа в r.
+func (c *Client) setFields(r *requestState, fields []string) {
+	dialect := c.fields
+	if dialect == nil {
+		dialect = GoogleFields
+	}
+
+	for k, vals := range dialect(fields) {
+		r.delQuery(k)
+
+		for _, v := range vals {
+			r.addQuery(k, v)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+// Package fluentprom передает метрики запросов fluent в Prometheus без зависимости от клиентской
+// библиотеки Prometheus: Collector сам отдает их в текстовом формате экспозиции.
+package fluentprom
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/devem-tech/fluent"
+)
+
+// DefaultDurationBuckets — границы гистограммы длительности запросов в секундах по умолчанию,
+// как prometheus.DefBuckets.
+var DefaultDurationBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// DefaultSizeBuckets — границы гистограммы размера ответов в байтах по умолчанию: от 100 Б до 100 МБ.
+var DefaultSizeBuckets = []float64{100, 1e3, 1e4, 1e5, 1e6, 1e7, 1e8}
+
+// Collector реализует fluent.Metrics и http.Handler, который отдает метрики в текстовом формате
+// Prometheus:
+//
+//   - fluent_requests_total{method, host, cost_center, code} — число запросов; code="error", если ответа нет;
+//   - fluent_request_duration_seconds{method, host, cost_center} — гистограмма времени до заголовков ответа;
+//   - fluent_response_size_bytes{method, host, cost_center} — гистограмма размера тела ответа.
+//
+// cost_center — тег fluent.Client.CostCenter или пустая строка, если тег не задан.
+//
+// Подключение:
+//
+//	m := fluentprom.New()
+//	c := fluent.New().Metrics(m)
+//	http.Handle("/metrics", m)
+//
+// Если метрики уже отдает prometheus/client_golang, Collector можно подключить как отдельный
+// эндпоинт scrape. Collector безопасен для конкурентного использования.
+type Collector struct {
+	// Namespace — префикс имен метрик вместо "fluent".
+	Namespace string
+	// DurationBuckets и SizeBuckets — границы гистограмм. По умолчанию DefaultDurationBuckets и DefaultSizeBuckets.
+	// Их нужно задать до первого запроса.
+	DurationBuckets []float64
+	SizeBuckets     []float64
+
+	mu        sync.Mutex
+	requests  map[requestKey]uint64
+	durations map[seriesKey]*histogram
+	sizes     map[seriesKey]*histogram
+}
+
+// seriesKey — метки гистограмм.
+type seriesKey struct {
+	method     string
+	host       string
+	costCenter string
+}
+
+// requestKey — метки счетчика запросов.
+type requestKey struct {
+	seriesKey
+
+	code string
+}
+
+// histogram — накопительная гистограмма Prometheus.
+type histogram struct {
+	bounds []float64
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// New создает Collector с параметрами по умолчанию.
+func New() *Collector {
+	return &Collector{}
+}
+
+// ObserveRequest учитывает запрос в метриках без тега центра затрат.
+func (c *Collector) ObserveRequest(method, host string, status int, duration time.Duration, size int64) {
+	c.ObserveRequestLabels(method, host, status, duration, size, fluent.RequestLabels{})
+}
+
+// ObserveRequestLabels учитывает запрос в метриках с метками labels.
+func (c *Collector) ObserveRequestLabels(
+	method, host string, status int, duration time.Duration, size int64, labels fluent.RequestLabels,
+) {
+	code := "error"
+	if status != 0 {
+		code = strconv.Itoa(status)
+	}
+
+	series := seriesKey{method: method, host: host, costCenter: labels.CostCenter}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.requests == nil {
+		c.requests = make(map[requestKey]uint64)
+		c.durations = make(map[seriesKey]*histogram)
+		c.sizes = make(map[seriesKey]*histogram)
+	}
+
+	c.requests[requestKey{seriesKey: series, code: code}]++
+	observe(c.durations, series, orDefault(c.DurationBuckets, DefaultDurationBuckets), duration.Seconds())
+
+	if status != 0 {
+		observe(c.sizes, series, orDefault(c.SizeBuckets, DefaultSizeBuckets), float64(size))
+	}
+}
+
+// ServeHTTP отдает метрики в текстовом формате Prometheus 0.0.4.
+func (c *Collector) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	_, _ = c.WriteTo(w)
+}
+
+// WriteTo записывает метрики в текстовом формате Prometheus в w.
+func (c *Collector) WriteTo(w io.Writer) (int64, error) {
+	var sb strings.Builder
+
+	ns := c.Namespace
+	if ns == "" {
+		ns = "fluent"
+	}
+
+	c.mu.Lock()
+
+	fmt.Fprintf(&sb, "# HELP %s_requests_total Total number of HTTP requests.\n", ns)
+	fmt.Fprintf(&sb, "# TYPE %s_requests_total counter\n", ns)
+
+	keys := make([]requestKey, 0, len(c.requests))
+	for k := range c.requests {
+		keys = append(keys, k)
+	}
+
+	slices.SortFunc(keys, func(a, b requestKey) int {
+		if n := compareSeries(a.seriesKey, b.seriesKey); n != 0 {
+			return n
+		}
+
+		return strings.Compare(a.code, b.code)
+	})
+
+	for _, k := range keys {
+		fmt.Fprintf(&sb, "%s_requests_total{%s,code=%q} %d\n", ns, labels(k.seriesKey), k.code, c.requests[k])
+	}
+
+	writeHistograms(&sb, ns+"_request_duration_seconds", "HTTP request latency until response headers.", c.durations)
+	writeHistograms(&sb, ns+"_response_size_bytes", "Size of HTTP response bodies.", c.sizes)
+
+	c.mu.Unlock()
+
+	n, err := io.WriteString(w, sb.String())
+
+	return int64(n), err
+}
+
+func observe(m map[seriesKey]*histogram, key seriesKey, bounds []float64, v float64) {
+	h, ok := m[key]
+	if !ok {
+		h = &histogram{bounds: slices.Clone(bounds), counts: make([]uint64, len(bounds))}
+		m[key] = h
+	}
+
+	for i, bound := range h.bounds {
+		if v <= bound {
+			h.counts[i]++
+		}
+	}
+
+	h.count++
+	h.sum += v
+}
+
+func writeHistograms(sb *strings.Builder, name, help string, m map[seriesKey]*histogram) {
+	fmt.Fprintf(sb, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(sb, "# TYPE %s histogram\n", name)
+
+	keys := make([]seriesKey, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	slices.SortFunc(keys, compareSeries)
+
+	for _, k := range keys {
+		h := m[k]
+
+		for i, bound := range h.bounds {
+			le := strconv.FormatFloat(bound, 'g', -1, 64)
+			fmt.Fprintf(sb, "%s_bucket{%s,le=%q} %d\n", name, labels(k), le, h.counts[i])
+		}
+
+		fmt.Fprintf(sb, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, labels(k), h.count)
+		fmt.Fprintf(sb, "%s_sum{%s} %s\n", name, labels(k), strconv.FormatFloat(h.sum, 'g', -1, 64))
+		fmt.Fprintf(sb, "%s_count{%s} %d\n", name, labels(k), h.count)
+	}
+}
+
+// labels форматирует метки method, host и cost_center с экранированием по правилам формата Prometheus.
+func labels(k seriesKey) string {
+	return `method="` + escape(k.method) + `",host="` + escape(k.host) + `",cost_center="` + escape(k.costCenter) + `"`
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escape(s string) string {
+	return labelEscaper.Replace(s)
+}
+
+func compareSeries(a, b seriesKey) int {
+	if n := strings.Compare(a.method, b.method); n != 0 {
+		return n
+	}
+
+	if n := strings.Compare(a.host, b.host); n != 0 {
+		return n
+	}
+
+	return strings.Compare(a.costCenter, b.costCenter)
+}
+
+func orDefault(v, def []float64) []float64 {
+	if len(v) == 0 {
+		return def
+	}
+
+	return v
+}
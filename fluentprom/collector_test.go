@@ -0,0 +1,64 @@
+package fluentprom_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/devem-tech/fluent"
+	"github.com/devem-tech/fluent/fluentprom"
+	"github.com/devem-tech/fluent/fluenttest"
+)
+
+func TestCollector(t *testing.T) {
+	t.Parallel()
+
+	s := fluenttest.NewScript().
+		Succeed("hello").
+		Reply(http.StatusInternalServerError, "oops").
+		Err(errors.New("connection refused")).
+		Succeed("ok")
+
+	m := fluentprom.New()
+	m.DurationBuckets = []float64{1}
+	m.SizeBuckets = []float64{4, 100}
+
+	c := fluent.New().HTTPClient(s.Client()).Metrics(m)
+
+	if _, err := c.Get(context.Background(), "http://api.example/a").Raw(); err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+
+	for range 2 {
+		if err := c.Get(context.Background(), "http://api.example/b").Error(); err == nil {
+			t.Fatal("expected error")
+		}
+	}
+
+	if _, err := c.Clone().CostCenter("search").Get(context.Background(), "http://api.example/c").Raw(); err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	for _, want := range []string{
+		"# TYPE fluent_requests_total counter\n",
+		`fluent_requests_total{method="GET",host="api.example",cost_center="",code="200"} 1` + "\n",
+		`fluent_requests_total{method="GET",host="api.example",cost_center="",code="500"} 1` + "\n",
+		`fluent_requests_total{method="GET",host="api.example",cost_center="",code="error"} 1` + "\n",
+		`fluent_request_duration_seconds_bucket{method="GET",host="api.example",cost_center="",le="1"} 3` + "\n",
+		`fluent_request_duration_seconds_count{method="GET",host="api.example",cost_center=""} 3` + "\n",
+		`fluent_response_size_bytes_bucket{method="GET",host="api.example",cost_center="",le="4"} 1` + "\n",
+		`fluent_response_size_bytes_bucket{method="GET",host="api.example",cost_center="",le="100"} 2` + "\n",
+		`fluent_response_size_bytes_sum{method="GET",host="api.example",cost_center=""} 9` + "\n",
+		`fluent_requests_total{method="GET",host="api.example",cost_center="search",code="200"} 1` + "\n",
+	} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Fatalf("expected %q in:\n%s", want, rec.Body.String())
+		}
+	}
+}
@@ -0,0 +1,313 @@
+package fluenttest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// ErrNoMatch возвращается MockTransport, если запрос не подошел ни к одному ожиданию.
+var ErrNoMatch = errors.New("fluenttest: no matching expectation")
+
+// MockTransport — http.RoundTripper, который отвечает на запросы заготовленными ответами по ожиданиям:
+// метод, путь, query-параметры и JSON-тело. В отличие от Script порядок запросов не важен, поэтому
+// юнит-тестам не нужны ни реальные серверы, ни самописные RoundTripper:
+//
+//	m := fluenttest.NewMock()
+//	m.On(http.MethodPost, "/users").JSONBody(map[string]any{"name": "Ann"}).ReplyJSON(http.StatusCreated, user)
+//	m.On(http.MethodGet, "/users").Query("page", "2").Reply(http.StatusOK, `[]`)
+//
+//	c := fluent.New().BaseURL("http://api.example").HTTPClient(m.Client())
+//	// ... код под тестом ...
+//	m.AssertExpectations(t)
+//
+// Каждое ожидание по умолчанию отвечает на один запрос, Times меняет их число. Запрос получает
+// первое подходящее ожидание, у которого остались ответы. MockTransport безопасен
+// для конкурентного использования.
+type MockTransport struct {
+	mu           sync.Mutex
+	expectations []*Expectation
+	unmatched    []string
+}
+
+// Expectation — ожидаемый запрос и ответ на него. Создается MockTransport.On.
+type Expectation struct {
+	m      *MockTransport
+	method string
+	path   string
+	query  url.Values
+	body   any
+	json   bool
+	times  int
+	calls  int
+	status int
+	reply  []byte
+	header http.Header
+	err    error
+}
+
+// NewMock создает MockTransport без ожиданий.
+func NewMock() *MockTransport {
+	return &MockTransport{}
+}
+
+// On добавляет ожидание запроса method path и возвращает его для настройки. path сравнивается с путем URL
+// без query-параметров. Без Reply ожидание отвечает 200 OK с пустым телом.
+func (m *MockTransport) On(method, path string) *Expectation {
+	e := &Expectation{
+		m:      m,
+		method: method,
+		path:   path,
+		query:  make(url.Values),
+		times:  1,
+		status: http.StatusOK,
+		header: make(http.Header),
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.expectations = append(m.expectations, e)
+
+	return e
+}
+
+// Query требует, чтобы у запроса был query-параметр key со значением value. Остальные параметры
+// запроса не проверяются.
+func (e *Expectation) Query(key, value string) *Expectation {
+	e.m.mu.Lock()
+	defer e.m.mu.Unlock()
+
+	e.query.Add(key, value)
+
+	return e
+}
+
+// JSONBody требует, чтобы тело запроса было JSON, равным v после сериализации. Порядок ключей
+// и пробелы не важны. Паникует, если v не сериализуется.
+func (e *Expectation) JSONBody(v any) *Expectation {
+	want, err := normalizeJSON(mustMarshal(v))
+	if err != nil {
+		panic(fmt.Sprintf("fluenttest: expected body: %v", err))
+	}
+
+	e.m.mu.Lock()
+	defer e.m.mu.Unlock()
+
+	e.body, e.json = want, true
+
+	return e
+}
+
+// Reply задает ответ со статусом status и телом body.
+func (e *Expectation) Reply(status int, body string) *Expectation {
+	e.m.mu.Lock()
+	defer e.m.mu.Unlock()
+
+	e.status, e.reply = status, []byte(body)
+
+	return e
+}
+
+// ReplyJSON задает ответ со статусом status и телом v в JSON. Паникует, если v не сериализуется.
+func (e *Expectation) ReplyJSON(status int, v any) *Expectation {
+	data := mustMarshal(v)
+
+	e.m.mu.Lock()
+	defer e.m.mu.Unlock()
+
+	e.status, e.reply = status, data
+	e.header.Set("Content-Type", "application/json")
+
+	return e
+}
+
+// Header добавляет заголовок к ответу, например Location или Retry-After.
+func (e *Expectation) Header(key, value string) *Expectation {
+	e.m.mu.Lock()
+	defer e.m.mu.Unlock()
+
+	e.header.Add(key, value)
+
+	return e
+}
+
+// Err задает ошибку транспорта вместо ответа, например сброс соединения.
+func (e *Expectation) Err(err error) *Expectation {
+	e.m.mu.Lock()
+	defer e.m.mu.Unlock()
+
+	e.err = err
+
+	return e
+}
+
+// Times задает, на сколько запросов отвечает ожидание. AssertExpectations требует ровно n запросов.
+func (e *Expectation) Times(n int) *Expectation {
+	e.m.mu.Lock()
+	defer e.m.mu.Unlock()
+
+	e.times = n
+
+	return e
+}
+
+// RoundTrip отвечает на запрос первым подходящим ожиданием или возвращает ErrNoMatch.
+func (m *MockTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+
+	if req.Body != nil {
+		body, _ = io.ReadAll(req.Body)
+		req.Body.Close()
+	}
+
+	m.mu.Lock()
+
+	e := m.match(req, body)
+	if e == nil {
+		desc := req.Method + " " + req.URL.String()
+		m.unmatched = append(m.unmatched, desc)
+		m.mu.Unlock()
+
+		return nil, fmt.Errorf("%w: %s", ErrNoMatch, desc)
+	}
+
+	e.calls++
+	status, reply, header, err := e.status, e.reply, e.header.Clone(), e.err
+	m.mu.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(reply)),
+		ContentLength: int64(len(reply)),
+		Request:       req,
+	}, nil
+}
+
+// Client возвращает *http.Client, который отправляет запросы через MockTransport.
+func (m *MockTransport) Client() *http.Client {
+	return &http.Client{Transport: m}
+}
+
+// AssertExpectations отмечает тест t проваленным, если ожидание получило не столько запросов, сколько задано
+// в Times, или какой-то запрос не подошел ни к одному ожиданию. Возвращает true, если все ожидания выполнены.
+func (m *MockTransport) AssertExpectations(t testing.TB) bool {
+	t.Helper()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ok := true
+
+	for _, e := range m.expectations {
+		if e.calls != e.times {
+			t.Errorf("fluenttest: %s: expected %d call(s), got %d", e, e.times, e.calls)
+
+			ok = false
+		}
+	}
+
+	for _, desc := range m.unmatched {
+		t.Errorf("fluenttest: unexpected request %s", desc)
+
+		ok = false
+	}
+
+	return ok
+}
+
+// String описывает ожидание для сообщений AssertExpectations.
+func (e *Expectation) String() string {
+	var b strings.Builder
+
+	b.WriteString(e.method + " " + e.path)
+
+	if len(e.query) != 0 {
+		b.WriteString("?" + e.query.Encode())
+	}
+
+	if e.json {
+		b.WriteString(" with JSON body " + string(mustMarshal(e.body)))
+	}
+
+	return b.String()
+}
+
+// match возвращает первое подходящее ожидание с оставшимися ответами. Вызывается под m.mu.
+func (m *MockTransport) match(req *http.Request, body []byte) *Expectation {
+	query := req.URL.Query()
+
+	for _, e := range m.expectations {
+		if e.calls >= e.times || e.method != req.Method || e.path != req.URL.Path {
+			continue
+		}
+
+		if !containsQuery(query, e.query) {
+			continue
+		}
+
+		if e.json {
+			got, err := normalizeJSON(body)
+			if err != nil || !reflect.DeepEqual(got, e.body) {
+				continue
+			}
+		}
+
+		return e
+	}
+
+	return nil
+}
+
+// containsQuery сообщает, что у query есть все значения want.
+func containsQuery(query, want url.Values) bool {
+	for key, values := range want {
+		for _, v := range values {
+			if !slices.Contains(query[key], v) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// normalizeJSON декодирует JSON в значения map, []any и json.Number для сравнения без учета форматирования.
+func normalizeJSON(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+func mustMarshal(v any) []byte {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("fluenttest: %v", err))
+	}
+
+	return data
+}
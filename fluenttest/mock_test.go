@@ -0,0 +1,84 @@
+package fluenttest_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/devem-tech/fluent"
+	"github.com/devem-tech/fluent/fluenttest"
+)
+
+func TestMockTransport(t *testing.T) {
+	t.Parallel()
+
+	type user struct {
+		ID   int    `json:"id,omitempty"`
+		Name string `json:"name"`
+	}
+
+	m := fluenttest.NewMock()
+	m.On(http.MethodPost, "/users").JSONBody(map[string]any{"name": "Ann"}).ReplyJSON(http.StatusCreated, user{ID: 1, Name: "Ann"})
+	m.On(http.MethodGet, "/users").Query("page", "2").Reply(http.StatusOK, `[]`).Times(2)
+
+	c := fluent.New().BaseURL("http://api.example").HTTPClient(m.Client())
+
+	created, err := fluent.Into[user](c.Request().Body(user{Name: "Ann"}).Post(context.Background(), "/users"))
+	if err != nil || created.ID != 1 {
+		t.Fatalf("unexpected result %+v: %v", created, err)
+	}
+
+	for range 2 {
+		if _, err := c.Request().Query("page", "2").Query("size", "10").Get(context.Background(), "/users").Raw(); err != nil {
+			t.Fatalf("Get returned error: %v", err)
+		}
+	}
+
+	if !m.AssertExpectations(t) {
+		t.Fatal("expected all expectations to be met")
+	}
+
+	if err := c.Request().Body(user{Name: "Bob"}).Post(context.Background(), "/users").Error(); !errors.Is(err, fluenttest.ErrNoMatch) {
+		t.Fatalf("expected ErrNoMatch, got %v", err)
+	}
+}
+
+func TestMockTransport_AssertExpectations(t *testing.T) {
+	t.Parallel()
+
+	m := fluenttest.NewMock()
+	m.On(http.MethodGet, "/a")
+	m.On(http.MethodDelete, "/b").Times(2)
+
+	c := fluent.New().BaseURL("http://api.example").HTTPClient(m.Client())
+	_ = c.Do(context.Background(), http.MethodDelete, "/b").Error()
+	_ = c.Get(context.Background(), "/c").Error()
+
+	rec := &recorder{TB: t}
+	if m.AssertExpectations(rec) {
+		t.Fatal("expected unmet expectations")
+	}
+
+	want := []string{
+		"fluenttest: GET /a: expected 1 call(s), got 0",
+		"fluenttest: DELETE /b: expected 2 call(s), got 1",
+		"fluenttest: unexpected request GET http://api.example/c",
+	}
+
+	if fmt.Sprint(rec.errors) != fmt.Sprint(want) {
+		t.Fatalf("expected %q, got %q", want, rec.errors)
+	}
+}
+
+// recorder запоминает ошибки вместо провала теста.
+type recorder struct {
+	testing.TB
+
+	errors []string
+}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,166 @@
+// Package fluenttest содержит инструменты для тестирования кода, использующего fluent.
+package fluenttest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// ErrExhausted возвращается Script, если запросов больше, чем шагов сценария.
+var ErrExhausted = errors.New("fluenttest: script exhausted")
+
+// Script — http.RoundTripper, который отвечает на запросы по заранее заданному сценарию.
+// Так настройки повторов, backoff и других механизмов устойчивости проверяются точно, без реальной сети:
+//
+//	s := fluenttest.NewScript().Fail(2, http.StatusServiceUnavailable).Succeed(`{"ok":true}`)
+//	c := fluent.New().HTTPClient(s.Client()).Retry(3)
+//	// ... запрос ...
+//	if s.Calls() != 3 || !s.Done() { ... }
+//
+// Каждый шаг отвечает на один запрос, Times повторяет последний шаг. Script безопасен
+// для конкурентного использования.
+type Script struct {
+	mu    sync.Mutex
+	steps []*step
+	calls int
+}
+
+// step — ответ или ошибка на один запрос сценария.
+type step struct {
+	status int
+	body   string
+	header http.Header
+	err    error
+}
+
+// NewScript создает пустой сценарий.
+func NewScript() *Script {
+	return &Script{}
+}
+
+// Reply добавляет шаг с ответом status и телом body.
+func (s *Script) Reply(status int, body string) *Script {
+	return s.add(&step{status: status, body: body, header: make(http.Header)})
+}
+
+// Succeed добавляет шаг с ответом 200 OK и телом body.
+func (s *Script) Succeed(body string) *Script {
+	return s.Reply(http.StatusOK, body)
+}
+
+// Fail добавляет times шагов с ответом status без тела, например Fail(2, 503).
+func (s *Script) Fail(times, status int) *Script {
+	return s.Reply(status, "").Times(times)
+}
+
+// Err добавляет шаг, на котором транспорт возвращает ошибку err, например сброс соединения.
+func (s *Script) Err(err error) *Script {
+	return s.add(&step{err: err})
+}
+
+// Header добавляет заголовок к ответу последнего шага, например Retry-After.
+func (s *Script) Header(key, value string) *Script {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if last := s.last(); last.header != nil {
+		last.header.Add(key, value)
+	}
+
+	return s
+}
+
+// Times повторяет последний шаг так, чтобы он выполнился n раз.
+func (s *Script) Times(n int) *Script {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	last := s.last()
+	for range n - 1 {
+		cp := *last
+		cp.header = last.header.Clone()
+		s.steps = append(s.steps, &cp)
+	}
+
+	return s
+}
+
+// RoundTrip отвечает на запрос следующим шагом сценария или возвращает ErrExhausted.
+func (s *Script) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		_, _ = io.Copy(io.Discard, req.Body)
+		req.Body.Close()
+	}
+
+	s.mu.Lock()
+	n := s.calls
+	s.calls++
+
+	if n >= len(s.steps) {
+		s.mu.Unlock()
+
+		return nil, fmt.Errorf("%w: request %d %s %s", ErrExhausted, n+1, req.Method, req.URL)
+	}
+
+	st := s.steps[n]
+	s.mu.Unlock()
+
+	if st.err != nil {
+		return nil, st.err
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", st.status, http.StatusText(st.status)),
+		StatusCode:    st.status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        st.header.Clone(),
+		Body:          io.NopCloser(strings.NewReader(st.body)),
+		ContentLength: int64(len(st.body)),
+		Request:       req,
+	}, nil
+}
+
+// Client возвращает *http.Client, который отправляет запросы через сценарий.
+func (s *Script) Client() *http.Client {
+	return &http.Client{Transport: s}
+}
+
+// Calls возвращает число запросов, полученных сценарием.
+func (s *Script) Calls() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.calls
+}
+
+// Done сообщает, что все шаги сценария выполнены.
+func (s *Script) Done() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.calls >= len(s.steps)
+}
+
+func (s *Script) add(st *step) *Script {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.steps = append(s.steps, st)
+
+	return s
+}
+
+// last возвращает последний шаг. Вызывается под s.mu.
+func (s *Script) last() *step {
+	if len(s.steps) == 0 {
+		panic("fluenttest: no steps in script")
+	}
+
+	return s.steps[len(s.steps)-1]
+}
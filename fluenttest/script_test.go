@@ -0,0 +1,53 @@
+package fluenttest_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/devem-tech/fluent"
+	"github.com/devem-tech/fluent/fluenttest"
+)
+
+func TestScript_Retry(t *testing.T) {
+	t.Parallel()
+
+	s := fluenttest.NewScript().
+		Fail(2, http.StatusServiceUnavailable).
+		Reply(http.StatusTooManyRequests, "").Header("Retry-After", "0").
+		Succeed(`{"ok":true}`)
+
+	c := fluent.New().HTTPClient(s.Client()).RetryPolicy(fluent.RetryPolicy{
+		MaxRetries:  3,
+		MinBackoff:  time.Millisecond,
+		MaxBackoff:  time.Millisecond,
+		StatusCodes: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests},
+	})
+
+	res, err := fluent.Into[struct{ OK bool }](c.Get(context.Background(), "http://api.example/status"))
+	if err != nil || !res.OK {
+		t.Fatalf("unexpected result %+v: %v", res, err)
+	}
+
+	if s.Calls() != 4 || !s.Done() {
+		t.Fatalf("expected all 4 steps to run, got %d calls", s.Calls())
+	}
+}
+
+func TestScript_Exhausted(t *testing.T) {
+	t.Parallel()
+
+	errReset := errors.New("connection reset")
+	s := fluenttest.NewScript().Err(errReset)
+	c := fluent.New().HTTPClient(s.Client())
+
+	if err := c.Get(context.Background(), "http://api.example/").Error(); !errors.Is(err, errReset) {
+		t.Fatalf("expected scripted error, got %v", err)
+	}
+
+	if err := c.Get(context.Background(), "http://api.example/").Error(); !errors.Is(err, fluenttest.ErrExhausted) {
+		t.Fatalf("expected ErrExhausted, got %v", err)
+	}
+}
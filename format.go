@@ -0,0 +1,125 @@
+package fluent
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// PrettyJSON читает тело ответа и записывает его в w как JSON с отступами.
+// Тело ответа автоматически закрывается.
+func (r *Response) PrettyJSON(w io.Writer) error {
+	data, err := r.Raw()
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+
+	if err := json.Indent(&buf, data, "", "  "); err != nil {
+		return err
+	}
+
+	buf.WriteByte('\n')
+
+	_, err = buf.WriteTo(w)
+
+	return err
+}
+
+// Table декодирует тело ответа — JSON-массив объектов или один объект — и записывает его в w
+// как выровненную текстовую таблицу. Колонки задаются именами полей, вложенные поля — через точку
+// ("address.city"). Если колонки не заданы, используются отсортированные ключи первого объекта.
+// Тело ответа автоматически закрывается.
+func (r *Response) Table(w io.Writer, columns ...string) error {
+	data, err := r.Raw()
+	if err != nil {
+		return err
+	}
+
+	var rows []map[string]any
+
+	if trimmed := bytes.TrimSpace(data); len(trimmed) != 0 && trimmed[0] == '{' {
+		var row map[string]any
+		if err := json.Unmarshal(trimmed, &row); err != nil {
+			return err
+		}
+
+		rows = append(rows, row)
+	} else if err := json.Unmarshal(data, &rows); err != nil {
+		return err
+	}
+
+	if len(columns) == 0 && len(rows) != 0 {
+		for k := range rows[0] {
+			columns = append(columns, k)
+		}
+
+		slices.Sort(columns)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0) //nolint:mnd
+
+	fmt.Fprintln(tw, strings.Join(upper(columns), "\t"))
+
+	for _, row := range rows {
+		cells := make([]string, len(columns))
+		for i, col := range columns {
+			cells[i] = formatCell(lookupPath(row, col))
+		}
+
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+
+	return tw.Flush()
+}
+
+// lookupPath возвращает значение вложенного поля по пути через точку или nil, если поля нет.
+// Элементы массивов выбираются по индексу, например "items.0.id".
+func lookupPath(v any, path string) any {
+	for key := range strings.SplitSeq(path, ".") {
+		switch t := v.(type) {
+		case map[string]any:
+			v = t[key]
+		case []any:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(t) {
+				return nil
+			}
+
+			v = t[i]
+		default:
+			return nil
+		}
+	}
+
+	return v
+}
+
+// formatCell форматирует значение ячейки: строки как есть, остальное — как JSON.
+func formatCell(v any) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.NewReplacer("\t", " ", "\n", " ").Replace(t)
+	default:
+		b, _ := json.Marshal(t)
+
+		return string(b)
+	}
+}
+
+func upper(ss []string) []string {
+	res := make([]string, len(ss))
+	for i, s := range ss {
+		res[i] = strings.ToUpper(s)
+	}
+
+	return res
+}
@@ -0,0 +1,402 @@
+package fluent
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"maps"
+	"net/http"
+	"os"
+	"runtime/debug"
+	"slices"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// HAR записывает трафик клиента в формате HTTP Archive 1.2: заголовки, тела и тайминги каждой попытки
+// запроса. Файл открывается во вкладке Network инструментов разработчика браузера, и им удобно делиться
+// с поставщиком API при разборе проблем. Нулевое значение готово к использованию, HAR безопасен
+// для конкурентного использования.
+//
+// Запись содержит заголовки авторизации и тела как есть, поэтому не включайте HAR в продакшене.
+type HAR struct {
+	// BodyLimit ограничивает число байтов тел запроса и ответа в записи. 0 — без ограничения.
+	BodyLimit int
+
+	mu      sync.Mutex
+	entries []harEntry
+}
+
+// RecordHAR записывает каждую попытку запроса в h. Запись подключается как middleware через Use и видит
+// запрос после middleware, добавленных раньше нее. Попытка попадает в h, когда тело ответа прочитано
+// до конца или закрыто (Raw, Into и другие методы закрывают его сами), а сетевая ошибка — сразу.
+// Тела BodyReader не записываются, а тела ответов записываются после распаковки Compression:
+//
+//	h := &fluent.HAR{}
+//	c.RecordHAR(h)
+//	...
+//	err := h.WriteFile("session.har")
+func (c *Client) RecordHAR(h *HAR) *Client {
+	return c.Use(func(next RoundFunc) RoundFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			ctx, trace := withTrace(req.Context())
+			start := time.Now()
+
+			resp, err := next(req.WithContext(ctx))
+			trace.done()
+
+			entry := harEntry{
+				StartedDateTime: start.Format(time.RFC3339Nano),
+				Request:         h.request(req),
+				Cache:           struct{}{},
+			}
+
+			if err != nil {
+				entry.Response = harResponse{
+					Cookies: []harNameValue{},
+					Headers: []harNameValue{},
+					Content: harContent{},
+				}
+				entry.Error = err.Error()
+				h.add(entry, trace, start)
+
+				return nil, err
+			}
+
+			resp.Body = &harBody{ReadCloser: resp.Body, h: h, resp: resp, entry: entry, trace: trace, start: start}
+
+			return resp, nil
+		}
+	})
+}
+
+// Len возвращает число записанных попыток.
+func (h *HAR) Len() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return len(h.entries)
+}
+
+// Reset удаляет записанные попытки.
+func (h *HAR) Reset() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.entries = nil
+}
+
+// WriteTo записывает архив в w как JSON.
+func (h *HAR) WriteTo(w io.Writer) (int64, error) {
+	h.mu.Lock()
+	entries := append([]harEntry{}, h.entries...)
+	h.mu.Unlock()
+
+	data, err := json.MarshalIndent(harFile{Log: harLog{
+		Version: "1.2",
+		Creator: harCreator{Name: modulePath, Version: moduleVersion()},
+		Entries: entries,
+	}}, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := w.Write(append(data, '\n'))
+
+	return int64(n), err
+}
+
+// WriteFile записывает архив в файл path.
+func (h *HAR) WriteFile(path string) error {
+	var buf bytes.Buffer
+
+	if _, err := h.WriteTo(&buf); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, buf.Bytes(), 0o600) //nolint:mnd
+}
+
+func (h *HAR) add(entry harEntry, trace *tracer, start time.Time) {
+	trace.mu.Lock()
+	info := trace.info
+	trace.mu.Unlock()
+
+	total := time.Since(start)
+	wait := info.ServerTime
+	send := info.TTFB - wait - info.DNSLookup - info.Connect
+
+	entry.Time = millis(total)
+	entry.ServerIPAddress = info.RemoteAddr
+	entry.Timings = harTimings{
+		Blocked: -1,
+		DNS:     optionalMillis(info.DNSLookup),
+		Connect: optionalMillis(info.Connect),
+		SSL:     optionalMillis(info.TLSHandshake),
+		Send:    millis(max(send, 0)),
+		Wait:    millis(wait),
+		Receive: millis(max(total-info.TTFB, 0)),
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.entries = append(h.entries, entry)
+}
+
+func (h *HAR) request(req *http.Request) harRequest {
+	r := harRequest{
+		Method:      req.Method,
+		URL:         req.URL.String(),
+		HTTPVersion: harVersion(req.Proto),
+		Cookies:     []harNameValue{},
+		Headers:     harHeaders(req.Header),
+		QueryString: []harNameValue{},
+		HeadersSize: -1,
+		BodySize:    -1,
+	}
+
+	for name, values := range req.URL.Query() {
+		for _, v := range values {
+			r.QueryString = append(r.QueryString, harNameValue{Name: name, Value: v})
+		}
+	}
+
+	for _, c := range req.Cookies() {
+		r.Cookies = append(r.Cookies, harNameValue{Name: c.Name, Value: c.Value})
+	}
+
+	switch {
+	case req.GetBody != nil:
+		body, err := req.GetBody()
+		if err != nil {
+			break
+		}
+
+		data, _ := io.ReadAll(body)
+		body.Close()
+
+		r.BodySize = len(data)
+		if len(data) > 0 {
+			text, _ := h.text(data)
+			r.PostData = &harPostData{MimeType: req.Header.Get("Content-Type"), Text: text}
+		}
+	case req.Body == nil || req.Body == http.NoBody:
+		r.BodySize = 0
+	}
+
+	return r
+}
+
+// text возвращает тело для записи с учетом BodyLimit; бинарные тела кодируются в base64.
+func (h *HAR) text(data []byte) (string, string) {
+	if h.BodyLimit > 0 && len(data) > h.BodyLimit {
+		data = data[:h.BodyLimit]
+	}
+
+	if utf8.Valid(data) {
+		return string(data), ""
+	}
+
+	return base64.StdEncoding.EncodeToString(data), "base64"
+}
+
+// harBody копирует тело ответа и записывает попытку в HAR, когда тело прочитано или закрыто.
+type harBody struct {
+	io.ReadCloser
+
+	h     *HAR
+	resp  *http.Response
+	entry harEntry
+	trace *tracer
+	start time.Time
+	buf   bytes.Buffer
+	size  int
+	once  sync.Once
+}
+
+func (b *harBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.size += n
+
+	keep := n
+	if b.h.BodyLimit > 0 {
+		keep = max(min(n, b.h.BodyLimit-b.buf.Len()), 0)
+	}
+
+	b.buf.Write(p[:keep])
+
+	if err == io.EOF {
+		b.finish()
+	}
+
+	return n, err
+}
+
+func (b *harBody) Close() error {
+	b.finish()
+
+	return b.ReadCloser.Close()
+}
+
+func (b *harBody) finish() {
+	b.once.Do(func() {
+		text, encoding := b.h.text(b.buf.Bytes())
+		location := b.resp.Header.Get("Location")
+
+		b.entry.Response = harResponse{
+			Status:      b.resp.StatusCode,
+			StatusText:  http.StatusText(b.resp.StatusCode),
+			HTTPVersion: harVersion(b.resp.Proto),
+			Cookies:     []harNameValue{},
+			Headers:     harHeaders(b.resp.Header),
+			Content: harContent{
+				Size:     b.size,
+				MimeType: b.resp.Header.Get("Content-Type"),
+				Text:     text,
+				Encoding: encoding,
+			},
+			RedirectURL: location,
+			HeadersSize: -1,
+			BodySize:    b.size,
+		}
+
+		for _, c := range b.resp.Cookies() {
+			b.entry.Response.Cookies = append(b.entry.Response.Cookies, harNameValue{Name: c.Name, Value: c.Value})
+		}
+
+		b.h.add(b.entry, b.trace, b.start)
+	})
+}
+
+// modulePath — путь модуля fluent, он же имя создателя архива.
+const modulePath = "github.com/devem-tech/fluent"
+
+// moduleVersion возвращает версию модуля fluent из информации о сборке.
+func moduleVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		if info.Main.Path == modulePath {
+			return info.Main.Version
+		}
+
+		for _, dep := range info.Deps {
+			if dep.Path == modulePath {
+				return dep.Version
+			}
+		}
+	}
+
+	return "(devel)"
+}
+
+func harVersion(proto string) string {
+	if proto == "" {
+		return "HTTP/1.1"
+	}
+
+	return proto
+}
+
+func harHeaders(h http.Header) []harNameValue {
+	headers := make([]harNameValue, 0, len(h))
+
+	for _, name := range slices.Sorted(maps.Keys(h)) {
+		for _, v := range h[name] {
+			headers = append(headers, harNameValue{Name: name, Value: v})
+		}
+	}
+
+	return headers
+}
+
+func millis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// optionalMillis возвращает -1 для этапов, которых не было, как требует HAR.
+func optionalMillis(d time.Duration) float64 {
+	if d == 0 {
+		return -1
+	}
+
+	return millis(d)
+}
+
+type harFile struct {
+	Log harLog `json:"log"`
+}
+
+type harLog struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	ServerIPAddress string      `json:"serverIPAddress,omitempty"`
+	Error           string      `json:"_error,omitempty"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+type harTimings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	SSL     float64 `json:"ssl"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
@@ -0,0 +1,256 @@
+package fluent
+
+import (
+	"encoding"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	durationType        = reflect.TypeFor[time.Duration]()
+	timeType            = reflect.TypeFor[time.Time]()
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+	textMarshalerType   = reflect.TypeFor[encoding.TextMarshaler]()
+	stringerType        = reflect.TypeFor[fmt.Stringer]()
+)
+
+// headerField — поле структуры, связанное с HTTP-заголовком тегом `header:"Name[,omitempty]"`.
+type headerField struct {
+	name      string
+	omitempty bool
+	index     int
+}
+
+// headerFields возвращает поля структуры t, помеченные тегом header. Поля с тегом "-" пропускаются.
+func headerFields(t reflect.Type) []headerField {
+	fields := make([]headerField, 0, t.NumField())
+
+	for i := range t.NumField() {
+		f := t.Field(i)
+
+		tag, ok := f.Tag.Lookup("header")
+		if !ok || tag == "-" || !f.IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = f.Name
+		}
+
+		fields = append(fields, headerField{name: name, omitempty: opts == "omitempty", index: i})
+	}
+
+	return fields
+}
+
+// bindHeaders заполняет поля структуры, на которую указывает v, значениями заголовков h.
+// Отсутствующие заголовки оставляют поле без изменений.
+func bindHeaders(h http.Header, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("%w: headers: expected pointer to struct, got %T", ErrUnsupportedTarget, v)
+	}
+
+	rv = rv.Elem()
+
+	for _, f := range headerFields(rv.Type()) {
+		values := h.Values(f.name)
+		if len(values) == 0 {
+			continue
+		}
+
+		if err := setHeaderField(rv.Field(f.index), values); err != nil {
+			return fmt.Errorf("header %s: %w", f.name, err)
+		}
+	}
+
+	return nil
+}
+
+// encodeHeaders записывает в h заголовки из полей структуры v (или указателя на нее).
+// Значения заменяют уже заданные заголовки с тем же именем.
+// Поля с omitempty и нулевым значением, а также nil-указатели пропускаются.
+func encodeHeaders(h http.Header, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Pointer {
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("%w: headers: expected struct, got %T", ErrUnsupportedTarget, v)
+	}
+
+	for _, f := range headerFields(rv.Type()) {
+		field := rv.Field(f.index)
+
+		if f.omitempty && field.IsZero() {
+			continue
+		}
+
+		if field.Kind() == reflect.Pointer {
+			if field.IsNil() {
+				continue
+			}
+
+			field = field.Elem()
+		}
+
+		values, err := headerValues(field)
+		if err != nil {
+			return fmt.Errorf("header %s: %w", f.name, err)
+		}
+
+		h.Del(f.name)
+
+		for _, value := range values {
+			h.Add(f.name, value)
+		}
+	}
+
+	return nil
+}
+
+// headerValues форматирует значение поля как список значений заголовка.
+// time.Time форматируется как HTTP-date, time.Duration — как целое число секунд.
+func headerValues(field reflect.Value) ([]string, error) {
+	switch field.Type() {
+	case durationType:
+		return []string{strconv.FormatInt(int64(field.Interface().(time.Duration)/time.Second), 10)}, nil //nolint:forcetypeassert
+	case timeType:
+		return []string{field.Interface().(time.Time).UTC().Format(http.TimeFormat)}, nil //nolint:forcetypeassert
+	}
+
+	switch {
+	case field.Type().Implements(textMarshalerType):
+		b, err := field.Interface().(encoding.TextMarshaler).MarshalText() //nolint:forcetypeassert
+		if err != nil {
+			return nil, err
+		}
+
+		return []string{string(b)}, nil
+	case field.Type().Implements(stringerType):
+		return []string{field.Interface().(fmt.Stringer).String()}, nil //nolint:forcetypeassert
+	}
+
+	switch field.Kind() { //nolint:exhaustive
+	case reflect.String:
+		return []string{field.String()}, nil
+	case reflect.Bool:
+		return []string{strconv.FormatBool(field.Bool())}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return []string{strconv.FormatInt(field.Int(), 10)}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return []string{strconv.FormatUint(field.Uint(), 10)}, nil
+	case reflect.Float32, reflect.Float64:
+		return []string{strconv.FormatFloat(field.Float(), 'f', -1, field.Type().Bits())}, nil
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.String {
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedTarget, field.Type())
+		}
+
+		values := make([]string, field.Len())
+		for i := range values {
+			values[i] = field.Index(i).String()
+		}
+
+		return values, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedTarget, field.Type())
+	}
+}
+
+// setHeaderField записывает значения заголовка в поле с учетом его типа.
+func setHeaderField(field reflect.Value, values []string) error { //nolint:cyclop
+	s := strings.TrimSpace(values[0])
+
+	switch field.Type() {
+	case durationType:
+		d, err := parseHeaderDuration(s)
+		if err != nil {
+			return err
+		}
+
+		field.SetInt(int64(d))
+
+		return nil
+	case timeType:
+		t, err := parseHeaderTime(s)
+		if err != nil {
+			return err
+		}
+
+		field.Set(reflect.ValueOf(t))
+
+		return nil
+	}
+
+	if field.Addr().Type().Implements(textUnmarshalerType) {
+		return field.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s)) //nolint:forcetypeassert
+	}
+
+	switch field.Kind() { //nolint:exhaustive
+	case reflect.String:
+		field.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		field.SetFloat(n)
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("%w: %s", ErrUnsupportedTarget, field.Type())
+		}
+
+		field.Set(reflect.ValueOf(append([]string(nil), values...)).Convert(field.Type()))
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedTarget, field.Type())
+	}
+
+	return nil
+}
+
+// parseHeaderDuration разбирает длительность как целое число секунд (как в Retry-After) или как строку time.ParseDuration.
+func parseHeaderDuration(s string) (time.Duration, error) {
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Duration(n) * time.Second, nil
+	}
+
+	return time.ParseDuration(s)
+}
+
+// parseHeaderTime разбирает время в формате HTTP-date или как Unix-время в секундах.
+func parseHeaderTime(s string) (time.Time, error) {
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(n, 0), nil
+	}
+
+	return http.ParseTime(s)
+}
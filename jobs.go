@@ -0,0 +1,204 @@
+package fluent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNoJobID возвращается Tracker.SubmitAndTrack, если в ответе нет идентификатора задачи.
+var ErrNoJobID = errors.New("no job id in response")
+
+// Job — задача асинхронного API, которую отслеживает Tracker.
+type Job struct {
+	// ID — идентификатор задачи из ответа на ее создание.
+	ID string
+	// StatusURL — адрес, по которому запрашивается статус задачи.
+	StatusURL string
+	// Meta — произвольные данные вызывающего, например ID заказа, чтобы связать результат с исходной операцией.
+	Meta        map[string]string
+	SubmittedAt time.Time
+}
+
+// JobStore хранит отслеживаемые задачи. Реализация должна быть безопасной для конкурентного использования;
+// чтобы задачи переживали перезапуск процесса, храните их в базе данных.
+type JobStore interface {
+	Save(ctx context.Context, job Job) error
+	Delete(ctx context.Context, id string) error
+	// List возвращает все сохраненные задачи.
+	List(ctx context.Context) ([]Job, error)
+}
+
+// MemoryJobStore — JobStore в памяти процесса.
+type MemoryJobStore struct {
+	mu   sync.Mutex
+	jobs map[string]Job
+}
+
+// Save сохраняет задачу, заменяя задачу с тем же ID.
+func (s *MemoryJobStore) Save(_ context.Context, job Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.jobs == nil {
+		s.jobs = make(map[string]Job)
+	}
+
+	s.jobs[job.ID] = job
+
+	return nil
+}
+
+// Delete удаляет задачу.
+func (s *MemoryJobStore) Delete(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.jobs, id)
+
+	return nil
+}
+
+// List возвращает задачи в порядке создания.
+func (s *MemoryJobStore) List(_ context.Context) ([]Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	jobs := make([]Job, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		jobs = append(jobs, job)
+	}
+
+	slices.SortFunc(jobs, func(a, b Job) int {
+		if n := a.SubmittedAt.Compare(b.SubmittedAt); n != 0 {
+			return n
+		}
+
+		return strings.Compare(a.ID, b.ID)
+	})
+
+	return jobs, nil
+}
+
+// Tracker связывает задачи асинхронного API, которые возвращают ID для последующего запроса статуса,
+// с их результатами: SubmitAndTrack создает задачу и сохраняет ее в Store, а Reconcile и Run
+// опрашивают статусы и передают завершенные задачи в Check:
+//
+//	t := &fluent.Tracker{
+//		Store:      &fluent.MemoryJobStore{},
+//		IDPath:     "job.id",
+//		StatusPath: "/jobs/%s",
+//		Check: func(ctx context.Context, job fluent.Job, status *fluent.Response) (bool, error) {
+//			s, err := fluent.Into[JobStatus](status)
+//			...
+//		},
+//	}
+//	job, err := t.SubmitAndTrack(ctx, c.Request().Body(order), "/jobs", map[string]string{"order": order.ID})
+//	go t.Run(ctx, c, time.Minute)
+type Tracker struct {
+	Store JobStore
+	// IDPath — путь через точку к ID задачи в JSON-теле ответа на ее создание, например "id" или "job.id".
+	IDPath string
+	// StatusPath — путь статуса задачи, в котором %s заменяется ID, например "/jobs/%s". Если пуст,
+	// используется заголовок Location ответа на создание задачи.
+	StatusPath string
+	// Check разбирает ответ статуса задачи и сообщает, завершена ли она; завершенная задача удаляется
+	// из Store. status может содержать ошибку, например HTTPError 404 для задачи, которую сервер уже удалил.
+	// Тело статуса закрывается после Check. Ошибка Check оставляет задачу в Store до следующей сверки.
+	Check func(ctx context.Context, job Job, status *Response) (done bool, err error)
+	// OnError получает ошибки сверки в Run. Если не задан, они пропускаются.
+	OnError func(err error)
+}
+
+// SubmitAndTrack отправляет POST path с параметрами r, берет ID задачи из ответа и сохраняет задачу
+// с метаданными meta в Store. Если в ответе нет ID, возвращается ErrNoJobID.
+func (t *Tracker) SubmitAndTrack(ctx context.Context, r *Request, path string, meta map[string]string) (Job, error) {
+	resp := r.Post(ctx, path)
+
+	body, err := resp.Raw()
+	if err != nil {
+		return Job{}, err
+	}
+
+	id, err := jsonString(body, t.IDPath)
+	if err != nil {
+		return Job{}, fmt.Errorf("job id: %w", err)
+	}
+
+	if id == "" {
+		return Job{}, fmt.Errorf("%w: %s", ErrNoJobID, t.IDPath)
+	}
+
+	job := Job{ID: id, Meta: meta, SubmittedAt: time.Now()}
+
+	if t.StatusPath != "" {
+		job.StatusURL = resp.resolve(fmt.Sprintf(t.StatusPath, id))
+	} else if location := resp.resp.Header.Get("Location"); location != "" {
+		job.StatusURL = resp.resolve(location)
+	}
+
+	if err := t.Store.Save(ctx, job); err != nil {
+		return Job{}, err
+	}
+
+	return job, nil
+}
+
+// Reconcile один раз запрашивает статус каждой задачи из Store и передает его в Check.
+// Ошибки отдельных задач не прерывают сверку остальных и возвращаются вместе.
+func (t *Tracker) Reconcile(ctx context.Context, c *Client) error {
+	jobs, err := t.Store.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+
+	for _, job := range jobs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if err := t.reconcile(ctx, c, job); err != nil {
+			errs = append(errs, fmt.Errorf("job %s: %w", job.ID, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (t *Tracker) reconcile(ctx context.Context, c *Client, job Job) error {
+	if job.StatusURL == "" {
+		return ErrNoStatusURL
+	}
+
+	status := c.Request().Get(ctx, job.StatusURL)
+	if status.resp != nil {
+		defer status.resp.Body.Close()
+	}
+
+	done, err := t.Check(ctx, job, status)
+	if err != nil || !done {
+		return err
+	}
+
+	return t.Store.Delete(ctx, job.ID)
+}
+
+// Run сверяет задачи через Reconcile каждые interval, пока не отменен ctx. Ошибки сверки передаются
+// в OnError и не останавливают цикл. Run возвращает ошибку ctx.
+func (t *Tracker) Run(ctx context.Context, c *Client, interval time.Duration) error {
+	for {
+		if err := t.Reconcile(ctx, c); err != nil && ctx.Err() == nil && t.OnError != nil {
+			t.OnError(err)
+		}
+
+		if err := sleepCtx(ctx, interval); err != nil {
+			return err
+		}
+	}
+}
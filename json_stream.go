@@ -0,0 +1,60 @@
+package fluent
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+// jsonStreamBody — тело запроса, которое сериализуется в JSON потоком при отправке.
+type jsonStreamBody struct {
+	v any
+}
+
+// BodyJSONStream задает тело запроса, которое сериализуется в JSON через json.Encoder прямо
+// в соединение по мере отправки, а не собирается целиком в []byte, как в Body. Пиковое потребление
+// памяти при больших выгрузках не зависит от размера тела. Тело отправляется с chunked-кодированием
+// и Content-Type: application/json; при повторах RetryPolicy и OnUnauthorized оно сериализуется заново.
+// С Seal и ContentEncoding тело все же собирается в память.
+func (c *Client) BodyJSONStream(body any) *Client {
+	c.body = jsonStreamBody{v: body}
+
+	return c
+}
+
+// BodyJSONStream задает потоковое JSON-тело запроса, так же как Client.BodyJSONStream.
+func (r *Request) BodyJSONStream(body any) *Request {
+	r.body = jsonStreamBody{v: body}
+
+	return r
+}
+
+// jsonStream — io.ReadCloser, из которого читается JSON-представление v. Сериализация начинается
+// при первом чтении, чтобы неотправленный запрос не оставлял горутину.
+type jsonStream struct {
+	v    any
+	once sync.Once
+	pr   *io.PipeReader
+	pw   *io.PipeWriter
+}
+
+func newJSONStream(v any) *jsonStream {
+	pr, pw := io.Pipe()
+
+	return &jsonStream{v: v, pr: pr, pw: pw}
+}
+
+func (s *jsonStream) Read(p []byte) (int, error) {
+	s.once.Do(func() {
+		go func() {
+			s.pw.CloseWithError(json.NewEncoder(s.pw).Encode(s.v))
+		}()
+	})
+
+	return s.pr.Read(p)
+}
+
+// Close прерывает сериализацию, если тело прочитано не до конца.
+func (s *jsonStream) Close() error {
+	return s.pr.Close()
+}
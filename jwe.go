@@ -0,0 +1,508 @@
+package fluent
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1" //nolint:gosec // RSA-OAEP по RFC 7518 использует SHA-1
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ErrJWE возвращается при ошибках разбора, шифрования или расшифровки JWE.
+var ErrJWE = errors.New("jwe")
+
+// DefaultJWEMaxInflated — наибольший размер открытого текста после распаковки zip=DEF по умолчанию.
+// Ограничение защищает от сжатых бомб: маленький токен может распаковаться в гигабайты.
+const DefaultJWEMaxInflated = 16 << 20
+
+// JWE расшифровывает тела ответов и шифрует тела запросов в компактной сериализации JWE (RFC 7516).
+//
+// Поддерживаемые алгоритмы управления ключом (alg): RSA-OAEP, RSA-OAEP-256, dir, A128KW, A192KW, A256KW.
+// Поддерживаемые алгоритмы шифрования содержимого (enc): A128GCM, A192GCM, A256GCM,
+// A128CBC-HS256, A192CBC-HS384, A256CBC-HS512. Сжатие zip=DEF также поддерживается.
+type JWE struct {
+	// Key возвращает ключ по идентификатору kid.
+	// При расшифровке kid берется из заголовка JWE, и для RSA-OAEP ожидается *rsa.PrivateKey.
+	// При шифровании kid берется из поля Kid, и для RSA-OAEP ожидается *rsa.PublicKey получателя.
+	// Для dir и AES Key Wrap в обоих случаях ожидается []byte.
+	Key func(kid string) (any, error)
+
+	// Alg, Enc и Kid используются только при шифровании тел запросов (Seal).
+	Alg string
+	Enc string
+	Kid string
+
+	// MaxInflated — наибольший размер открытого текста после распаковки zip=DEF, байт.
+	// Если распакованный текст больше, Decrypt возвращает ErrJWE. По умолчанию DefaultJWEMaxInflated.
+	MaxInflated int64
+}
+
+// jweHeader — поля защищенного заголовка JWE, которые нужны для расшифровки.
+type jweHeader struct {
+	Alg string `json:"alg"`
+	Enc string `json:"enc"`
+	Kid string `json:"kid,omitempty"`
+	Zip string `json:"zip,omitempty"`
+}
+
+// Decrypt расшифровывает JWE в компактной сериализации и возвращает открытый текст.
+func (j *JWE) Decrypt(data []byte) ([]byte, error) {
+	parts := strings.Split(string(bytes.TrimSpace(data)), ".")
+	if len(parts) != 5 { //nolint:mnd
+		return nil, fmt.Errorf("%w: expected 5 parts in compact serialization, got %d", ErrJWE, len(parts))
+	}
+
+	var header jweHeader
+
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+
+	segments := make([][]byte, 4) //nolint:mnd
+
+	for i, p := range parts[1:] {
+		b, err := base64.RawURLEncoding.DecodeString(p)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid segment %d: %w", ErrJWE, i+1, err)
+		}
+
+		segments[i] = b
+	}
+
+	encryptedKey, iv, ciphertext, tag := segments[0], segments[1], segments[2], segments[3]
+
+	if j.Key == nil {
+		return nil, fmt.Errorf("%w: key lookup is not configured", ErrJWE)
+	}
+
+	key, err := j.Key(header.Kid)
+	if err != nil {
+		return nil, fmt.Errorf("%w: key lookup for kid %q: %w", ErrJWE, header.Kid, err)
+	}
+
+	cek, err := unwrapKey(header.Alg, key, encryptedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := decryptContent(header.Enc, cek, iv, ciphertext, tag, []byte(parts[0]))
+	if err != nil {
+		return nil, err
+	}
+
+	switch header.Zip {
+	case "":
+		return plaintext, nil
+	case "DEF":
+		return j.inflate(plaintext)
+	default:
+		return nil, fmt.Errorf("%w: unsupported zip %q", ErrJWE, header.Zip)
+	}
+}
+
+// inflate распаковывает открытый текст zip=DEF не больше MaxInflated байтов.
+func (j *JWE) inflate(data []byte) ([]byte, error) {
+	limit := j.MaxInflated
+	if limit <= 0 {
+		limit = DefaultJWEMaxInflated
+	}
+
+	out, err := io.ReadAll(io.LimitReader(flate.NewReader(bytes.NewReader(data)), limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: inflate: %w", ErrJWE, err)
+	}
+
+	if int64(len(out)) > limit {
+		return nil, fmt.Errorf("%w: inflated plaintext exceeds %d bytes", ErrJWE, limit)
+	}
+
+	return out, nil
+}
+
+// Seal шифрует тело запроса в компактную сериализацию JWE и выставляет Content-Type: application/jose.
+func (j *JWE) Seal(body []byte, header http.Header) ([]byte, error) {
+	if j.Key == nil {
+		return nil, fmt.Errorf("%w: key lookup is not configured", ErrJWE)
+	}
+
+	key, err := j.Key(j.Kid)
+	if err != nil {
+		return nil, fmt.Errorf("%w: key lookup for kid %q: %w", ErrJWE, j.Kid, err)
+	}
+
+	size, err := cekSize(j.Enc)
+	if err != nil {
+		return nil, err
+	}
+
+	cek := make([]byte, size)
+
+	if j.Alg == "dir" {
+		k, ok := key.([]byte)
+		if !ok {
+			return nil, fmt.Errorf("%w: alg dir requires []byte key, got %T", ErrJWE, key)
+		}
+
+		cek = k
+	} else {
+		_, _ = rand.Read(cek)
+	}
+
+	encryptedKey, err := wrapKey(j.Alg, key, cek)
+	if err != nil {
+		return nil, err
+	}
+
+	h, err := json.Marshal(jweHeader{Alg: j.Alg, Enc: j.Enc, Kid: j.Kid})
+	if err != nil {
+		return nil, err
+	}
+
+	protected := base64.RawURLEncoding.EncodeToString(h)
+
+	iv, ciphertext, tag, err := encryptContent(j.Enc, cek, body, []byte(protected))
+	if err != nil {
+		return nil, err
+	}
+
+	header.Set("Content-Type", "application/jose")
+
+	return []byte(strings.Join([]string{
+		protected,
+		base64.RawURLEncoding.EncodeToString(encryptedKey),
+		base64.RawURLEncoding.EncodeToString(iv),
+		base64.RawURLEncoding.EncodeToString(ciphertext),
+		base64.RawURLEncoding.EncodeToString(tag),
+	}, ".")), nil
+}
+
+// decodeSegment декодирует base64url-сегмент и разбирает его как JSON.
+func decodeSegment(segment string, v any) error {
+	b, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return fmt.Errorf("%w: invalid header: %w", ErrJWE, err)
+	}
+
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("%w: invalid header: %w", ErrJWE, err)
+	}
+
+	return nil
+}
+
+// unwrapKey восстанавливает ключ шифрования содержимого (CEK) согласно alg.
+func unwrapKey(alg string, key any, encryptedKey []byte) ([]byte, error) {
+	switch alg {
+	case "RSA-OAEP", "RSA-OAEP-256":
+		priv, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("%w: alg %s requires *rsa.PrivateKey, got %T", ErrJWE, alg, key)
+		}
+
+		cek, err := rsa.DecryptOAEP(oaepHash(alg), nil, priv, encryptedKey, nil)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrJWE, err)
+		}
+
+		return cek, nil
+	case "dir":
+		k, ok := key.([]byte)
+		if !ok {
+			return nil, fmt.Errorf("%w: alg dir requires []byte key, got %T", ErrJWE, key)
+		}
+
+		if len(encryptedKey) != 0 {
+			return nil, fmt.Errorf("%w: alg dir requires empty encrypted key", ErrJWE)
+		}
+
+		return k, nil
+	case "A128KW", "A192KW", "A256KW":
+		kek, ok := key.([]byte)
+		if !ok {
+			return nil, fmt.Errorf("%w: alg %s requires []byte key, got %T", ErrJWE, alg, key)
+		}
+
+		return aesKeyUnwrap(kek, encryptedKey)
+	default:
+		return nil, fmt.Errorf("%w: unsupported alg %q", ErrJWE, alg)
+	}
+}
+
+// wrapKey шифрует ключ шифрования содержимого (CEK) согласно alg.
+func wrapKey(alg string, key any, cek []byte) ([]byte, error) {
+	switch alg {
+	case "RSA-OAEP", "RSA-OAEP-256":
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("%w: alg %s requires *rsa.PublicKey, got %T", ErrJWE, alg, key)
+		}
+
+		encryptedKey, err := rsa.EncryptOAEP(oaepHash(alg), rand.Reader, pub, cek, nil)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrJWE, err)
+		}
+
+		return encryptedKey, nil
+	case "dir":
+		return nil, nil
+	case "A128KW", "A192KW", "A256KW":
+		kek, ok := key.([]byte)
+		if !ok {
+			return nil, fmt.Errorf("%w: alg %s requires []byte key, got %T", ErrJWE, alg, key)
+		}
+
+		return aesKeyWrap(kek, cek)
+	default:
+		return nil, fmt.Errorf("%w: unsupported alg %q", ErrJWE, alg)
+	}
+}
+
+func oaepHash(alg string) hash.Hash {
+	if alg == "RSA-OAEP-256" {
+		return sha256.New()
+	}
+
+	return sha1.New() //nolint:gosec
+}
+
+// cekSize возвращает длину ключа шифрования содержимого в байтах для enc.
+func cekSize(enc string) (int, error) {
+	switch enc {
+	case "A128GCM":
+		return 16, nil //nolint:mnd
+	case "A192GCM":
+		return 24, nil //nolint:mnd
+	case "A256GCM", "A128CBC-HS256":
+		return 32, nil //nolint:mnd
+	case "A192CBC-HS384":
+		return 48, nil //nolint:mnd
+	case "A256CBC-HS512":
+		return 64, nil //nolint:mnd
+	default:
+		return 0, fmt.Errorf("%w: unsupported enc %q", ErrJWE, enc)
+	}
+}
+
+// decryptContent расшифровывает содержимое согласно enc и проверяет тег аутентификации.
+func decryptContent(enc string, cek, iv, ciphertext, tag, aad []byte) ([]byte, error) {
+	size, err := cekSize(enc)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(cek) != size {
+		return nil, fmt.Errorf("%w: enc %s requires %d-byte key, got %d", ErrJWE, enc, size, len(cek))
+	}
+
+	if strings.HasSuffix(enc, "GCM") {
+		aead, err := newGCM(cek)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(iv) != aead.NonceSize() {
+			return nil, fmt.Errorf("%w: invalid iv length %d", ErrJWE, len(iv))
+		}
+
+		plaintext, err := aead.Open(nil, iv, append(ciphertext, tag...), aad)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrJWE, err)
+		}
+
+		return plaintext, nil
+	}
+
+	macKey, encKey := cek[:size/2], cek[size/2:]
+
+	expected := cbcHMACTag(enc, macKey, aad, iv, ciphertext)
+	if subtle.ConstantTimeCompare(expected, tag) != 1 {
+		return nil, fmt.Errorf("%w: authentication tag mismatch", ErrJWE)
+	}
+
+	block, err := aes.NewCipher(encKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrJWE, err)
+	}
+
+	if len(iv) != block.BlockSize() || len(ciphertext) == 0 || len(ciphertext)%block.BlockSize() != 0 {
+		return nil, fmt.Errorf("%w: invalid ciphertext or iv length", ErrJWE)
+	}
+
+	plaintext := make([]byte, len(ciphertext))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plaintext, ciphertext)
+
+	padding := int(plaintext[len(plaintext)-1])
+	if padding == 0 || padding > block.BlockSize() {
+		return nil, fmt.Errorf("%w: invalid padding", ErrJWE)
+	}
+
+	return plaintext[:len(plaintext)-padding], nil
+}
+
+// encryptContent шифрует содержимое согласно enc и возвращает iv, шифротекст и тег.
+func encryptContent(enc string, cek, plaintext, aad []byte) ([]byte, []byte, []byte, error) {
+	size, err := cekSize(enc)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	if len(cek) != size {
+		return nil, nil, nil, fmt.Errorf("%w: enc %s requires %d-byte key, got %d", ErrJWE, enc, size, len(cek))
+	}
+
+	if strings.HasSuffix(enc, "GCM") {
+		aead, err := newGCM(cek)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		iv := make([]byte, aead.NonceSize())
+		_, _ = rand.Read(iv)
+
+		sealed := aead.Seal(nil, iv, plaintext, aad)
+		tagStart := len(sealed) - aead.Overhead()
+
+		return iv, sealed[:tagStart], sealed[tagStart:], nil
+	}
+
+	macKey, encKey := cek[:size/2], cek[size/2:]
+
+	block, err := aes.NewCipher(encKey)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("%w: %w", ErrJWE, err)
+	}
+
+	iv := make([]byte, block.BlockSize())
+	_, _ = rand.Read(iv)
+
+	padding := block.BlockSize() - len(plaintext)%block.BlockSize()
+	padded := append(bytes.Clone(plaintext), bytes.Repeat([]byte{byte(padding)}, padding)...)
+
+	ciphertext := make([]byte, len(padded))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(ciphertext, padded)
+
+	return iv, ciphertext, cbcHMACTag(enc, macKey, aad, iv, ciphertext), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrJWE, err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrJWE, err)
+	}
+
+	return aead, nil
+}
+
+// cbcHMACTag вычисляет тег аутентификации AES-CBC-HMAC-SHA2 (RFC 7518, раздел 5.2.2.1).
+func cbcHMACTag(enc string, macKey, aad, iv, ciphertext []byte) []byte {
+	var h func() hash.Hash
+
+	switch enc {
+	case "A128CBC-HS256":
+		h = sha256.New
+	case "A192CBC-HS384":
+		h = sha512.New384
+	default:
+		h = sha512.New
+	}
+
+	al := make([]byte, 8)                              //nolint:mnd
+	binary.BigEndian.PutUint64(al, uint64(len(aad))*8) //nolint:mnd
+
+	mac := hmac.New(h, macKey)
+	mac.Write(aad)
+	mac.Write(iv)
+	mac.Write(ciphertext)
+	mac.Write(al)
+
+	return mac.Sum(nil)[:len(macKey)]
+}
+
+// aesKeyWrapIV — начальное значение AES Key Wrap по умолчанию (RFC 3394, раздел 2.2.3.1).
+var aesKeyWrapIV = []byte{0xA6, 0xA6, 0xA6, 0xA6, 0xA6, 0xA6, 0xA6, 0xA6}
+
+// aesKeyWrap оборачивает ключ по алгоритму AES Key Wrap (RFC 3394).
+func aesKeyWrap(kek, key []byte) ([]byte, error) {
+	if len(key)%8 != 0 || len(key) < 16 {
+		return nil, fmt.Errorf("%w: invalid key length %d for key wrap", ErrJWE, len(key))
+	}
+
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrJWE, err)
+	}
+
+	n := len(key) / 8 //nolint:mnd
+	r := bytes.Clone(key)
+	a := bytes.Clone(aesKeyWrapIV)
+	buf := make([]byte, 16) //nolint:mnd
+
+	for j := range 6 {
+		for i := range n {
+			copy(buf, a)
+			copy(buf[8:], r[i*8:(i+1)*8])
+			block.Encrypt(buf, buf)
+
+			t := uint64(n*j + i + 1) //nolint:gosec
+			binary.BigEndian.PutUint64(a, binary.BigEndian.Uint64(buf[:8])^t)
+			copy(r[i*8:], buf[8:])
+		}
+	}
+
+	return append(a, r...), nil
+}
+
+// aesKeyUnwrap восстанавливает ключ, обернутый по алгоритму AES Key Wrap (RFC 3394).
+func aesKeyUnwrap(kek, wrapped []byte) ([]byte, error) {
+	if len(wrapped)%8 != 0 || len(wrapped) < 24 {
+		return nil, fmt.Errorf("%w: invalid wrapped key length %d", ErrJWE, len(wrapped))
+	}
+
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrJWE, err)
+	}
+
+	n := len(wrapped)/8 - 1 //nolint:mnd
+	a := bytes.Clone(wrapped[:8])
+	r := bytes.Clone(wrapped[8:])
+	buf := make([]byte, 16) //nolint:mnd
+
+	for j := 5; j >= 0; j-- {
+		for i := n - 1; i >= 0; i-- {
+			t := uint64(n*j + i + 1) //nolint:gosec
+			binary.BigEndian.PutUint64(buf, binary.BigEndian.Uint64(a)^t)
+			copy(buf[8:], r[i*8:(i+1)*8])
+			block.Decrypt(buf, buf)
+
+			copy(a, buf[:8])
+			copy(r[i*8:], buf[8:])
+		}
+	}
+
+	if subtle.ConstantTimeCompare(a, aesKeyWrapIV) != 1 {
+		return nil, fmt.Errorf("%w: key unwrap integrity check failed", ErrJWE)
+	}
+
+	return r, nil
+}
@@ -0,0 +1,118 @@
+package fluent_test
+
+import (
+	"bytes"
+	"compress/flate"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/devem-tech/fluent"
+)
+
+// jweA3 — пример из RFC 7516, приложение A.3 (A128KW + A128CBC-HS256).
+const jweA3 = "eyJhbGciOiJBMTI4S1ciLCJlbmMiOiJBMTI4Q0JDLUhTMjU2In0." +
+	"6KB707dM9YTIgHtLvtgWQ8mKwboJW3of9locizkDTHzBC2IlrT1oOQ." +
+	"AxY8DCtDaGlsbGljb3RoZQ." +
+	"KDlTtXchhZTGufMYmOYGS4HffxPSUrfmqCHXaI9wOGY." +
+	"U0m_YmjN04DJvceFICbCVQ"
+
+func jweA3Key(string) (any, error) {
+	return base64.RawURLEncoding.DecodeString("GawgguFyGrWKav7AX4VKUg")
+}
+
+func TestJWE_Decrypt_RFC7516A3(t *testing.T) {
+	t.Parallel()
+
+	plaintext, err := (&fluent.JWE{Key: jweA3Key}).Decrypt([]byte(jweA3))
+	if err != nil {
+		t.Fatalf("Decrypt returned error: %v", err)
+	}
+
+	if string(plaintext) != "Live long and prosper." {
+		t.Fatalf("unexpected plaintext: %q", plaintext)
+	}
+}
+
+func TestJWE_Decrypt_TamperedTag(t *testing.T) {
+	t.Parallel()
+
+	tampered := jweA3[:len(jweA3)-1] + "A"
+
+	_, err := (&fluent.JWE{Key: jweA3Key}).Decrypt([]byte(tampered))
+	if !errors.Is(err, fluent.ErrJWE) {
+		t.Fatalf("expected ErrJWE, got: %v", err)
+	}
+}
+
+// deflatedJWE шифрует plaintext со сжатием zip=DEF (dir + A128GCM).
+func deflatedJWE(t *testing.T, key, plaintext []byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	fw, _ := flate.NewWriter(&buf, flate.BestCompression)
+	_, _ = fw.Write(plaintext)
+	_ = fw.Close()
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gcm, _ := cipher.NewGCM(block)
+	iv := make([]byte, gcm.NonceSize())
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"dir","enc":"A128GCM","zip":"DEF"}`))
+
+	sealed := gcm.Seal(nil, iv, buf.Bytes(), []byte(header))
+	ciphertext, tag := sealed[:len(sealed)-gcm.Overhead()], sealed[len(sealed)-gcm.Overhead():]
+
+	enc := base64.RawURLEncoding.EncodeToString
+
+	return []byte(header + ".." + enc(iv) + "." + enc(ciphertext) + "." + enc(tag))
+}
+
+func TestJWE_Decrypt_InflateLimit(t *testing.T) {
+	t.Parallel()
+
+	key := bytes.Repeat([]byte{1}, 16)
+	j := &fluent.JWE{Key: func(string) (any, error) { return key, nil }, MaxInflated: 1 << 10}
+
+	if got, err := j.Decrypt(deflatedJWE(t, key, []byte("hello"))); err != nil || string(got) != "hello" {
+		t.Fatalf("unexpected plaintext %q: %v", got, err)
+	}
+
+	bomb := deflatedJWE(t, key, []byte(strings.Repeat("a", 1<<20)))
+	if _, err := j.Decrypt(bomb); !errors.Is(err, fluent.ErrJWE) {
+		t.Fatalf("expected ErrJWE for oversized plaintext, got %v", err)
+	}
+}
+
+func TestClient_Decrypt_Raw(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/jose")
+		_, _ = w.Write([]byte(jweA3))
+	}))
+	t.Cleanup(srv.Close)
+
+	data, err := fluent.New().
+		BaseURL(srv.URL).
+		Decrypt(&fluent.JWE{Key: jweA3Key}).
+		Get(context.Background(), "/").
+		Raw()
+	if err != nil {
+		t.Fatalf("Raw returned error: %v", err)
+	}
+
+	if string(data) != "Live long and prosper." {
+		t.Fatalf("unexpected body: %q", data)
+	}
+}
@@ -0,0 +1,179 @@
+package fluent
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"time"
+)
+
+// ErrJWS возвращается при ошибках подписи JWS.
+var ErrJWS = errors.New("jws")
+
+// DefaultJWSHeader — заголовок, в котором по умолчанию передается detached-подпись.
+const DefaultJWSHeader = "X-Jws-Signature"
+
+// JWS подписывает тела запросов по RFC 7515.
+//
+// Поддерживаемые алгоритмы: HS256, HS384, HS512, RS256, RS384, RS512, PS256, PS384, PS512, ES256, ES384, ES512.
+// Если Detached = false, тело запроса заменяется компактной сериализацией JWS с Content-Type: application/jose.
+// Если Detached = true, тело не меняется, а подпись вида "header..signature" (RFC 7515, приложение F)
+// передается в заголовке DetachedHeader. Незакодированный payload (RFC 7797) включается через Header["b64"] = false.
+type JWS struct {
+	// Alg — алгоритм подписи.
+	Alg string
+	// Key — ключ подписи: []byte для HS*, *rsa.PrivateKey для RS* и PS*, *ecdsa.PrivateKey для ES*.
+	Key any
+	// Kid — идентификатор ключа, попадает в заголовок kid, если не пустой.
+	Kid string
+	// Header — дополнительные поля защищенного заголовка (например, crit или iat).
+	Header map[string]any
+	// Detached включает detached-подпись.
+	Detached bool
+	// DetachedHeader — заголовок для detached-подписи. По умолчанию DefaultJWSHeader.
+	DetachedHeader string
+	// IssuedAt — имя поля защищенного заголовка, в которое при подписи записывается текущее Unix-время,
+	// например "iat" или "http://openbanking.org.uk/iat". Пустое значение отключает метку времени.
+	IssuedAt string
+	// Clock — измеренное расхождение с часами сервера. Если задано, метка IssuedAt берется
+	// с поправкой на него, чтобы подписи проходили проверку даже при неточных локальных часах.
+	Clock *ClockSkew
+}
+
+// Seal подписывает тело запроса.
+func (j *JWS) Seal(body []byte, header http.Header) ([]byte, error) {
+	fields := make(map[string]any, len(j.Header)+3) //nolint:mnd
+	for k, v := range j.Header {
+		fields[k] = v
+	}
+
+	fields["alg"] = j.Alg
+	if j.Kid != "" {
+		fields["kid"] = j.Kid
+	}
+
+	if j.IssuedAt != "" {
+		now := time.Now()
+		if j.Clock != nil {
+			now = j.Clock.Now()
+		}
+
+		fields[j.IssuedAt] = now.Unix()
+	}
+
+	h, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	protected := base64.RawURLEncoding.EncodeToString(h)
+
+	payload := base64.RawURLEncoding.EncodeToString(body)
+	if b64, ok := j.Header["b64"].(bool); ok && !b64 {
+		payload = string(body)
+	}
+
+	signature, err := j.sign([]byte(protected + "." + payload))
+	if err != nil {
+		return nil, err
+	}
+
+	sig := base64.RawURLEncoding.EncodeToString(signature)
+
+	if j.Detached {
+		name := j.DetachedHeader
+		if name == "" {
+			name = DefaultJWSHeader
+		}
+
+		header.Set(name, protected+".."+sig)
+
+		return body, nil
+	}
+
+	header.Set("Content-Type", "application/jose")
+
+	return []byte(protected + "." + payload + "." + sig), nil
+}
+
+// sign вычисляет подпись над signingInput согласно Alg.
+func (j *JWS) sign(signingInput []byte) ([]byte, error) {
+	if len(j.Alg) != 5 { //nolint:mnd
+		return nil, fmt.Errorf("%w: unsupported alg %q", ErrJWS, j.Alg)
+	}
+
+	var hash crypto.Hash
+
+	switch j.Alg[2:] {
+	case "256":
+		hash = crypto.SHA256
+	case "384":
+		hash = crypto.SHA384
+	case "512":
+		hash = crypto.SHA512
+	default:
+		return nil, fmt.Errorf("%w: unsupported alg %q", ErrJWS, j.Alg)
+	}
+
+	switch j.Alg[:2] {
+	case "HS":
+		key, ok := j.Key.([]byte)
+		if !ok {
+			return nil, fmt.Errorf("%w: alg %s requires []byte key, got %T", ErrJWS, j.Alg, j.Key)
+		}
+
+		mac := hmac.New(hash.New, key)
+		mac.Write(signingInput)
+
+		return mac.Sum(nil), nil
+	case "RS", "PS":
+		key, ok := j.Key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("%w: alg %s requires *rsa.PrivateKey, got %T", ErrJWS, j.Alg, j.Key)
+		}
+
+		digest := hash.New()
+		digest.Write(signingInput)
+
+		if j.Alg[0] == 'R' {
+			return rsa.SignPKCS1v15(rand.Reader, key, hash, digest.Sum(nil))
+		}
+
+		return rsa.SignPSS(rand.Reader, key, hash, digest.Sum(nil), &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+	case "ES":
+		key, ok := j.Key.(*ecdsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("%w: alg %s requires *ecdsa.PrivateKey, got %T", ErrJWS, j.Alg, j.Key)
+		}
+
+		digest := hash.New()
+		digest.Write(signingInput)
+
+		r, s, err := ecdsa.Sign(rand.Reader, key, digest.Sum(nil))
+		if err != nil {
+			return nil, err
+		}
+
+		// JWS использует конкатенацию R||S фиксированной длины, а не ASN.1 DER.
+		size := (key.Curve.Params().BitSize + 7) / 8 //nolint:mnd
+
+		return append(padInt(r, size), padInt(s, size)...), nil
+	default:
+		return nil, fmt.Errorf("%w: unsupported alg %q", ErrJWS, j.Alg)
+	}
+}
+
+// padInt возвращает big-endian представление n, дополненное нулями слева до size байт.
+func padInt(n *big.Int, size int) []byte {
+	b := make([]byte, size)
+
+	return n.FillBytes(b)
+}
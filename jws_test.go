@@ -0,0 +1,159 @@
+package fluent_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/devem-tech/fluent"
+)
+
+func verifyHS256(t *testing.T, key []byte, signingInput, signature string) {
+	t.Helper()
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(signingInput))
+
+	if base64.RawURLEncoding.EncodeToString(mac.Sum(nil)) != signature {
+		t.Fatal("signature mismatch")
+	}
+}
+
+func TestClient_Seal_DetachedJWS(t *testing.T) {
+	t.Parallel()
+
+	key := []byte("secret")
+
+	var (
+		gotBody []byte
+		gotSig  string
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotBody, _ = io.ReadAll(r.Body)
+		gotSig = r.Header.Get(fluent.DefaultJWSHeader)
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(srv.Close)
+
+	err := fluent.New().
+		BaseURL(srv.URL).
+		Seal(&fluent.JWS{Alg: "HS256", Key: key, Detached: true}).
+		Body(map[string]any{"amount": 10}).
+		Post(context.Background(), "/payments").
+		Error()
+	if err != nil {
+		t.Fatalf("Post returned error: %v", err)
+	}
+
+	if string(gotBody) != `{"amount":10}` {
+		t.Fatalf("expected body to stay unchanged, got %q", gotBody)
+	}
+
+	parts := strings.Split(gotSig, ".")
+	if len(parts) != 3 || parts[1] != "" {
+		t.Fatalf("expected detached signature, got %q", gotSig)
+	}
+
+	verifyHS256(t, key, parts[0]+"."+base64.RawURLEncoding.EncodeToString(gotBody), parts[2])
+}
+
+func TestClient_Seal_SignThenEncrypt(t *testing.T) {
+	t.Parallel()
+
+	signKey := []byte("secret")
+	encKey := []byte("0123456789abcdef0123456789abcdef")
+	keys := func(string) (any, error) { return encKey, nil }
+
+	var (
+		gotBody        []byte
+		gotContentType string
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotBody, _ = io.ReadAll(r.Body)
+		gotContentType = r.Header.Get("Content-Type")
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(srv.Close)
+
+	err := fluent.New().
+		BaseURL(srv.URL).
+		Seal(
+			&fluent.JWS{Alg: "HS256", Key: signKey},
+			&fluent.JWE{Key: keys, Alg: "A256KW", Enc: "A256GCM", Kid: "k1"},
+		).
+		Body(map[string]any{"amount": 10}).
+		Post(context.Background(), "/payments").
+		Error()
+	if err != nil {
+		t.Fatalf("Post returned error: %v", err)
+	}
+
+	if gotContentType != "application/jose" {
+		t.Fatalf("expected Content-Type application/jose, got %q", gotContentType)
+	}
+
+	jws, err := (&fluent.JWE{Key: keys}).Decrypt(gotBody)
+	if err != nil {
+		t.Fatalf("Decrypt returned error: %v", err)
+	}
+
+	parts := strings.Split(string(jws), ".")
+	if len(parts) != 3 {
+		t.Fatalf("expected compact JWS, got %q", jws)
+	}
+
+	verifyHS256(t, signKey, parts[0]+"."+parts[1], parts[2])
+
+	payload, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	if string(payload) != `{"amount":10}` {
+		t.Fatalf("unexpected payload: %q", payload)
+	}
+}
+
+func TestJWS_IssuedAt_AppliesClockSkew(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Date", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+	}))
+	t.Cleanup(srv.Close)
+
+	skew := &fluent.ClockSkew{}
+
+	if err := fluent.New().ClockSkew(skew).Get(context.Background(), srv.URL).Error(); err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+
+	header := make(http.Header)
+
+	jws := &fluent.JWS{Alg: "HS256", Key: []byte("secret"), Detached: true, IssuedAt: "iat", Clock: skew}
+	if _, err := jws.Seal([]byte("{}"), header); err != nil {
+		t.Fatalf("Seal returned error: %v", err)
+	}
+
+	protected, _ := base64.RawURLEncoding.DecodeString(strings.Split(header.Get(fluent.DefaultJWSHeader), ".")[0])
+
+	var fields struct {
+		IAT int64 `json:"iat"`
+	}
+
+	if err := json.Unmarshal(protected, &fields); err != nil {
+		t.Fatalf("invalid protected header: %v", err)
+	}
+
+	if d := time.Until(time.Unix(fields.IAT, 0)); d < 59*time.Minute || d > 61*time.Minute {
+		t.Fatalf("expected iat about an hour ahead, got %v", d)
+	}
+}
@@ -0,0 +1,58 @@
+package fluent
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// localeKey — ключ контекста для WithLocale.
+type localeKey struct{}
+
+// Locale задает языки пользователя в порядке предпочтения (теги BCP 47, например "de-CH", "de", "en").
+// Они передаются в Accept-Language с убывающими весами: "de-CH, de;q=0.9, en;q=0.8".
+// Языки из контекста (WithLocale) имеют приоритет, а явно заданный заголовок Accept-Language не изменяется.
+func (c *Client) Locale(tags ...string) *Client {
+	c.locale = tags
+
+	return c
+}
+
+// WithLocale возвращает контекст с языками пользователя для Accept-Language, чтобы прокси к
+// локализованным сервисам передавал язык каждого входящего запроса без перенастройки клиента.
+func WithLocale(ctx context.Context, tags ...string) context.Context {
+	return context.WithValue(ctx, localeKey{}, tags)
+}
+
+// applyLocale выставляет Accept-Language из контекста запроса или Locale, если заголовок еще не задан.
+func (c *Client) applyLocale(req *http.Request) {
+	tags, ok := req.Context().Value(localeKey{}).([]string)
+	if !ok {
+		tags = c.locale
+	}
+
+	if len(tags) == 0 || req.Header.Get("Accept-Language") != "" {
+		return
+	}
+
+	req.Header.Set("Accept-Language", acceptLanguage(tags))
+}
+
+// acceptLanguage форматирует теги с весами, которые убывают на 0.1 и не опускаются ниже 0.1.
+func acceptLanguage(tags []string) string {
+	parts := make([]string, len(tags))
+
+	for i, tag := range tags {
+		if i == 0 {
+			parts[i] = tag
+
+			continue
+		}
+
+		q := max(10-i, 1) //nolint:mnd
+		parts[i] = tag + ";q=0." + strconv.Itoa(q)
+	}
+
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,132 @@
+package fluent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ErrNoLocation возвращается Response.Location, если в ответе нет заголовка Location.
+var ErrNoLocation = errors.New("no Location header in response")
+
+// Location возвращает заголовок Location ответа как URL, разрешенный относительно URL запроса.
+// Так создающие эндпоинты, вся полезная информация которых — адрес нового ресурса в ответе 201 Created,
+// не требуют ручного разбора заголовка. Работает и для ответов 3xx, которые вернул http-клиент
+// без перехода по редиректу (они приходят как HTTPError). Если ответа нет, возвращается ошибка запроса,
+// а если заголовка нет — ErrNoLocation.
+func (r *Response) Location() (*url.URL, error) {
+	h := r.Header()
+	if h == nil {
+		return nil, r.err
+	}
+
+	location := h.Get("Location")
+	if location == "" {
+		return nil, ErrNoLocation
+	}
+
+	base := r.requestURL()
+	if base == nil {
+		return url.Parse(location)
+	}
+
+	u, err := base.Parse(location)
+	if err != nil {
+		return nil, fmt.Errorf("location: %w", err)
+	}
+
+	return u, nil
+}
+
+// requestURL возвращает URL, на который получен ответ, или nil.
+func (r *Response) requestURL() *url.URL {
+	if resp := r.HTTP(); resp != nil && resp.Request != nil {
+		return resp.Request.URL
+	}
+
+	var e *HTTPError
+	if errors.As(r.err, &e) {
+		if u, err := url.Parse(e.URL); err == nil {
+			return u
+		}
+	}
+
+	return nil
+}
+
+// FollowLocation делает результатом запросов ресурс, на который указывает Location ответов 201 Created
+// и 202 Accepted: после такого ответа клиент запрашивает его через GET с заголовками и авторизацией исходного
+// запроса и возвращает этот ответ. Если Location ведет на другой origin (схему, хост или порт), например
+// на presigned-URL хранилища, запрос уходит без Authorization, Proxy-Authorization и Cookie, в том числе
+// выставленных middleware, и без подписей Sign — так же, как http.Client поступает при редиректах. Так Into сразу декодирует созданный ресурс, даже если создающий эндпоинт
+// отвечает пустым телом. Остальные ответы возвращаются как есть. Для длительных операций, адрес статуса
+// которых нужно опрашивать, используйте Accepted. Действует до вызова Reset.
+func (c *Client) FollowLocation() *Client {
+	c.follow = true
+
+	return c
+}
+
+// FollowLocation делает результатом запроса ресурс из Location ответа 201 или 202, так же как
+// Client.FollowLocation.
+func (r *Request) FollowLocation() *Request {
+	r.follow = true
+
+	return r
+}
+
+// followLocation запрашивает ресурс из Location ответа resp, если это ответ 201 или 202. Повторный запрос
+// сохраняет заголовки и разовые настройки r, но не query-параметры и тело: Location уже содержит полный адрес.
+func (c *Client) followLocation(ctx context.Context, r *requestState, resp *Response) *Response {
+	if resp.err != nil || resp.resp.StatusCode != http.StatusCreated && resp.resp.StatusCode != http.StatusAccepted {
+		return resp
+	}
+
+	location, err := resp.Location()
+	if err != nil {
+		return resp
+	}
+
+	_, _ = io.Copy(io.Discard, resp.resp.Body)
+	resp.resp.Body.Close()
+
+	state := r.fork()
+	state.params, state.keys, state.rawQuery, state.body = make(url.Values), nil, "", nil
+	state.sharedParams = false
+
+	if base := resp.requestURL(); base == nil || !sameOrigin(base, location) {
+		ctx = context.WithValue(ctx, crossOriginKey{}, true)
+	}
+
+	return c.exchange(ctx, http.MethodGet, location.String(), &state)
+}
+
+// crossOriginKey — ключ контекста запроса на другой origin, которому не передаются учетные данные.
+type crossOriginKey struct{}
+
+// crossOriginHeaders — заголовки с учетными данными, которые не отправляются на другой origin.
+var crossOriginHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Cookie2", "Www-Authenticate"}
+
+// sameOrigin сообщает, что у a и b совпадают схема, хост и порт.
+func sameOrigin(a, b *url.URL) bool {
+	return strings.EqualFold(a.Scheme, b.Scheme) && strings.EqualFold(a.Host, b.Host)
+}
+
+// withoutCredentials возвращает копию req без заголовков с учетными данными и true, если запрос идет
+// на другой origin (см. FollowLocation). Иначе возвращается сам req и false.
+func withoutCredentials(req *http.Request) (*http.Request, bool) {
+	if cross, _ := req.Context().Value(crossOriginKey{}).(bool); !cross {
+		return req, false
+	}
+
+	req = req.Clone(req.Context())
+	for _, name := range crossOriginHeaders {
+		req.Header.Del(name)
+	}
+
+	return req, true
+}
@@ -0,0 +1,248 @@
+package fluent
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// redacted заменяет значения скрытых заголовков и query-параметров в логах.
+const redacted = "REDACTED"
+
+// DefaultRedactedHeaders — заголовки, значения которых Logger всегда скрывает.
+var DefaultRedactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// LogOption настраивает Logger.
+type LogOption func(o *logOptions)
+
+type logOptions struct {
+	bodies  int
+	files   bool
+	dir     string
+	headers bool
+	redact  map[string]bool
+	mask    map[string]bool
+}
+
+// LogBodies добавляет в лог до limit байтов тел запроса и ответа. Тело запроса берется через GetBody,
+// поэтому потоковые тела (BodyReader) не логируются. Начало тела ответа читается до возврата
+// Response, поэтому для потоков событий и длинных скачиваний опцию лучше не включать.
+func LogBodies(limit int) LogOption {
+	return func(o *logOptions) { o.bodies = limit }
+}
+
+// LogBodyFiles сохраняет тела длиннее лимита LogBodies целиком во временные файлы в dir (пустая строка —
+// os.TempDir), а в лог добавляет их пути (request_body_file и response_body_file). Так многомегабайтные
+// ответы не засоряют лог, но остаются доступны для разбора. Тело ответа записывается в файл по мере чтения
+// и целиком попадает в него, когда вызывающий код дочитал его до конца. Файлы не удаляются автоматически
+// и содержат тела как есть, поэтому не включайте опцию в продакшене. Без LogBodies опция не действует.
+func LogBodyFiles(dir string) LogOption {
+	return func(o *logOptions) { o.files, o.dir = true, dir }
+}
+
+// LogHeaders добавляет в лог заголовки запроса и ответа.
+func LogHeaders() LogOption {
+	return func(o *logOptions) { o.headers = true }
+}
+
+// RedactHeaders скрывает значения заголовков names в дополнение к DefaultRedactedHeaders, например "X-Api-Key".
+func RedactHeaders(names ...string) LogOption {
+	return func(o *logOptions) {
+		for _, name := range names {
+			o.redact[http.CanonicalHeaderKey(name)] = true
+		}
+	}
+}
+
+// MaskQuery скрывает значения query-параметров names в URL, например "api_key" или "token".
+func MaskQuery(names ...string) LogOption {
+	return func(o *logOptions) {
+		for _, name := range names {
+			o.mask[name] = true
+		}
+	}
+}
+
+// Logger логирует каждую попытку запроса в logger: метод, URL, код ответа и длительность, а с опциями —
+// заголовки и начала тел. Значения Authorization, Cookie и других заголовков из DefaultRedactedHeaders
+// и пароль в URL всегда скрываются. Ответы 4xx и 5xx логируются с уровнем Warn, сетевые ошибки — Error,
+// остальные — Info. Logger подключается как middleware через Use:
+//
+//	c.Logger(slog.Default(), fluent.MaskQuery("api_key"), fluent.LogBodies(512))
+func (c *Client) Logger(logger *slog.Logger, opts ...LogOption) *Client {
+	o := &logOptions{redact: make(map[string]bool), mask: make(map[string]bool)}
+	for _, name := range DefaultRedactedHeaders {
+		o.redact[name] = true
+	}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return c.Use(func(next RoundFunc) RoundFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			start := time.Now()
+			resp, err := next(req)
+
+			attrs := []slog.Attr{
+				slog.String("method", req.Method),
+				slog.String("url", o.url(req.URL)),
+				slog.Duration("duration", time.Since(start)),
+			}
+
+			if o.headers {
+				attrs = append(attrs, slog.Any("request_headers", o.redactHeader(req.Header)))
+			}
+
+			if o.bodies > 0 && req.GetBody != nil {
+				if body, err := req.GetBody(); err == nil {
+					attrs = append(attrs, o.head(body)...)
+				}
+			}
+
+			level := slog.LevelInfo
+
+			if err != nil {
+				level = slog.LevelError
+				attrs = append(attrs, slog.Any("error", err))
+			} else {
+				if resp.StatusCode >= http.StatusBadRequest {
+					level = slog.LevelWarn
+				}
+
+				attrs = append(attrs, slog.Int("status", resp.StatusCode))
+
+				if o.headers {
+					attrs = append(attrs, slog.Any("response_headers", o.redactHeader(resp.Header)))
+				}
+
+				if o.bodies > 0 {
+					attrs = append(attrs, o.peek(resp)...)
+				}
+			}
+
+			logger.LogAttrs(req.Context(), level, "http request", attrs...)
+
+			return resp, err
+		}
+	})
+}
+
+// url возвращает URL без пароля и со скрытыми значениями параметров из MaskQuery.
+// Порядок и кодирование остальных параметров не меняются.
+func (o *logOptions) url(u *url.URL) string {
+	if len(o.mask) == 0 || u.RawQuery == "" {
+		return u.Redacted()
+	}
+
+	parts := strings.Split(u.RawQuery, "&")
+	for i, part := range parts {
+		key, _, _ := strings.Cut(part, "=")
+		if name, err := url.QueryUnescape(key); err == nil && o.mask[name] {
+			parts[i] = key + "=" + redacted
+		}
+	}
+
+	cp := *u
+	cp.RawQuery = strings.Join(parts, "&")
+
+	return cp.Redacted()
+}
+
+// redactHeader возвращает копию h со скрытыми значениями заголовков из RedactHeaders.
+func (o *logOptions) redactHeader(h http.Header) http.Header {
+	cp := h.Clone()
+	for name := range cp {
+		if o.redact[http.CanonicalHeaderKey(name)] {
+			cp[name] = []string{redacted}
+		}
+	}
+
+	return cp
+}
+
+// head читает и закрывает body, возвращая не больше o.bodies байтов, а с LogBodyFiles — еще и путь к файлу
+// с телом целиком, если оно длиннее.
+func (o *logOptions) head(body io.ReadCloser) []slog.Attr {
+	defer body.Close()
+
+	if !o.files {
+		data, _ := io.ReadAll(io.LimitReader(body, int64(o.bodies)))
+
+		return []slog.Attr{slog.String("request_body", string(data))}
+	}
+
+	data, _ := io.ReadAll(body)
+	attrs := []slog.Attr{slog.String("request_body", string(data[:min(len(data), o.bodies)]))}
+
+	if len(data) > o.bodies {
+		if path, err := o.save("request", bytes.NewReader(data)); err == nil {
+			attrs = append(attrs, slog.String("request_body_file", path))
+		}
+	}
+
+	return attrs
+}
+
+// peek возвращает начало тела ответа, не расходуя его, так же как Response.Peek. С LogBodyFiles тело
+// длиннее лимита по мере чтения копируется в файл, путь к которому тоже возвращается.
+func (o *logOptions) peek(resp *http.Response) []slog.Attr {
+	size := o.bodies
+	if o.files {
+		size++
+	}
+
+	pb := &peekBody{Reader: bufio.NewReaderSize(resp.Body, size), Closer: resp.Body}
+	resp.Body = pb
+
+	data, _ := pb.Peek(size)
+	attrs := []slog.Attr{slog.String("response_body", string(data[:min(len(data), o.bodies)]))}
+
+	if len(data) <= o.bodies {
+		return attrs
+	}
+
+	f, err := os.CreateTemp(o.dir, "fluent-response-*.body")
+	if err != nil {
+		return attrs
+	}
+
+	resp.Body = &teeFileBody{Reader: io.TeeReader(pb, f), body: pb, f: f}
+
+	return append(attrs, slog.String("response_body_file", f.Name()))
+}
+
+// save записывает r во временный файл и возвращает его путь.
+func (o *logOptions) save(kind string, r io.Reader) (string, error) {
+	f, err := os.CreateTemp(o.dir, "fluent-"+kind+"-*.body")
+	if err != nil {
+		return "", err
+	}
+
+	_, err = io.Copy(f, r)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+
+	return f.Name(), err
+}
+
+// teeFileBody копирует прочитанное тело ответа в файл и закрывает файл вместе с телом.
+type teeFileBody struct {
+	io.Reader
+
+	body io.Closer
+	f    *os.File
+}
+
+func (b *teeFileBody) Close() error {
+	b.f.Close()
+
+	return b.body.Close()
+}
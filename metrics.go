@@ -0,0 +1,164 @@
+package fluent
+
+import (
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Metrics получает сведения о каждом выполненном запросе, например для Prometheus (см. пакет fluentprom).
+type Metrics interface {
+	// ObserveRequest вызывается один раз на запрос: status — код ответа или 0, если ответа нет
+	// (сетевая ошибка), duration — время до получения заголовков ответа, как Response.Duration,
+	// size — число прочитанных байтов тела ответа.
+	ObserveRequest(method, host string, status int, duration time.Duration, size int64)
+}
+
+// RequestLabels — дополнительные метки запроса для LabeledMetrics.
+type RequestLabels struct {
+	// CostCenter — тег центра затрат клиента (см. CostCenter) или пустая строка.
+	CostCenter string
+}
+
+// LabeledMetrics — Metrics, который получает также метки запроса, например чтобы разделять метрики
+// по центрам затрат. Если получатель метрик реализует LabeledMetrics, вместо ObserveRequest вызывается
+// ObserveRequestLabels.
+type LabeledMetrics interface {
+	Metrics
+	ObserveRequestLabels(method, host string, status int, duration time.Duration, size int64, labels RequestLabels)
+}
+
+// Metrics задает получателя метрик запросов. Для успешных ответов метрики передаются, когда тело
+// закрыто (Raw, Into и другие методы закрывают его сами), чтобы учесть его размер, а для остальных — сразу.
+// Запросы, отклоненные до отправки (Precheck, NegativeCache), не учитываются.
+func (c *Client) Metrics(m Metrics) *Client {
+	c.metrics = m
+
+	return c
+}
+
+// meter собирает метрики одного запроса. Методы nil-meter ничего не делают.
+type meter struct {
+	m      Metrics
+	method string
+	host   string
+	labels RequestLabels
+	start  time.Time
+
+	mu       sync.Mutex
+	status   int
+	duration time.Duration
+	size     int64
+	owned    bool
+	done     bool
+}
+
+// newMeter начинает измерение запроса req или возвращает nil, если Metrics не задан.
+func (c *Client) newMeter(req *http.Request) *meter {
+	if c.metrics == nil {
+		return nil
+	}
+
+	return &meter{
+		m:      c.metrics,
+		method: req.Method,
+		host:   req.URL.Host,
+		labels: RequestLabels{CostCenter: c.costCenter.tag},
+		start:  time.Now(),
+	}
+}
+
+// received фиксирует время получения заголовков и код ответа resp, если он есть.
+func (m *meter) received(resp *http.Response) {
+	if m == nil {
+		return
+	}
+
+	m.duration = time.Since(m.start)
+	if resp != nil {
+		m.status = resp.StatusCode
+	}
+}
+
+// read учитывает n прочитанных байтов тела.
+func (m *meter) read(n int) {
+	if m != nil {
+		m.mu.Lock()
+		m.size += int64(n)
+		m.mu.Unlock()
+	}
+}
+
+// wrap передает завершение измерения телу ответа: метрики будут переданы при его закрытии.
+func (m *meter) wrap(body io.ReadCloser) io.ReadCloser {
+	if m == nil {
+		return body
+	}
+
+	m.owned = true
+
+	mb := &meterBody{ReadCloser: body, m: m}
+	if s, ok := body.(io.Seeker); ok {
+		return seekableMeterBody{meterBody: mb, Seeker: s}
+	}
+
+	return mb
+}
+
+// release передает метрики запроса, тело которого не досталось вызывающему.
+func (m *meter) release() {
+	if m != nil && !m.owned {
+		m.finish()
+	}
+}
+
+// finish передает метрики один раз.
+func (m *meter) finish() {
+	m.mu.Lock()
+	if m.done {
+		m.mu.Unlock()
+
+		return
+	}
+
+	m.done = true
+	size := m.size
+	m.mu.Unlock()
+
+	if lm, ok := m.m.(LabeledMetrics); ok {
+		lm.ObserveRequestLabels(m.method, m.host, m.status, m.duration, size, m.labels)
+
+		return
+	}
+
+	m.m.ObserveRequest(m.method, m.host, m.status, m.duration, size)
+}
+
+// meterBody считает прочитанные байты тела ответа и передает метрики при закрытии.
+type meterBody struct {
+	io.ReadCloser
+
+	m *meter
+}
+
+func (b *meterBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.m.read(n)
+
+	return n, err
+}
+
+func (b *meterBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.m.finish()
+
+	return err
+}
+
+// seekableMeterBody — meterBody для тел, сохраненных SpoolToDisk, который сохраняет io.Seeker для Seekable.
+// Байты, прочитанные повторно после Seek, учитываются в размере ответа еще раз.
+type seekableMeterBody struct {
+	*meterBody
+	io.Seeker
+}
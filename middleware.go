@@ -0,0 +1,78 @@
+package fluent
+
+import "net/http"
+
+// RoundFunc отправляет HTTP-запрос и возвращает ответ.
+type RoundFunc func(req *http.Request) (*http.Response, error)
+
+// Middleware оборачивает RoundFunc, получая доступ к исходящему *http.Request и полученному *http.Response.
+// Так логирование, авторизация, метрики и собственные повторы подключаются как независимые слои:
+//
+//	c.Use(func(next fluent.RoundFunc) fluent.RoundFunc {
+//		return func(req *http.Request) (*http.Response, error) {
+//			start := time.Now()
+//			resp, err := next(req)
+//			log.Println(req.Method, req.URL, time.Since(start))
+//
+//			return resp, err
+//		}
+//	})
+type Middleware func(next RoundFunc) RoundFunc
+
+// Use добавляет middleware в цепочку клиента. Первый добавленный middleware — внешний:
+// он первым видит запрос и последним — ответ. Middleware вызывается на каждую попытку запроса,
+// включая повторы RetryPolicy, и применяется также в Transport. Как и http.RoundTripper,
+// middleware не должен изменять переданный запрос: для изменений используйте req.Clone.
+func (c *Client) Use(middleware ...Middleware) *Client {
+	c.middleware = append(c.middleware, middleware...)
+
+	return c
+}
+
+// chain возвращает RoundFunc, который отправляет запрос через client, обернутый цепочкой middleware.
+func (c *Client) chain(client httpClient) RoundFunc {
+	next := func(req *http.Request) (*http.Response, error) {
+		// Подпись вычисляется последней, по окончательному виду запроса. Запросы на другой origin
+		// не подписываются и уходят без учетных данных, даже если их добавил middleware
+		req, cross := withoutCredentials(req)
+		if !cross {
+			var err error
+			if req, err = c.sign(req); err != nil {
+				return nil, err
+			}
+		}
+
+		if c.debug != nil {
+			c.debug.dump(req)
+		}
+
+		if c.bandwidth != nil {
+			req = c.bandwidth.limit(req)
+		}
+
+		resp, err := c.send(client, req)
+		if err == nil {
+			c.compat.tolerate(resp)
+		}
+
+		if err == nil && c.bandwidth != nil {
+			resp.Body = &throttledBody{ReadCloser: resp.Body, ctx: req.Context(), bw: c.bandwidth}
+		}
+
+		if err != nil || len(c.accept) == 0 {
+			return resp, err
+		}
+
+		if err := decompress(resp, c.accept); err != nil {
+			return nil, err
+		}
+
+		return resp, nil
+	}
+
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		next = c.middleware[i](next)
+	}
+
+	return next
+}
@@ -0,0 +1,79 @@
+package fluent
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// Each построчно декодирует поток JSON-значений из тела ответа (application/x-ndjson, JSON Lines
+// или просто последовательность JSON-документов) и вызывает fn для каждого элемента по мере чтения,
+// не буферизуя тело целиком. Ошибка fn останавливает чтение и возвращается из Each.
+// Опции opts (StrictFields, UseNumber) настраивают декодирование, а WithCheckpoint сообщает
+// позицию в потоке для продолжения после сбоя. Тело ответа автоматически закрывается.
+func Each[T any](r *Response, fn func(item T) error, opts ...DecodeOption) error {
+	if r.err != nil {
+		return r.err
+	}
+	defer r.resp.Body.Close()
+
+	o := newDecodeOptions(opts)
+	dec := o.decoder(r.resp.Body)
+
+	var cp Checkpoint
+
+	for {
+		var item T
+
+		err := dec.Decode(&item)
+		if errors.Is(err, io.EOF) {
+			if o.checkpoint != nil && cp.Records%int64(o.every) != 0 {
+				cp.Offset = dec.InputOffset()
+
+				return o.checkpoint(cp)
+			}
+
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if err := fn(item); err != nil {
+			return err
+		}
+
+		cp.Records++
+
+		if o.checkpoint != nil && cp.Records%int64(o.every) == 0 {
+			cp.Offset = dec.InputOffset()
+
+			if err := o.checkpoint(cp); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// IntoChan построчно декодирует поток JSON-значений из тела ответа, так же как Each, и отправляет
+// элементы в ch. По завершении ch закрывается, поэтому IntoChan обычно запускают в отдельной горутине,
+// а элементы читают через range:
+//
+//	items := make(chan Event)
+//	go func() { errc <- fluent.IntoChan(ctx, resp, items) }()
+//	for e := range items { ... }
+//
+// Если ctx отменен, пока получатель не читает из ch, IntoChan возвращает ошибку контекста.
+func IntoChan[T any](ctx context.Context, r *Response, ch chan<- T, opts ...DecodeOption) error {
+	defer close(ch)
+
+	return Each(r, func(item T) error {
+		select {
+		case ch <- item:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}, opts...)
+}
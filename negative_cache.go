@@ -0,0 +1,117 @@
+package fluent
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// negativeCacheSweep — размер кэша, после которого при записи удаляются устаревшие записи.
+const negativeCacheSweep = 1024
+
+// negativeCache запоминает ответы 404 и 410 на GET-запросы на короткое время.
+type negativeCache struct {
+	ttl time.Duration
+
+	mu        sync.Mutex
+	entries   map[string]negativeEntry
+	nextSweep int
+}
+
+type negativeEntry struct {
+	statusCode int
+	status     string
+	header     http.Header
+	body       []byte
+	requestID  string
+	expires    time.Time
+}
+
+func newNegativeCache(ttl time.Duration) *negativeCache {
+	return &negativeCache{
+		ttl:       ttl,
+		entries:   make(map[string]negativeEntry),
+		nextSweep: negativeCacheSweep,
+	}
+}
+
+// cacheable сообщает, подходит ли ответ для негативного кэша.
+func (nc *negativeCache) cacheable(method string, statusCode int) bool {
+	return method == http.MethodGet && (statusCode == http.StatusNotFound || statusCode == http.StatusGone)
+}
+
+func (nc *negativeCache) get(url string) (negativeEntry, bool) {
+	nc.mu.Lock()
+	defer nc.mu.Unlock()
+
+	e, ok := nc.entries[url]
+	if !ok {
+		return negativeEntry{}, false
+	}
+
+	if time.Now().After(e.expires) {
+		delete(nc.entries, url)
+
+		return negativeEntry{}, false
+	}
+
+	return e, true
+}
+
+// put запоминает ответ resp с телом body на запрос с идентификатором requestID.
+func (nc *negativeCache) put(url string, resp *http.Response, body []byte, requestID string) {
+	nc.mu.Lock()
+	defer nc.mu.Unlock()
+
+	now := time.Now()
+
+	if len(nc.entries) >= nc.nextSweep {
+		for k, e := range nc.entries {
+			if now.After(e.expires) {
+				delete(nc.entries, k)
+			}
+		}
+
+		nc.nextSweep = max(2*len(nc.entries), negativeCacheSweep) //nolint:mnd
+	}
+
+	nc.entries[url] = negativeEntry{
+		statusCode: resp.StatusCode,
+		status:     resp.Status,
+		header:     resp.Header.Clone(),
+		body:       body,
+		requestID:  requestID,
+		expires:    now.Add(nc.ttl),
+	}
+}
+
+// httpError возвращает ошибку, равную той, что была получена при исходном запросе: с его RequestID,
+// а API и Problem разбираются из тела так же, как для ответа сервера.
+func (e negativeEntry) httpError(c *Client, method, url string) *HTTPError {
+	return c.withAPIError(&HTTPError{
+		StatusCode: e.statusCode,
+		Status:     e.status,
+		Method:     method,
+		URL:        url,
+		Header:     e.header.Clone(),
+		Body:       bytes.Clone(e.body),
+		RequestID:  e.requestID,
+	})
+}
+
+// response восстанавливает закэшированный ответ для использования в Transport.
+func (e negativeEntry) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        e.status,
+		StatusCode:    e.statusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        e.header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(e.body)),
+		ContentLength: int64(len(e.body)),
+		Request:       req,
+	}
+}
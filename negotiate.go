@@ -0,0 +1,138 @@
+package fluent
+
+import (
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// Negotiation — предпочтения форматов для повторного согласования, если сервер отверг запрос
+// ответом 406 Not Acceptable или 415 Unsupported Media Type.
+type Negotiation struct {
+	// Accept — значения Accept в порядке предпочтения. Первое передается, если Accept не задан явно,
+	// а после ответа 406 запрос повторяется со следующими.
+	Accept []string
+	// ContentTypes — типы тела запроса в порядке предпочтения. После ответа 415 тело сериализуется заново
+	// следующим типом: "application/json" — в JSON, "application/xml" — в XML, остальные — кодеком
+	// из RegisterCodec. Тела BodyRaw, BodyString и BodyReader заново не сериализуются.
+	ContentTypes []string
+}
+
+// Negotiate включает повторное согласование формата: на ответ 406 или 415 запрос повторяется
+// с другими Accept или Content-Type из n, пока сервер не примет один из них или варианты не закончатся.
+// Тогда возвращается последний ответ. Нужно для API, которые поддерживают разные форматы в разных версиях
+// или регионах:
+//
+//	c.Negotiate(fluent.Negotiation{
+//		Accept:       []string{"application/vnd.api.v2+json", "application/json"},
+//		ContentTypes: []string{"application/json", "application/xml"},
+//	})
+func (c *Client) Negotiate(n Negotiation) *Client {
+	c.negotiate = n
+
+	return c
+}
+
+// applyAccept выставляет первый Accept из Negotiate, если заголовок еще не задан.
+func (c *Client) applyAccept(h http.Header) {
+	if len(c.negotiate.Accept) != 0 && h.Get("Accept") == "" {
+		h.Set("Accept", c.negotiate.Accept[0])
+	}
+}
+
+// renegotiable сообщает, что ответ resp можно попробовать получить в другом формате.
+func (c *Client) renegotiable(resp *http.Response) bool {
+	switch resp.StatusCode {
+	case http.StatusNotAcceptable:
+		return len(c.negotiate.Accept) != 0
+	case http.StatusUnsupportedMediaType:
+		return len(c.negotiate.ContentTypes) != 0
+	default:
+		return false
+	}
+}
+
+// renegotiate повторяет запрос с другими Accept или Content-Type из Negotiate, пока сервер отвечает 406 или 415.
+func (c *Client) renegotiate(client httpClient, req *http.Request, r *requestState, resp *http.Response) (*http.Response, error) {
+	if raw, ok := r.body.(rawBody); ok && raw.reader != nil {
+		return resp, nil
+	}
+
+	state := r.clone()
+	accepted := req.Header.Get("Accept")
+	accepts := slices.DeleteFunc(slices.Clone(c.negotiate.Accept), func(v string) bool { return v == accepted })
+	types := otherTypes(c.negotiate.ContentTypes, req.Header.Get("Content-Type"))
+
+	for {
+		switch {
+		case resp.StatusCode == http.StatusNotAcceptable && len(accepts) != 0:
+			state.writableHeaders().Set("Accept", accepts[0])
+			accepts = accepts[1:]
+		case resp.StatusCode == http.StatusUnsupportedMediaType && len(types) != 0:
+			body, ok := reencode(state.body, types[0])
+			if !ok {
+				return resp, nil
+			}
+
+			// Явно заданный Content-Type относится к прежнему формату тела
+			state.body = body
+			state.writableHeaders().Del("Content-Type")
+			types = types[1:]
+		default:
+			return resp, nil
+		}
+
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		retry, err := c.newRequest(req.Context(), req.Method, req.URL.String(), &state)
+		if err != nil {
+			return nil, err
+		}
+
+		if resp, err = c.execute(client, retry); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// reencode возвращает тело body, которое будет сериализовано в mediaType, или false,
+// если тело передается как есть.
+func reencode(body any, mediaType string) (any, bool) {
+	var v any
+
+	switch b := body.(type) {
+	case nil, rawBody:
+		return nil, false
+	case jsonStreamBody:
+		v = b.v
+	case codecBody:
+		v = b.v
+	case xmlBody:
+		v = b.v
+	default:
+		v = body
+	}
+
+	switch mediaType {
+	case "application/json":
+		return v, true
+	case "application/xml":
+		return xmlBody{v: v}, true
+	default:
+		return codecBody{v: v, mediaType: mediaType}, true
+	}
+}
+
+// otherTypes возвращает типы из types, кроме типа уже отправленного тела sent без учета параметров (charset).
+func otherTypes(types []string, sent string) []string {
+	sentType, _, _ := mime.ParseMediaType(sent)
+
+	return slices.DeleteFunc(slices.Clone(types), func(t string) bool {
+		mediaType, _, _ := mime.ParseMediaType(t)
+
+		return sentType != "" && strings.EqualFold(mediaType, sentType)
+	})
+}
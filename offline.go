@@ -0,0 +1,365 @@
+package fluent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ErrQueued возвращается, если изменяющий запрос не удалось отправить из-за отсутствия связи
+// и он сохранен в OfflineQueue для последующей отправки через Replay.
+var ErrQueued = errors.New("request queued for replay")
+
+// DefaultQueuedHeaders — заголовки запроса, которые OfflineQueue сохраняет на диск. Остальные, в том числе
+// учетные данные и подписи с любыми именами, не записываются: при Replay заголовки клиента, его авторизация,
+// Sign и middleware применяются заново.
+var DefaultQueuedHeaders = []string{
+	"Accept", "Accept-Language", "Content-Type", "Content-Encoding", "Content-Language",
+	"Idempotency-Key", "If-Match", "If-None-Match", "If-Modified-Since", "If-Unmodified-Since", "Prefer",
+}
+
+// QueuedRequest — изменяющий запрос, сохраненный в OfflineQueue.
+type QueuedRequest struct {
+	// ID — имя файла запроса в каталоге очереди.
+	ID       string      `json:"-"`
+	Method   string      `json:"method"`
+	URL      string      `json:"url"`
+	Header   http.Header `json:"header,omitempty"`
+	Body     []byte      `json:"body,omitempty"`
+	QueuedAt time.Time   `json:"queued_at"`
+}
+
+// OfflineQueue — очередь изменяющих запросов (POST, PUT, PATCH, DELETE и др.), которые не удалось
+// отправить из-за отсутствия связи. Запросы хранятся в каталоге Dir по одному файлу на запрос
+// и переживают перезапуск приложения, что подходит для CLI и настольных программ, работающих
+// с периодическим подключением к сети. Очередь подключается к клиенту через Client.Offline,
+// а накопленные запросы отправляются через Replay, например при запуске или восстановлении сети.
+type OfflineQueue struct {
+	// Dir — каталог, в котором хранятся запросы. Создается при первой записи.
+	Dir string
+	// OnConflict вызывается в Replay, если сервер ответил на сохраненный запрос не 2xx,
+	// например 409 или 412, потому что данные успели измениться. Если функция вернула nil,
+	// конфликт считается разрешенным и запрос удаляется из очереди; иначе запрос остается в очереди,
+	// а Replay останавливается и возвращает ошибку. Если OnConflict не задан, запрос остается в очереди.
+	OnConflict func(ctx context.Context, req QueuedRequest, err *HTTPError) error
+	// Headers — заголовки запроса, которые сохраняются на диск в дополнение к DefaultQueuedHeaders,
+	// например "X-Tenant". Не добавляйте сюда заголовки с учетными данными.
+	Headers []string
+
+	// replay не дает параллельным Replay отправить один запрос дважды, а mu защищает каталог очереди
+	// и не удерживается во время отправки, чтобы не блокировать сохранение новых запросов.
+	replay sync.Mutex
+	mu     sync.Mutex
+	seq    uint64
+}
+
+// Offline включает офлайн-режим: если изменяющий запрос точно не дошел до сервера (адрес не разрешился,
+// соединение не установлено или отклонено), он сохраняется в q, а запрос возвращает ошибку, для которой
+// errors.Is(err, ErrQueued) истинно. После таймаута или разрыва соединения сервер мог уже выполнить запрос,
+// поэтому такие запросы сохраняются, только если у них есть заголовок Idempotency-Key, по которому сервер
+// распознает повтор. Запросы GET, HEAD, OPTIONS и TRACE, запросы с потоковым телом BodyReader и запросы,
+// отмененные через контекст, не сохраняются.
+//
+// Тело сохраняется уже сериализованным и защищенным Seal. Из заголовков на диск записываются только
+// DefaultQueuedHeaders и OfflineQueue.Headers, поэтому учетные данные и подписи не попадают на диск
+// под любыми именами. При отправке через Replay заголовки клиента (Header, BasicAuth, BearerToken,
+// BearerTokenFunc) берутся из него, а Sign и middleware применяются заново. Остальные заголовки,
+// заданные только для отдельного запроса, при Replay не отправляются.
+func (c *Client) Offline(q *OfflineQueue) *Client {
+	c.offline = q
+
+	return c
+}
+
+// Replay отправляет накопленные запросы через клиент c в порядке их сохранения и удаляет отправленные
+// из очереди. Ответы не 2xx передаются в OnConflict. Если связи по-прежнему нет, Replay останавливается
+// и возвращает сетевую ошибку; оставшиеся запросы будут отправлены при следующем вызове.
+// Возвращает число запросов, удаленных из очереди.
+func (q *OfflineQueue) Replay(ctx context.Context, c *Client) (int, error) {
+	q.replay.Lock()
+	defer q.replay.Unlock()
+
+	q.mu.Lock()
+	pending, err := q.list()
+	q.mu.Unlock()
+
+	if err != nil {
+		return 0, err
+	}
+
+	var sent int
+
+	for _, id := range pending {
+		q.mu.Lock()
+		qr, err := q.load(id)
+		q.mu.Unlock()
+
+		if err != nil {
+			return sent, err
+		}
+
+		if err := q.send(ctx, c, qr); err != nil {
+			return sent, err
+		}
+
+		q.mu.Lock()
+		err = os.Remove(filepath.Join(q.Dir, id))
+		q.mu.Unlock()
+
+		if err != nil {
+			return sent, err
+		}
+
+		sent++
+	}
+
+	return sent, nil
+}
+
+// Pending возвращает сохраненные запросы в порядке их отправки.
+func (q *OfflineQueue) Pending() ([]QueuedRequest, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	ids, err := q.list()
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]QueuedRequest, 0, len(ids))
+
+	for _, id := range ids {
+		qr, err := q.load(id)
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, qr)
+	}
+
+	return res, nil
+}
+
+// send отправляет сохраненный запрос. Ответ не 2xx передается в OnConflict.
+func (q *OfflineQueue) send(ctx context.Context, c *Client, qr QueuedRequest) error {
+	req, err := http.NewRequestWithContext(ctx, qr.Method, qr.URL, bytes.NewReader(qr.Body))
+	if err != nil {
+		return err
+	}
+
+	req.Header = qr.Header.Clone()
+	if req.Header == nil {
+		req.Header = make(http.Header)
+	}
+
+	for name, v := range c.headers {
+		if _, ok := req.Header[name]; !ok {
+			req.Header[name] = slices.Clone(v)
+		}
+	}
+
+	c.authorize(req.Header)
+
+	resp, err := c.execute(c.client, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices {
+		_, _ = io.Copy(io.Discard, resp.Body)
+
+		return nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	e := c.withAPIError(&HTTPError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Method:     qr.Method,
+		URL:        qr.URL,
+		Header:     resp.Header,
+		Body:       body,
+		RequestID:  req.Header.Get(c.requestIDHeader()),
+	})
+
+	if q.OnConflict == nil {
+		return e
+	}
+
+	return q.OnConflict(ctx, qr, e)
+}
+
+// enqueue сохраняет запрос req, который не удалось отправить из-за ошибки cause.
+// Если запрос сохранить нельзя, возвращается cause.
+func (q *OfflineQueue) enqueue(req *http.Request, cause error) error {
+	if !q.queueable(req, cause) {
+		return cause
+	}
+
+	qr := QueuedRequest{
+		Method:   req.Method,
+		URL:      req.URL.String(),
+		Header:   make(http.Header),
+		QueuedAt: time.Now().UTC(),
+	}
+
+	for _, name := range slices.Concat(DefaultQueuedHeaders, q.Headers) {
+		if v := req.Header.Values(name); len(v) != 0 {
+			qr.Header[http.CanonicalHeaderKey(name)] = slices.Clone(v)
+		}
+	}
+
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return errors.Join(cause, err)
+		}
+
+		qr.Body, err = io.ReadAll(body)
+		body.Close()
+
+		if err != nil {
+			return errors.Join(cause, err)
+		}
+	}
+
+	if err := q.store(qr); err != nil {
+		return errors.Join(cause, err)
+	}
+
+	return fmt.Errorf("%w: %w", ErrQueued, cause)
+}
+
+// queueable сообщает, что запрос изменяющий, его тело можно прочитать повторно, а повторная отправка
+// не выполнит его дважды: запрос не дошел до сервера или защищен Idempotency-Key.
+func (q *OfflineQueue) queueable(req *http.Request, cause error) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return false
+	}
+
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+
+	if req.Context().Err() != nil {
+		return false
+	}
+
+	if isOffline(cause) || errors.Is(cause, syscall.ECONNREFUSED) {
+		return true
+	}
+
+	return isTransient(cause) && req.Header.Get("Idempotency-Key") != ""
+}
+
+// store записывает запрос в новый файл очереди. Файл сначала пишется во временный, а затем
+// переименовывается, чтобы сбой посреди записи не оставлял в очереди поврежденных запросов.
+func (q *OfflineQueue) store(qr QueuedRequest) error {
+	data, err := json.Marshal(qr)
+	if err != nil {
+		return err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if err := os.MkdirAll(q.Dir, 0o700); err != nil { //nolint:mnd
+		return err
+	}
+
+	tmp, err := os.CreateTemp(q.Dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+
+		return err
+	}
+
+	q.seq++
+	name := fmt.Sprintf("%020d-%06d.json", qr.QueuedAt.UnixNano(), q.seq)
+
+	return os.Rename(tmp.Name(), filepath.Join(q.Dir, name))
+}
+
+// list возвращает имена файлов очереди в порядке сохранения.
+func (q *OfflineQueue) list() ([]string, error) {
+	entries, err := os.ReadDir(q.Dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(entries))
+
+	for _, e := range entries {
+		name := e.Name()
+		if e.Type().IsRegular() && !strings.HasPrefix(name, ".") && strings.HasSuffix(name, ".json") {
+			ids = append(ids, name)
+		}
+	}
+
+	slices.Sort(ids)
+
+	return ids, nil
+}
+
+// load читает сохраненный запрос по имени файла.
+func (q *OfflineQueue) load(id string) (QueuedRequest, error) {
+	var qr QueuedRequest
+
+	data, err := os.ReadFile(filepath.Join(q.Dir, id))
+	if err != nil {
+		return qr, err
+	}
+
+	if err := json.Unmarshal(data, &qr); err != nil {
+		return qr, fmt.Errorf("offline queue %s: %w", id, err)
+	}
+
+	qr.ID = id
+
+	return qr, nil
+}
+
+// isOffline сообщает, что запрос не дошел до сервера: адрес не разрешился или соединение не установлено.
+func isOffline(err error) bool {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return true
+	}
+
+	var opErr *net.OpError
+
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
@@ -0,0 +1,239 @@
+package fluent_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/devem-tech/fluent"
+)
+
+func TestClient_Offline(t *testing.T) {
+	t.Parallel()
+
+	var (
+		online   atomic.Bool
+		received atomic.Value
+	)
+
+	rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if !online.Load() {
+			return nil, &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+		}
+
+		body, _ := io.ReadAll(req.Body)
+		received.Store(req.Method + " " + req.URL.Path + " " + string(body) + " " + req.Header.Get("Authorization"))
+
+		return &http.Response{StatusCode: http.StatusCreated, Body: http.NoBody, Header: make(http.Header)}, nil
+	})
+
+	q := &fluent.OfflineQueue{Dir: t.TempDir()}
+	c := fluent.New().BaseURL("http://api.example").HTTPClient(&http.Client{Transport: rt}).Offline(q).BearerToken("t1")
+
+	err := c.Request().Body(map[string]int{"n": 1}).Post(context.Background(), "/notes").Error()
+	if !errors.Is(err, fluent.ErrQueued) || !errors.Is(err, syscall.ECONNREFUSED) {
+		t.Fatalf("expected ErrQueued wrapping ECONNREFUSED, got %v", err)
+	}
+
+	if err := c.Request().Get(context.Background(), "/notes").Error(); errors.Is(err, fluent.ErrQueued) {
+		t.Fatal("GET must not be queued")
+	}
+
+	pending, err := q.Pending()
+	if err != nil || len(pending) != 1 || pending[0].Method != http.MethodPost {
+		t.Fatalf("unexpected pending %v: %v", pending, err)
+	}
+
+	if auth := pending[0].Header.Get("Authorization"); auth != "" {
+		t.Fatalf("credentials must not be persisted, got %q", auth)
+	}
+
+	c.BearerToken("t2")
+
+	if n, err := q.Replay(context.Background(), c); n != 0 || err == nil {
+		t.Fatalf("replay while offline: n=%d err=%v", n, err)
+	}
+
+	online.Store(true)
+
+	n, err := q.Replay(context.Background(), c)
+	if err != nil || n != 1 {
+		t.Fatalf("replay: n=%d err=%v", n, err)
+	}
+
+	if got := received.Load(); got != `POST /notes {"n":1} Bearer t2` {
+		t.Fatalf("unexpected replayed request %q", got)
+	}
+
+	if pending, _ := q.Pending(); len(pending) != 0 {
+		t.Fatalf("queue must be empty, got %d", len(pending))
+	}
+}
+
+func TestOfflineQueue_OnConflict(t *testing.T) {
+	t.Parallel()
+
+	var online atomic.Bool
+
+	rt := roundTripFunc(func(*http.Request) (*http.Response, error) {
+		if !online.Load() {
+			return nil, &net.DNSError{Err: "no such host", Name: "api.example"}
+		}
+
+		return &http.Response{
+			StatusCode: http.StatusConflict,
+			Status:     "409 Conflict",
+			Body:       io.NopCloser(strings.NewReader(`{"version":2}`)),
+			Header:     make(http.Header),
+		}, nil
+	})
+
+	var conflicts int
+
+	q := &fluent.OfflineQueue{
+		Dir: t.TempDir(),
+		OnConflict: func(_ context.Context, req fluent.QueuedRequest, err *fluent.HTTPError) error {
+			conflicts++
+
+			if req.Method != http.MethodPut || err.StatusCode != http.StatusConflict {
+				t.Errorf("unexpected conflict %s: %v", req.Method, err)
+			}
+
+			return nil
+		},
+	}
+	c := fluent.New().BaseURL("http://api.example").HTTPClient(&http.Client{Transport: rt}).Offline(q)
+
+	if err := c.Request().Body("v1").Do(context.Background(), http.MethodPut, "/doc").Error(); !errors.Is(err, fluent.ErrQueued) {
+		t.Fatalf("expected ErrQueued, got %v", err)
+	}
+
+	online.Store(true)
+
+	n, err := q.Replay(context.Background(), c)
+	if err != nil || n != 1 || conflicts != 1 {
+		t.Fatalf("replay: n=%d err=%v conflicts=%d", n, err, conflicts)
+	}
+}
+
+func TestClient_Offline_MaybeDelivered(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+
+		_, _ = io.ReadAll(r.Body)
+
+		conn, _, err := http.NewResponseController(w).Hijack()
+		if err == nil {
+			_ = conn.Close()
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	q := &fluent.OfflineQueue{Dir: t.TempDir()}
+	c := fluent.New().BaseURL(srv.URL).Offline(q)
+
+	err := c.Request().Body(map[string]int{"n": 1}).Post(context.Background(), "/notes").Error()
+	if err == nil || errors.Is(err, fluent.ErrQueued) {
+		t.Fatalf("a request that may have been applied must not be queued, got %v", err)
+	}
+
+	if pending, _ := q.Pending(); len(pending) != 0 || calls.Load() != 1 {
+		t.Fatalf("expected empty queue after 1 call, got %d pending after %d calls", len(pending), calls.Load())
+	}
+
+	err = c.Request().Header("Idempotency-Key", "k1").Body(map[string]int{"n": 1}).Post(context.Background(), "/notes").Error()
+	if !errors.Is(err, fluent.ErrQueued) {
+		t.Fatalf("expected request with Idempotency-Key to be queued, got %v", err)
+	}
+}
+
+func TestOfflineQueue_Headers(t *testing.T) {
+	t.Parallel()
+
+	var (
+		online   atomic.Bool
+		received atomic.Value
+	)
+
+	release := make(chan struct{})
+
+	rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if !online.Load() || req.URL.Path == "/offline" {
+			return nil, &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+		}
+
+		received.Store(req.Header.Clone())
+		<-release
+
+		return &http.Response{StatusCode: http.StatusNoContent, Body: http.NoBody, Header: make(http.Header)}, nil
+	})
+
+	q := &fluent.OfflineQueue{Dir: t.TempDir(), Headers: []string{"X-Tenant"}}
+	c := fluent.New().
+		BaseURL("http://api.example").
+		HTTPClient(&http.Client{Transport: rt}).
+		Offline(q).
+		Header("X-Api-Key", "k1")
+
+	err := c.Request().
+		Header("X-Tenant", "acme").
+		Header("X-Custom-Signature", "sig").
+		Header("Idempotency-Key", "i1").
+		BodyString("v").
+		Post(context.Background(), "/notes").
+		Error()
+	if !errors.Is(err, fluent.ErrQueued) {
+		t.Fatalf("expected ErrQueued, got %v", err)
+	}
+
+	pending, err := q.Pending()
+	if err != nil || len(pending) != 1 {
+		t.Fatalf("unexpected pending %v: %v", pending, err)
+	}
+
+	if h := pending[0].Header; h.Get("X-Tenant") != "acme" || h.Get("Idempotency-Key") != "i1" ||
+		h.Get("X-Custom-Signature") != "" || h.Get("X-Api-Key") != "" {
+		t.Fatalf("expected only allow-listed headers on disk, got %v", h)
+	}
+
+	online.Store(true)
+	c.SetHeader("X-Api-Key", "k2")
+
+	done := make(chan error, 1)
+
+	go func() {
+		_, err := q.Replay(context.Background(), c)
+		done <- err
+	}()
+
+	// Пока Replay ждет ответа, новые запросы сохраняются без блокировки
+	for received.Load() == nil {
+		time.Sleep(time.Millisecond)
+	}
+
+	if err := c.Request().BodyString("w").Post(context.Background(), "/offline").Error(); !errors.Is(err, fluent.ErrQueued) {
+		t.Fatalf("expected ErrQueued during replay, got %v", err)
+	}
+
+	close(release)
+
+	if err := <-done; err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+
+	if h := received.Load().(http.Header); h.Get("X-Tenant") != "acme" || h.Get("X-Api-Key") != "k2" {
+		t.Fatalf("expected stored and current client headers on replay, got %v", h)
+	}
+}
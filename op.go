@@ -0,0 +1,87 @@
+package fluent
+
+import (
+	"context"
+	"sync"
+)
+
+// Op помечает следующие запросы тегом операции, например "reports.export", чтобы их можно было
+// отменить все сразу через CancelOp. Действует до вызова Reset.
+func (c *Client) Op(op string) *Client {
+	c.op = op
+
+	return c
+}
+
+// Op помечает запрос тегом операции, так же как Client.Op.
+func (r *Request) Op(op string) *Request {
+	r.op = op
+
+	return r
+}
+
+// CancelOp отменяет все выполняющиеся запросы с тегом op, включая чтение тел их ответов,
+// и возвращает число отмененных запросов. Полезно, когда пользователь ушел со страницы
+// и дорогую работу на стороне сервисов можно прекратить. Клоны клиента (Clone) разделяют теги
+// с исходным клиентом.
+func (c *Client) CancelOp(op string) int {
+	return c.ops.cancel(op)
+}
+
+// opRegistry хранит функции отмены выполняющихся запросов по тегам операций.
+type opRegistry struct {
+	mu     sync.Mutex
+	nextID uint64
+	ops    map[string]map[uint64]context.CancelFunc
+}
+
+func newOpRegistry() *opRegistry {
+	return &opRegistry{ops: make(map[string]map[uint64]context.CancelFunc)}
+}
+
+// track возвращает отменяемый контекст запроса с тегом op. Возвращенная функция снимает запрос с учета,
+// отменяет его контекст и вызывает parent, если он не nil.
+func (o *opRegistry) track(ctx context.Context, op string, parent context.CancelFunc) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	o.mu.Lock()
+	o.nextID++
+	id := o.nextID
+
+	if o.ops[op] == nil {
+		o.ops[op] = make(map[uint64]context.CancelFunc)
+	}
+
+	o.ops[op][id] = cancel
+	o.mu.Unlock()
+
+	return ctx, func() {
+		o.mu.Lock()
+		delete(o.ops[op], id)
+
+		if len(o.ops[op]) == 0 {
+			delete(o.ops, op)
+		}
+		o.mu.Unlock()
+
+		cancel()
+
+		if parent != nil {
+			parent()
+		}
+	}
+}
+
+// cancel отменяет все запросы с тегом op.
+func (o *opRegistry) cancel(op string) int {
+	o.mu.Lock()
+	cancels := o.ops[op]
+	delete(o.ops, op)
+	o.mu.Unlock()
+
+	for _, cancel := range cancels {
+		cancel()
+	}
+
+	return len(cancels)
+}
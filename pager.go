@@ -0,0 +1,231 @@
+package fluent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"net/http"
+	"strconv"
+)
+
+// PageInfo — сведения о полученной странице, по которым NextCursor определяет следующую.
+type PageInfo struct {
+	// Cursor — курсор, с которым запрошена страница, или пустая строка для первой страницы.
+	Cursor string
+	Header http.Header
+	Body   []byte
+	// Count — число элементов на странице.
+	Count int
+}
+
+// NextCursor возвращает курсор следующей страницы или пустую строку, если страниц больше нет.
+type NextCursor func(page PageInfo) (string, error)
+
+// CursorFromJSON берет курсор следующей страницы из поля JSON-тела по пути через точку,
+// например "meta.next_cursor". Отсутствующее, пустое или null поле завершает обход.
+func CursorFromJSON(path string) NextCursor {
+	return func(page PageInfo) (string, error) {
+		return jsonString(page.Body, path)
+	}
+}
+
+// jsonString возвращает строку или число по пути через точку в JSON-теле body
+// или пустую строку, если поля нет или оно null.
+func jsonString(body []byte, path string) (string, error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return "", err
+	}
+
+	switch v := lookupPath(doc, path).(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	default:
+		return "", fmt.Errorf("%w: %s: %T", ErrUnsupportedTarget, path, v)
+	}
+}
+
+// CursorFromHeader берет курсор следующей страницы из заголовка ответа, например "X-Next-Cursor".
+func CursorFromHeader(name string) NextCursor {
+	return func(page PageInfo) (string, error) {
+		return page.Header.Get(name), nil
+	}
+}
+
+// OffsetCursor передает смещение: следующая страница начинается после уже полученных элементов.
+// Пустая страница завершает обход.
+func OffsetCursor() NextCursor {
+	return func(page PageInfo) (string, error) {
+		if page.Count == 0 {
+			return "", nil
+		}
+
+		offset, _ := strconv.Atoi(page.Cursor)
+
+		return strconv.Itoa(offset + page.Count), nil
+	}
+}
+
+// PageNumberCursor передает номер страницы, начиная с first для первой. Пустая страница завершает обход.
+func PageNumberCursor(first int) NextCursor {
+	return func(page PageInfo) (string, error) {
+		if page.Count == 0 {
+			return "", nil
+		}
+
+		n, err := strconv.Atoi(page.Cursor)
+		if err != nil {
+			n = first
+		}
+
+		return strconv.Itoa(n + 1), nil
+	}
+}
+
+// Pager обходит API с курсорной, offset- или постраничной пагинацией: после каждой страницы Next
+// вычисляет курсор следующей, и запрос повторяется с query-параметром Param, пока курсор не станет пустым.
+// Стратегия задается функцией Next, поэтому курсор можно взять из тела, заголовка или вычислить:
+//
+//	p := fluent.Pager[Item]{Param: "cursor", Next: fluent.CursorFromJSON("meta.next"), ItemsPath: "data"}
+//	items, err := p.All(ctx, c.Request().Query("limit", "100"), "/items")
+//
+// Число страниц ограничено MaxPages клиента.
+type Pager[T any] struct {
+	// Param — query-параметр курсора, например "cursor", "offset" или "page".
+	Param string
+	// Next — стратегия получения курсора следующей страницы.
+	Next NextCursor
+	// ItemsPath — путь через точку к массиву элементов в JSON-теле, например "data" или "result.items".
+	// Если пуст, тело страницы целиком декодируется как JSON-массив.
+	ItemsPath string
+}
+
+// Each запрашивает страницы по пути path с параметрами r и вызывает fn для каждого элемента по мере
+// получения страниц, не накапливая их. С Prefetch следующие страницы запрашиваются, пока fn обрабатывает
+// элементы текущей. Ошибка fn или запроса останавливает обход.
+func (p *Pager[T]) Each(ctx context.Context, r *Request, path string, fn func(item T) error) error {
+	limit, pages := r.c.pageLimit(), 0
+
+	cursor := r.params.Get(p.Param)
+
+	fetch := func(ctx context.Context) (pagerPage[T], bool, error) {
+		if limit > 0 && pages >= limit {
+			return pagerPage[T]{}, false, fmt.Errorf("%w: limit %d reached", ErrTooManyPages, limit)
+		}
+
+		req := &Request{requestState: r.fork(), c: r.c}
+		if cursor != "" {
+			req.SetQuery(p.Param, cursor)
+		}
+
+		pages++
+
+		resp := req.Get(ctx, path)
+
+		body, err := resp.Raw()
+		if err != nil {
+			return pagerPage[T]{}, false, err
+		}
+
+		items, err := p.items(body)
+		if err != nil {
+			return pagerPage[T]{}, false, err
+		}
+
+		next, err := p.Next(PageInfo{Cursor: cursor, Header: resp.resp.Header, Body: body, Count: len(items)})
+		cursor = next
+
+		return pagerPage[T]{items: items, err: err}, err == nil && next != "", nil
+	}
+
+	return walkPages(ctx, r.prefetch, fetch, func(page pagerPage[T]) error {
+		for _, item := range page.items {
+			if err := fn(item); err != nil {
+				return err
+			}
+		}
+
+		return page.err
+	})
+}
+
+// pagerPage — элементы полученной страницы и ошибка Next, которая возвращается после их обработки.
+type pagerPage[T any] struct {
+	items []T
+	err   error
+}
+
+// All запрашивает все страницы, так же как Each, и возвращает их элементы одним срезом.
+func (p *Pager[T]) All(ctx context.Context, r *Request, path string) ([]T, error) {
+	var res []T
+
+	err := p.Each(ctx, r, path, func(item T) error {
+		res = append(res, item)
+
+		return nil
+	})
+
+	return res, err
+}
+
+// items декодирует элементы страницы из тела.
+func (p *Pager[T]) items(body []byte) ([]T, error) {
+	var items []T
+
+	if p.ItemsPath == "" {
+		err := json.Unmarshal(body, &items)
+
+		return items, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	found := lookupPath(doc, p.ItemsPath)
+	if found == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(found)
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(data, &items)
+
+	return items, err
+}
+
+// Items возвращает элементы всех страниц как итератор, так же как fluent.Items: страницы
+// запрашиваются по мере перебора, а break останавливает обход.
+func (p *Pager[T]) Items(ctx context.Context, r *Request, path string) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		err := p.Each(ctx, r, path, func(item T) error {
+			if !yield(item, nil) {
+				return errStopIteration
+			}
+
+			return nil
+		})
+		if err != nil && !errors.Is(err, errStopIteration) {
+			var zero T
+
+			yield(zero, err)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package fluent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// DefaultMaxPages — ограничение числа страниц Paginate по умолчанию, защищающее от бесконечных
+// циклов ссылок rel="next".
+const DefaultMaxPages = 1000
+
+// ErrTooManyPages возвращается, если Paginate или Pager достиг ограничения MaxPages, а следующая страница все еще есть.
+var ErrTooManyPages = errors.New("too many pages")
+
+// MaxPages ограничивает число страниц, которые обходят Paginate и Pager. По умолчанию — DefaultMaxPages.
+// Значение n <= 0 снимает ограничение.
+func (c *Client) MaxPages(n int) *Client {
+	c.maxPages = n
+	if n <= 0 {
+		c.maxPages = -1
+	}
+
+	return c
+}
+
+// pageLimit возвращает ограничение числа страниц или -1, если оно снято.
+func (c *Client) pageLimit() int {
+	if c.maxPages == 0 {
+		return DefaultMaxPages
+	}
+
+	return c.maxPages
+}
+
+// Paginate обходит страницы, начиная с path, так же как Request.Paginate. Клиент при этом не изменяется.
+func (c *Client) Paginate(ctx context.Context, path string, fn func(page *Response) error) error {
+	return c.Request().Paginate(ctx, path, fn)
+}
+
+// Paginate запрашивает GET path и переходит по ссылкам rel="next" из заголовков Link (RFC 5988),
+// вызывая fn для каждой страницы, пока ссылки не закончатся. Query-параметры запроса применяются
+// только к первой странице: ссылка на следующую уже содержит их. Параметры клиента (Client.Query)
+// добавляются и к следующим страницам, если ссылка их не содержит. Тело страницы можно прочитать
+// в fn, например через Into; после fn оно закрывается. Ошибка fn или запроса останавливает обход.
+// С Prefetch следующие страницы запрашиваются, пока fn обрабатывает текущую.
+// Если страниц больше MaxPages, возвращается ErrTooManyPages:
+//
+//	err := c.Request().Query("per_page", "100").Paginate(ctx, "/items", func(page *fluent.Response) error {
+//		items, err := fluent.Into[[]Item](page)
+//		...
+//	})
+func (r *Request) Paginate(ctx context.Context, path string, fn func(page *Response) error) error {
+	if path == "" {
+		return nil
+	}
+
+	limit, pages := r.c.pageLimit(), 0
+
+	fetch := func(ctx context.Context) (*Response, bool, error) {
+		if limit > 0 && pages >= limit {
+			return nil, false, fmt.Errorf("%w: limit %d reached", ErrTooManyPages, limit)
+		}
+
+		req := &Request{requestState: r.fork(), c: r.c}
+		if pages > 0 {
+			req.params, req.keys = r.c.linkParams(path)
+			req.rawQuery, req.sharedParams = "", false
+		}
+
+		pages++
+
+		page := req.Get(ctx, path)
+		if err := page.Error(); err != nil {
+			return nil, false, err
+		}
+
+		if r.prefetch > 0 {
+			if err := page.buffer(); err != nil {
+				return nil, false, err
+			}
+		}
+
+		path = page.nextLink()
+
+		return page, path != "", nil
+	}
+
+	return walkPages(ctx, r.prefetch, fetch, func(page *Response) error {
+		defer page.resp.Body.Close()
+
+		return fn(page)
+	})
+}
+
+// linkParams возвращает query-параметры клиента, которых нет в ссылке next. Ссылка обычно повторяет параметры
+// запроса, но не всегда, например ключ API в query (api_key), без которого следующая страница вернет 401.
+func (c *Client) linkParams(next string) (url.Values, []string) {
+	params := make(url.Values)
+
+	u, err := url.Parse(next)
+	if err != nil {
+		return params, nil
+	}
+
+	linked := u.Query()
+
+	var keys []string
+
+	for _, key := range c.keys {
+		if v, ok := c.params[key]; ok && !linked.Has(key) {
+			params[key] = slices.Clone(v)
+			keys = append(keys, key)
+		}
+	}
+
+	return params, keys
+}
+
+// Link возвращает URL ссылки с отношением rel из заголовков Link ответа (RFC 5988), например "next"
+// или "last", или пустую строку, если такой ссылки нет. Относительные ссылки возвращаются как есть.
+func (r *Response) Link(rel string) string {
+	if r.err != nil {
+		return ""
+	}
+
+	return findLink(r.resp.Header, rel)
+}
+
+// nextLink возвращает абсолютный URL следующей страницы или пустую строку.
+func (r *Response) nextLink() string {
+	return r.resolve(findLink(r.resp.Header, "next"))
+}
+
+// resolve возвращает абсолютный URL ссылки ref относительно URL запроса.
+func (r *Response) resolve(ref string) string {
+	if ref == "" || r.resp.Request == nil {
+		return ref
+	}
+
+	u, err := r.resp.Request.URL.Parse(ref)
+	if err != nil {
+		return ref
+	}
+
+	return u.String()
+}
+
+// findLink ищет в заголовках Link вида `<https://api/items?page=2>; rel="next"` ссылку с отношением rel.
+func findLink(h http.Header, rel string) string {
+	for _, header := range h.Values("Link") {
+		for link := range strings.SplitSeq(header, ",") {
+			target, params, ok := strings.Cut(strings.TrimSpace(link), ";")
+			if !ok || !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+
+			for param := range strings.SplitSeq(params, ";") {
+				key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+				if !strings.EqualFold(key, "rel") {
+					continue
+				}
+
+				rels := strings.Fields(strings.ToLower(strings.Trim(value, `"`)))
+				if slices.Contains(rels, strings.ToLower(rel)) {
+					return strings.Trim(target, "<>")
+				}
+			}
+		}
+	}
+
+	return ""
+}
+
+// errStopIteration останавливает обход страниц, когда цикл range завершился досрочно.
+var errStopIteration = errors.New("stop iteration")
+
+// Items возвращает элементы всех страниц, начиная с path, как итератор: страницы запрашиваются
+// через Paginate по мере перебора, а каждая декодируется как JSON-массив []T. Если цикл прерван
+// через break, следующие страницы не запрашиваются. Ошибка запроса или декодирования передается
+// последней парой с нулевым элементом:
+//
+//	for post, err := range fluent.Items[Post](ctx, c, "/posts") {
+//		if err != nil { ... }
+//	}
+func Items[T any](ctx context.Context, c *Client, path string) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		err := c.Paginate(ctx, path, func(page *Response) error {
+			items, err := Into[[]T](page)
+			if err != nil {
+				return err
+			}
+
+			for _, item := range items {
+				if !yield(item, nil) {
+					return errStopIteration
+				}
+			}
+
+			return nil
+		})
+		if err != nil && !errors.Is(err, errStopIteration) {
+			var zero T
+
+			yield(zero, err)
+		}
+	}
+}
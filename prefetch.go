@@ -0,0 +1,104 @@
+package fluent
+
+import (
+	"bytes"
+	"context"
+	"io"
+)
+
+// Prefetch включает упреждающую загрузку страниц в Paginate, Items и Pager: пока вызывающий код
+// обрабатывает страницу, следующие запрашиваются в отдельной горутине, но не больше n страниц вперед.
+// Так задержка сети прячется за обработкой в постраничных конвейерах. Упрежденные страницы читаются
+// в память целиком. n <= 0 выключает упреждение, страницы запрашиваются по одной. Действует до вызова Reset.
+func (c *Client) Prefetch(n int) *Client {
+	c.prefetch = n
+
+	return c
+}
+
+// Prefetch включает упреждающую загрузку страниц для обхода этим запросом, так же как Client.Prefetch.
+func (r *Request) Prefetch(n int) *Request {
+	r.prefetch = n
+
+	return r
+}
+
+// walkPages передает consume страницы, которые возвращает fetch, по порядку, пока fetch не сообщит,
+// что следующей страницы нет. При n > 0 fetch вызывается в отдельной горутине и опережает consume
+// не больше чем на n страниц. Ошибка fetch возвращается после обработки предыдущих страниц.
+func walkPages[P any](ctx context.Context, n int, fetch func(ctx context.Context) (P, bool, error),
+	consume func(page P) error,
+) error {
+	if n <= 0 {
+		for {
+			page, more, err := fetch(ctx)
+			if err != nil {
+				return err
+			}
+
+			if err := consume(page); err != nil || !more {
+				return err
+			}
+		}
+	}
+
+	type result struct {
+		page P
+		err  error
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	// Одна страница обрабатывается, n-1 ждут в канале, и еще одна загружается
+	results := make(chan result, n-1)
+
+	defer func() {
+		cancel()
+
+		for range results { //nolint:revive
+		}
+	}()
+
+	go func() {
+		defer close(results)
+
+		for {
+			page, more, err := fetch(ctx)
+
+			select {
+			case results <- result{page: page, err: err}:
+			case <-ctx.Done():
+				return
+			}
+
+			if err != nil || !more {
+				return
+			}
+		}
+	}()
+
+	for res := range results {
+		if res.err != nil {
+			return res.err
+		}
+
+		if err := consume(res.page); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// buffer читает тело ответа в память, чтобы соединение освободилось до обработки упрежденной страницы.
+func (r *Response) buffer() error {
+	data, err := io.ReadAll(r.resp.Body)
+	r.resp.Body.Close()
+
+	if err != nil {
+		return err
+	}
+
+	r.resp.Body = io.NopCloser(bytes.NewReader(data))
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package fluent
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+)
+
+// ProblemDetails — описание ошибки в формате RFC 7807 (application/problem+json).
+// Если ответ не 2xx пришел с таким Content-Type, он декодируется в HTTPError.Problem
+// и доступен через errors.As:
+//
+//	var problem *fluent.ProblemDetails
+//	if errors.As(err, &problem) && problem.Type == "https://example.com/probs/out-of-credit" { ... }
+type ProblemDetails struct {
+	Type     string
+	Title    string
+	Status   int
+	Detail   string
+	Instance string
+	// Extensions — остальные поля объекта, например "balance" или "invalid-params".
+	Extensions map[string]any
+}
+
+func (p *ProblemDetails) Error() string {
+	switch {
+	case p.Detail == "":
+		return p.Title
+	case p.Title == "":
+		return p.Detail
+	default:
+		return p.Title + ": " + p.Detail
+	}
+}
+
+// UnmarshalJSON декодирует стандартные поля RFC 7807, а остальные сохраняет в Extensions.
+func (p *ProblemDetails) UnmarshalJSON(data []byte) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	*p = ProblemDetails{}
+
+	for key, raw := range fields {
+		var err error
+
+		switch key {
+		case "type":
+			err = json.Unmarshal(raw, &p.Type)
+		case "title":
+			err = json.Unmarshal(raw, &p.Title)
+		case "status":
+			err = json.Unmarshal(raw, &p.Status)
+		case "detail":
+			err = json.Unmarshal(raw, &p.Detail)
+		case "instance":
+			err = json.Unmarshal(raw, &p.Instance)
+		default:
+			var v any
+			if err = json.Unmarshal(raw, &v); err == nil {
+				if p.Extensions == nil {
+					p.Extensions = make(map[string]any)
+				}
+
+				p.Extensions[key] = v
+			}
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// parseProblem декодирует тело body в ProblemDetails, если Content-Type из header — application/problem+json.
+func parseProblem(header http.Header, body []byte) *ProblemDetails {
+	if len(body) == 0 {
+		return nil
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+	if mediaType != "application/problem+json" {
+		return nil
+	}
+
+	var p ProblemDetails
+	if err := json.Unmarshal(body, &p); err != nil {
+		return nil
+	}
+
+	return &p
+}
@@ -0,0 +1,240 @@
+package fluent
+
+import (
+	"io"
+	"runtime/metrics"
+	"sync"
+	"time"
+)
+
+// Phase — этап выполнения запроса в RequestProfile.
+type Phase string
+
+const (
+	// PhaseBuild — сборка запроса: URL, заголовки и сериализация тела.
+	PhaseBuild Phase = "build"
+	// PhaseSend — отправка запроса и ожидание заголовков ответа, включая повторы и middleware.
+	PhaseSend Phase = "send"
+	// PhaseRead — чтение тела ответа.
+	PhaseRead Phase = "read"
+	// PhaseDecode — декодирование тела в Into без учета его чтения.
+	PhaseDecode Phase = "decode"
+)
+
+// PhaseProfile — затраты одного этапа запроса.
+type PhaseProfile struct {
+	Phase    Phase
+	Duration time.Duration
+	// AllocBytes и AllocObjects — объем и число выделений в куче за время этапа.
+	AllocBytes   uint64
+	AllocObjects uint64
+}
+
+// RequestProfile — затраты запроса по этапам в порядке их завершения.
+type RequestProfile struct {
+	Method string
+	URL    string
+	Phases []PhaseProfile
+}
+
+// Profile включает профилирование запросов: для каждого запроса fn получает длительность и выделения
+// памяти по этапам — сборка, отправка, чтение и декодирование тела, — чтобы под нагрузкой было видно,
+// что преобладает: сериализация или сеть. fn вызывается, когда тело ответа закрыто (Raw, Into и другие
+// методы закрывают его сами), а для неуспешных запросов — сразу.
+//
+// Выделения берутся из runtime/metrics и учитываются по всему процессу, поэтому точны, только когда
+// запросы не выполняются параллельно, например в бенчмарках. Процессорное время отдельной горутины
+// среда выполнения Go не измеряет, поэтому его заменяет длительность этапа. Профилирование само
+// выделяет память и замедляет запросы, не включайте его постоянно.
+func (c *Client) Profile(fn func(p RequestProfile)) *Client {
+	c.profile = fn
+
+	return c
+}
+
+// usage — момент времени и счетчики выделений памяти процесса.
+type usage struct {
+	at      time.Time
+	bytes   uint64
+	objects uint64
+}
+
+func readUsage() usage {
+	samples := []metrics.Sample{{Name: "/gc/heap/allocs:bytes"}, {Name: "/gc/heap/allocs:objects"}}
+	metrics.Read(samples)
+
+	u := usage{at: time.Now()}
+
+	if samples[0].Value.Kind() == metrics.KindUint64 {
+		u.bytes = samples[0].Value.Uint64()
+	}
+
+	if samples[1].Value.Kind() == metrics.KindUint64 {
+		u.objects = samples[1].Value.Uint64()
+	}
+
+	return u
+}
+
+// profiler собирает RequestProfile одного запроса. Методы nil-профайлера ничего не делают,
+// чтобы без Profile запросы не платили за измерения.
+type profiler struct {
+	mu      sync.Mutex
+	profile RequestProfile
+	read    PhaseProfile
+	fn      func(p RequestProfile)
+	owned   bool
+	done    bool
+}
+
+// newProfiler возвращает профайлер запроса или nil, если Profile не задан.
+func (c *Client) newProfiler(method string) *profiler {
+	if c.profile == nil {
+		return nil
+	}
+
+	return &profiler{profile: RequestProfile{Method: method}, fn: c.profile, read: PhaseProfile{Phase: PhaseRead}}
+}
+
+// start возвращает начальную точку измерения этапа.
+func (p *profiler) start() usage {
+	if p == nil {
+		return usage{}
+	}
+
+	return readUsage()
+}
+
+// setURL запоминает URL запроса.
+func (p *profiler) setURL(url string) {
+	if p != nil {
+		p.profile.URL = url
+	}
+}
+
+// end добавляет этап phase, начавшийся в start.
+func (p *profiler) end(phase Phase, start usage) {
+	if p != nil {
+		p.add(phase, start, PhaseProfile{})
+	}
+}
+
+// wrap передает завершение профиля телу ответа: профиль будет передан в fn при его закрытии.
+func (p *profiler) wrap(body io.ReadCloser) io.ReadCloser {
+	if p == nil {
+		return body
+	}
+
+	p.owned = true
+
+	pb := &profileBody{ReadCloser: body, p: p}
+	if s, ok := body.(io.Seeker); ok {
+		return seekableProfileBody{profileBody: pb, Seeker: s}
+	}
+
+	return pb
+}
+
+// release завершает профиль запроса, тело которого не досталось вызывающему, например при ошибке.
+func (p *profiler) release() {
+	if p != nil && !p.owned {
+		p.finish()
+	}
+}
+
+// add добавляет этап phase, начавшийся в start, за вычетом затрат except.
+func (p *profiler) add(phase Phase, start usage, except PhaseProfile) {
+	end := readUsage()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.profile.Phases = append(p.profile.Phases, PhaseProfile{
+		Phase:        phase,
+		Duration:     end.at.Sub(start.at) - except.Duration,
+		AllocBytes:   end.bytes - start.bytes - except.AllocBytes,
+		AllocObjects: end.objects - start.objects - except.AllocObjects,
+	})
+}
+
+// addRead учитывает затраты одного чтения тела.
+func (p *profiler) addRead(start usage) {
+	end := readUsage()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.read.Duration += end.at.Sub(start.at)
+	p.read.AllocBytes += end.bytes - start.bytes
+	p.read.AllocObjects += end.objects - start.objects
+}
+
+// decode начинает измерение этапа декодирования. Возвращенная функция завершает его,
+// исключая затраты на чтение тела, которое идет вперемешку с декодированием.
+func (p *profiler) decode() func() {
+	start := readUsage()
+
+	p.mu.Lock()
+	before := p.read
+	p.mu.Unlock()
+
+	return func() {
+		p.mu.Lock()
+		during := PhaseProfile{
+			Duration:     p.read.Duration - before.Duration,
+			AllocBytes:   p.read.AllocBytes - before.AllocBytes,
+			AllocObjects: p.read.AllocObjects - before.AllocObjects,
+		}
+		p.mu.Unlock()
+
+		p.add(PhaseDecode, start, during)
+	}
+}
+
+// finish передает профиль в fn один раз.
+func (p *profiler) finish() {
+	p.mu.Lock()
+	if p.done {
+		p.mu.Unlock()
+
+		return
+	}
+
+	p.done = true
+	if p.read.Duration > 0 {
+		p.profile.Phases = append(p.profile.Phases, p.read)
+	}
+
+	profile := p.profile
+	p.mu.Unlock()
+
+	p.fn(profile)
+}
+
+// profileBody учитывает чтение тела ответа и завершает профиль при закрытии.
+type profileBody struct {
+	io.ReadCloser
+
+	p *profiler
+}
+
+func (b *profileBody) Read(buf []byte) (int, error) {
+	start := readUsage()
+	n, err := b.ReadCloser.Read(buf)
+	b.p.addRead(start)
+
+	return n, err
+}
+
+func (b *profileBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.p.finish()
+
+	return err
+}
+
+// seekableProfileBody — profileBody для тел, сохраненных SpoolToDisk, который сохраняет io.Seeker для Seekable.
+type seekableProfileBody struct {
+	*profileBody
+	io.Seeker
+}
@@ -0,0 +1,39 @@
+package fluent
+
+import "io"
+
+// Progress вызывает fn по мере чтения тела ответа любым способом — Raw, Into, SaveTo или вручную через Body.
+// read — число прочитанных байт, total — размер тела из Content-Length или -1, если он неизвестен.
+// Удобно для индикаторов прогресса длинных загрузок:
+//
+//	n, err := c.Get(ctx, "/exports/42.csv").
+//		Progress(func(read, total int64) { bar.Set(read, total) }).
+//		SaveTo(path)
+func (r *Response) Progress(fn func(read, total int64)) *Response {
+	if r.err != nil {
+		return r
+	}
+
+	r.resp.Body = &progressBody{ReadCloser: r.resp.Body, total: r.resp.ContentLength, fn: fn}
+
+	return r
+}
+
+// progressBody сообщает о прочитанных байтах тела ответа.
+type progressBody struct {
+	io.ReadCloser
+
+	read  int64
+	total int64
+	fn    func(read, total int64)
+}
+
+func (p *progressBody) Read(b []byte) (int, error) {
+	n, err := p.ReadCloser.Read(b)
+	if n > 0 {
+		p.read += int64(n)
+		p.fn(p.read, p.total)
+	}
+
+	return n, err
+}
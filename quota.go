@@ -0,0 +1,100 @@
+package fluent
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrQuotaExceeded возвращается, если Quota с Block = true отклонила запрос сверх лимита.
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// Quota — локальный учет расхода квоты: не больше Limit запросов за окно Window для каждого ключа.
+// Ключ определяется функцией Key, например по API-ключу или операции.
+// Quota безопасна для конкурентного использования и может разделяться несколькими клиентами.
+type Quota struct {
+	// Limit — допустимое число запросов за окно.
+	Limit int
+	// Window — длительность окна учета, например 24 * time.Hour.
+	Window time.Duration
+	// Key возвращает ключ учета для запроса. По умолчанию — хост запроса.
+	Key func(req *http.Request) string
+	// OnQuotaExceeded вызывается для каждого запроса сверх лимита с ключом и числом запросов в текущем окне.
+	OnQuotaExceeded func(key string, used int)
+	// Block запрещает отправку запросов сверх лимита: они возвращают ErrQuotaExceeded.
+	// Без Block запросы отправляются, а превышение только сообщается через OnQuotaExceeded.
+	Block bool
+
+	mu      sync.Mutex
+	windows map[string]*quotaWindow
+}
+
+type quotaWindow struct {
+	start time.Time
+	used  int
+}
+
+// Used возвращает число запросов по ключу key в текущем окне.
+func (q *Quota) Used(key string) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	w, ok := q.windows[key]
+	if !ok || time.Since(w.start) >= q.Window {
+		return 0
+	}
+
+	return w.used
+}
+
+// acquire учитывает запрос и возвращает ErrQuotaExceeded, если запрос нужно отклонить.
+func (q *Quota) acquire(req *http.Request) error {
+	key := req.URL.Host
+	if q.Key != nil {
+		key = q.Key(req)
+	}
+
+	used, exceeded := q.count(key)
+	if !exceeded {
+		return nil
+	}
+
+	if q.OnQuotaExceeded != nil {
+		q.OnQuotaExceeded(key, used)
+	}
+
+	if q.Block {
+		return fmt.Errorf("%w: %s: %d of %d requests per %s", ErrQuotaExceeded, key, used, q.Limit, q.Window)
+	}
+
+	return nil
+}
+
+// count увеличивает счетчик ключа и сообщает, превышен ли лимит.
+// Отклоненные запросы (Block) не расходуют квоту.
+func (q *Quota) count(key string) (int, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.windows == nil {
+		q.windows = make(map[string]*quotaWindow)
+	}
+
+	now := time.Now()
+
+	w, ok := q.windows[key]
+	if !ok || now.Sub(w.start) >= q.Window {
+		w = &quotaWindow{start: now}
+		q.windows[key] = w
+	}
+
+	if w.used >= q.Limit && q.Block {
+		return w.used + 1, true
+	}
+
+	w.used++
+
+	return w.used, w.used > q.Limit
+}
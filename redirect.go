@@ -0,0 +1,49 @@
+package fluent
+
+import (
+	"net/http"
+	"slices"
+)
+
+// RedirectHop — один шаг цепочки редиректов: запрошенный URL и ответ сервера на него.
+type RedirectHop struct {
+	URL        string
+	StatusCode int
+	Location   string
+}
+
+// LastRequest возвращает запрос, на который получен ответ, — после редиректов это запрос к итоговому URL.
+// Полезно для сервисов коротких ссылок и хранилищ, где ответом служит сам конечный адрес.
+// Возвращает nil, если http-клиент не заполнил http.Response.Request.
+func (r *Response) LastRequest() (*http.Request, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	return r.resp.Request, nil
+}
+
+// RedirectHistory возвращает цепочку редиректов, пройденных до итогового ответа, от первого запроса
+// к последнему. Если редиректов не было, возвращает пустой срез.
+func (r *Response) RedirectHistory() ([]RedirectHop, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	var hops []RedirectHop
+
+	for req := r.resp.Request; req != nil && req.Response != nil; req = req.Response.Request {
+		prev := req.Response
+
+		hop := RedirectHop{StatusCode: prev.StatusCode, Location: prev.Header.Get("Location")}
+		if prev.Request != nil {
+			hop.URL = prev.Request.URL.String()
+		}
+
+		hops = append(hops, hop)
+	}
+
+	slices.Reverse(hops)
+
+	return hops, nil
+}
@@ -0,0 +1,288 @@
+package fluent
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// requestState — параметры отдельного запроса: query-параметры, заголовки, тело и разовые настройки.
+// Client хранит значения по умолчанию, а Request — собственную копию.
+type requestState struct {
+	params    url.Values
+	keys      []string
+	rawQuery  string
+	headers   http.Header
+	body      any
+	once      httpClient
+	closeConn bool
+	identity  bool
+	op        string
+	resume    string
+	follow    bool
+	prefetch  int
+	err       error
+	// sharedParams и sharedHeaders сообщают, что params вместе с keys и headers разделены с другим
+	// состоянием и копируются перед первым изменением.
+	sharedParams  bool
+	sharedHeaders bool
+}
+
+// clone возвращает копию состояния, которую можно изменять независимо от исходного. Query-параметры
+// и заголовки не копируются, пока копия их не изменит (copy-on-write), поэтому запросы без собственных
+// параметров не тратят память на копии. Исходное состояние clone не изменяет, и его можно вызывать
+// конкурентно, но владелец исходного состояния сам копирует map перед изменением (см. Client.ownHeaders).
+func (s *requestState) clone() requestState {
+	cp := *s
+	cp.sharedParams, cp.sharedHeaders = true, true
+
+	return cp
+}
+
+// fork возвращает копию состояния, так же как clone, и помечает map исходного состояния общими.
+func (s *requestState) fork() requestState {
+	s.sharedParams, s.sharedHeaders = true, true
+
+	return s.clone()
+}
+
+// writableParams копирует params и keys перед изменением, если они общие с другим состоянием.
+func (s *requestState) writableParams() {
+	if !s.sharedParams {
+		return
+	}
+
+	s.params = url.Values(cloneValues(s.params))
+	s.keys = slices.Clone(s.keys)
+	s.sharedParams = false
+}
+
+// writableHeaders возвращает заголовки для изменения, копируя их, если они общие с другим состоянием.
+func (s *requestState) writableHeaders() http.Header {
+	if s.sharedHeaders {
+		s.headers = http.Header(cloneValues(s.headers))
+		s.sharedHeaders = false
+	}
+
+	return s.headers
+}
+
+// cloneValues копирует map вместе со срезами значений.
+func cloneValues(m map[string][]string) map[string][]string {
+	cp := make(map[string][]string, len(m))
+	for k, v := range m {
+		cp[k] = append([]string(nil), v...)
+	}
+
+	return cp
+}
+
+// addQuery добавляет значение query-параметра, запоминая порядок ключей для OrderedQuery.
+func (s *requestState) addQuery(key, value string) {
+	s.writableParams()
+
+	if !s.params.Has(key) {
+		s.keys = append(s.keys, key)
+	}
+
+	s.params.Add(key, value)
+}
+
+// setQuery заменяет значения query-параметра. Ключ сохраняет свое место в порядке OrderedQuery.
+func (s *requestState) setQuery(key, value string) {
+	s.writableParams()
+
+	if !s.params.Has(key) {
+		s.keys = append(s.keys, key)
+	}
+
+	s.params.Set(key, value)
+}
+
+// delQuery удаляет query-параметр.
+func (s *requestState) delQuery(key string) {
+	s.writableParams()
+
+	s.params.Del(key)
+	s.keys = slices.DeleteFunc(s.keys, func(k string) bool { return k == key })
+}
+
+// encodeQuery возвращает строку запроса из query raw исходного URL и параметров params.
+// Если задан RawQuery, он заменяет raw. Если raw передается без изменений (RawQuery или ordered),
+// параметры добавляются после него, иначе все параметры сортируются и кодируются заново.
+func (s *requestState) encodeQuery(raw string, ordered bool) string {
+	if s.rawQuery != "" {
+		raw = s.rawQuery
+	} else if !ordered {
+		q, _ := url.ParseQuery(raw)
+		for k, vals := range s.params {
+			for _, v := range vals {
+				q.Add(k, v)
+			}
+		}
+
+		return q.Encode()
+	}
+
+	params := s.params.Encode()
+	if ordered {
+		params = s.encodeOrdered()
+	}
+
+	switch {
+	case raw == "":
+		return params
+	case params == "":
+		return raw
+	default:
+		return raw + "&" + params
+	}
+}
+
+// encodeOrdered кодирует params в порядке первого добавления ключей.
+func (s *requestState) encodeOrdered() string {
+	var sb strings.Builder
+
+	for _, k := range s.keys {
+		for _, v := range s.params[k] {
+			if sb.Len() > 0 {
+				sb.WriteByte('&')
+			}
+
+			sb.WriteString(url.QueryEscape(k))
+			sb.WriteByte('=')
+			sb.WriteString(url.QueryEscape(v))
+		}
+	}
+
+	return sb.String()
+}
+
+// Request — отдельный запрос, который наследует настройки клиента, но хранит свои query-параметры,
+// заголовки и тело. Изменения Request не затрагивают клиент и другие запросы, поэтому один Client
+// можно использовать из нескольких горутин:
+//
+//	resp := c.Request().Query("id", id).Body(payload).Post(ctx, "/items")
+type Request struct {
+	requestState
+
+	c *Client
+}
+
+// Request создает новый запрос с копией query-параметров, заголовков, тела и разовых настроек клиента.
+// Сам клиент при этом не изменяется. Параметры и заголовки копируются только при первом их изменении
+// в запросе, поэтому c.Request().Get(...) не выделяет под них память.
+func (c *Client) Request() *Request {
+	return &Request{requestState: c.clone(), c: c}
+}
+
+// Query добавляет query-параметр к запросу.
+func (r *Request) Query(key, value string) *Request {
+	r.addQuery(key, value)
+
+	return r
+}
+
+// RawQuery задает строку запроса без повторного кодирования, так же как Client.RawQuery.
+func (r *Request) RawQuery(query string) *Request {
+	r.rawQuery = query
+
+	return r
+}
+
+// Header добавляет HTTP-заголовок к запросу.
+func (r *Request) Header(key, value string) *Request {
+	r.writableHeaders().Add(key, value)
+
+	return r
+}
+
+// HeaderExact добавляет HTTP-заголовок с именем ровно в указанном регистре, так же как Client.HeaderExact.
+func (r *Request) HeaderExact(key, value string) *Request {
+	h := r.writableHeaders()
+	h[key] = append(h[key], value)
+
+	return r
+}
+
+// SetQuery заменяет все значения query-параметра key одним значением value.
+func (r *Request) SetQuery(key, value string) *Request {
+	r.setQuery(key, value)
+
+	return r
+}
+
+// DelQuery удаляет query-параметр key.
+func (r *Request) DelQuery(key string) *Request {
+	r.delQuery(key)
+
+	return r
+}
+
+// SetHeader заменяет все значения HTTP-заголовка key одним значением value.
+func (r *Request) SetHeader(key, value string) *Request {
+	r.writableHeaders().Set(key, value)
+
+	return r
+}
+
+// DelHeader удаляет HTTP-заголовок key.
+func (r *Request) DelHeader(key string) *Request {
+	r.writableHeaders().Del(key)
+
+	return r
+}
+
+// HeaderStruct задает HTTP-заголовки запроса из полей структуры, так же как Client.HeaderStruct.
+func (r *Request) HeaderStruct(v any) *Request {
+	if err := encodeHeaders(r.writableHeaders(), v); err != nil {
+		r.err = err
+	}
+
+	return r
+}
+
+// WithHTTPClient задает http-клиент для этого запроса.
+func (r *Request) WithHTTPClient(client httpClient) *Request {
+	r.once = client
+
+	return r
+}
+
+// CloseConnection запрещает переиспользование соединения для этого запроса.
+func (r *Request) CloseConnection(enabled bool) *Request {
+	r.closeConn = enabled
+
+	return r
+}
+
+// DisableCompression запрашивает ответ без сжатия, так же как Client.DisableCompression.
+func (r *Request) DisableCompression() *Request {
+	r.identity = true
+
+	return r
+}
+
+// Body задает тело запроса, которое будет сериализовано в JSON.
+func (r *Request) Body(body any) *Request {
+	r.body = body
+
+	return r
+}
+
+// Get выполняет HTTP GET-запрос по указанному пути или URL.
+func (r *Request) Get(ctx context.Context, path string) *Response {
+	return r.c.do(ctx, http.MethodGet, path, &r.requestState)
+}
+
+// Post выполняет HTTP POST-запрос по указанному пути или URL.
+func (r *Request) Post(ctx context.Context, path string) *Response {
+	return r.c.do(ctx, http.MethodPost, path, &r.requestState)
+}
+
+// Do выполняет HTTP-запрос с произвольным методом.
+func (r *Request) Do(ctx context.Context, method, path string) *Response {
+	return r.c.do(ctx, method, path, &r.requestState)
+}
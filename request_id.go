@@ -0,0 +1,83 @@
+package fluent
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"net/http"
+)
+
+// DefaultRequestIDHeader — заголовок, в котором по умолчанию передается идентификатор запроса.
+const DefaultRequestIDHeader = "X-Request-ID"
+
+// requestIDKey — ключ контекста для WithRequestID.
+type requestIDKey struct{}
+
+// RequestID включает генерацию идентификатора запроса: каждый запрос без идентификатора в контексте
+// получает случайный UUID, который передается в заголовке header (пустая строка — DefaultRequestIDHeader).
+// Повторы RetryPolicy и OnUnauthorized отправляются с тем же идентификатором, а HTTPError.RequestID
+// позволяет найти запрос в логах сервера. Явно заданный заголовок не изменяется.
+func (c *Client) RequestID(header string) *Client {
+	if header == "" {
+		header = DefaultRequestIDHeader
+	}
+
+	c.requestID = header
+
+	return c
+}
+
+// WithRequestID возвращает контекст с идентификатором запроса, например из входящего запроса,
+// чтобы передать его в исходящие запросы клиента. Идентификатор из контекста отправляется и без RequestID.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext возвращает идентификатор запроса из контекста или пустую строку.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+
+	return id
+}
+
+// withRequestID добавляет в контекст новый идентификатор, если RequestID включен, а в контексте его нет.
+func (c *Client) withRequestID(ctx context.Context) context.Context {
+	if c.requestID == "" || RequestIDFromContext(ctx) != "" {
+		return ctx
+	}
+
+	return WithRequestID(ctx, newUUID())
+}
+
+// requestIDHeader возвращает заголовок идентификатора запроса.
+func (c *Client) requestIDHeader() string {
+	if c.requestID == "" {
+		return DefaultRequestIDHeader
+	}
+
+	return c.requestID
+}
+
+// applyRequestID выставляет заголовок с идентификатором из контекста запроса, если заголовок еще не задан.
+func (c *Client) applyRequestID(req *http.Request) {
+	id := RequestIDFromContext(req.Context())
+	if id == "" {
+		return
+	}
+
+	if name := c.requestIDHeader(); req.Header.Get(name) == "" {
+		req.Header.Set(name, id)
+	}
+}
+
+// newUUID возвращает случайный UUID версии 4 (RFC 9562).
+func newUUID() string {
+	var b [16]byte
+
+	_, _ = rand.Read(b[:])
+
+	b[6] = b[6]&0x0f | 0x40 //nolint:mnd
+	b[8] = b[8]&0x3f | 0x80 //nolint:mnd
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -0,0 +1,98 @@
+package fluent
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"syscall"
+)
+
+// idleCloser — http-клиент или транспорт, который умеет закрывать простаивающие соединения.
+type idleCloser interface {
+	CloseIdleConnections()
+}
+
+// resetBudgetKey — ключ контекста с resetBudget попытки запроса.
+type resetBudgetKey struct{}
+
+// resetBudget — остаток повторов RetryPolicy, из которого send расходует повтор после разрыва соединения,
+// чтобы RetryOnReset и RetryPolicy вместе не превышали MaxRetries.
+type resetBudget struct {
+	left int
+	used int
+}
+
+// take расходует один повтор, если он остался.
+func (b *resetBudget) take() bool {
+	if b.left <= 0 {
+		return false
+	}
+
+	b.left--
+	b.used++
+
+	return true
+}
+
+// send выполняет запрос и, если включен RetryOnReset, один раз повторяет идемпотентный запрос,
+// когда переиспользованное keep-alive соединение оказалось разорвано сервером (ECONNRESET или EOF).
+// Перед повтором простаивающие соединения закрываются, чтобы повтор ушел по новому соединению.
+// Если задана RetryPolicy, повтор расходует ее остаток (см. resetBudget).
+func (c *Client) send(client httpClient, req *http.Request) (*http.Response, error) {
+	if !c.resetRetry || !idempotent(req.Method) {
+		return client.Do(req)
+	}
+
+	var reused bool
+
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			reused = info.Reused
+		},
+	}
+
+	resp, err := client.Do(req.WithContext(httptrace.WithClientTrace(req.Context(), trace)))
+	if err == nil || !reused || !isConnReset(err) {
+		return resp, err
+	}
+
+	if budget, ok := req.Context().Value(resetBudgetKey{}).(*resetBudget); ok && !budget.take() {
+		return resp, err
+	}
+
+	if req.Body != nil && req.Body != http.NoBody {
+		if req.GetBody == nil {
+			return resp, err
+		}
+
+		body, bodyErr := req.GetBody()
+		if bodyErr != nil {
+			return resp, err
+		}
+
+		req = req.Clone(req.Context())
+		req.Body = body
+	}
+
+	if ic, ok := client.(idleCloser); ok {
+		ic.CloseIdleConnections()
+	}
+
+	return client.Do(req)
+}
+
+// idempotent сообщает, можно ли безопасно повторить запрос с методом method (RFC 9110, раздел 9.2.2).
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// isConnReset сообщает, что соединение было разорвано сервером до получения ответа.
+func isConnReset(err error) bool {
+	return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
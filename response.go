@@ -1,15 +1,26 @@
 package fluent
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
+	"reflect"
 )
 
 // Response обёртка над http.Response и ошибкой, полученной при выполнении запроса.
 type Response struct {
-	resp *http.Response
-	err  error
+	resp           *http.Response
+	err            error
+	decoders       []Decoder
+	codecs         map[string]Codec
+	defaultTimeout bool
+	prof           *profiler
+	trace          *tracer
+	noContent      bool
+	sent           *http.Request // запрос, на который получен ответ не 2xx, для Curl
 }
 
 // Raw читает и возвращает весь ответ сервера как []byte.
@@ -34,24 +45,206 @@ func (r *Response) Body() (io.ReadCloser, error) {
 	return r.resp.Body, nil
 }
 
+// Text читает тело ответа и возвращает его как строку UTF-8.
+// Кодировка определяется по BOM или параметру charset из Content-Type, по умолчанию — UTF-8.
+// Поддерживаются UTF-8, UTF-16, ISO-8859-1 и Windows-1252.
+func (r *Response) Text() (string, error) {
+	data, err := r.Raw()
+	if err != nil {
+		return "", err
+	}
+
+	return decodeCharset(data, r.resp.Header.Get("Content-Type"))
+}
+
+// Peek возвращает первые n байт тела ответа, не расходуя их: последующие Raw, Into и другие методы
+// прочитают тело целиком. Помогает определить содержимое до декодирования, например JSON это
+// или HTML-страница ошибки от прокси. Если тело короче n, возвращается все тело.
+func (r *Response) Peek(n int) ([]byte, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	pb, ok := r.resp.Body.(*peekBody)
+	if !ok || pb.Size() < n {
+		pb = &peekBody{Reader: bufio.NewReaderSize(r.resp.Body, n), Closer: r.resp.Body}
+		r.resp.Body = pb
+	}
+
+	data, err := pb.Peek(n)
+	if errors.Is(err, io.EOF) {
+		return data, nil
+	}
+
+	return data, err
+}
+
+// peekBody — тело ответа с буфером для Peek.
+type peekBody struct {
+	*bufio.Reader
+	io.Closer
+}
+
+// HeadersInto заполняет структуру, на которую указывает v, значениями заголовков ответа.
+// Имена заголовков задаются тегом `header:"X-RateLimit-Remaining"`.
+// Поддерживаются строки, bool, числа, []string (все значения заголовка), time.Duration
+// (секунды или строка вида "1m30s"), time.Time (HTTP-date или Unix-время) и encoding.TextUnmarshaler.
+// Тело ответа не читается.
+func (r *Response) HeadersInto(v any) error {
+	if r.err != nil {
+		return r.err
+	}
+
+	return bindHeaders(r.resp.Header, v)
+}
+
+// StatusCode возвращает код ответа. Для ответов не 2xx он берется из HTTPError,
+// а если ответа нет (сетевая ошибка), возвращается 0.
+func (r *Response) StatusCode() int {
+	if resp := r.HTTP(); resp != nil {
+		return resp.StatusCode
+	}
+
+	var e *HTTPError
+	if errors.As(r.err, &e) {
+		return e.StatusCode
+	}
+
+	return 0
+}
+
+// Header возвращает заголовки ответа, например заголовки пагинации или лимитов. Для ответов не 2xx
+// они берутся из HTTPError, а если ответа нет, возвращается nil. Тело ответа не читается.
+func (r *Response) Header() http.Header {
+	if resp := r.HTTP(); resp != nil {
+		return resp.Header
+	}
+
+	var e *HTTPError
+	if errors.As(r.err, &e) {
+		return e.Header
+	}
+
+	return nil
+}
+
+// Cookies возвращает cookie из заголовков Set-Cookie ответа.
+func (r *Response) Cookies() []*http.Cookie {
+	return (&http.Response{Header: r.Header()}).Cookies()
+}
+
+// NoContent сообщает, что у ответа нет тела: это ответ на HEAD, 204 No Content или 304 Not Modified.
+// Так ответ 204 можно обработать отдельно, не пытаясь декодировать его через Into, которое вернет io.EOF.
+// Закрывать тело таких ответов не нужно: соединение освобождается сразу.
+func (r *Response) NoContent() bool {
+	return r.noContent
+}
+
+// HTTP возвращает исходный *http.Response или nil, если запрос завершился ошибкой.
+// Тело ответа при этом остается непрочитанным: закройте его или прочитайте через методы Response.
+func (r *Response) HTTP() *http.Response {
+	if r.err != nil {
+		return nil
+	}
+
+	return r.resp
+}
+
 // Error возвращает ошибку, возникшую при выполнении HTTP-запроса.
 // Если ошибки не было — возвращает nil.
 func (r *Response) Error() error {
 	return r.err
 }
 
-// Into декодирует тело ответа из JSON в структуру типа T.
-// Возвращает значение T и ошибку, если она возникла.
+// Into декодирует тело ответа в значение типа T по Content-Type ответа: кодеком из RegisterCodec,
+// XML (application/xml, text/xml, *+xml) — через encoding/xml, application/x-www-form-urlencoded —
+// в url.Values, map[string][]string или map[string]string, text/plain — в string, []byte
+// или encoding.TextUnmarshaler с учетом charset. Во всех остальных случаях, а также если тип T
+// не подходит для текста или формы, тело декодируется как JSON. Если вместо JSON пришла HTML-страница,
+// возвращается ErrUnexpectedHTML или, для страниц входа и captive portal, ErrCaptivePortal.
+// Поля структуры с тегом `fluent:"json:data.user.name"` заполняются значениями вложенных полей JSON,
+// что избавляет от промежуточных DTO.
+// Опции opts (StrictFields, UseNumber) настраивают декодирование JSON.
+// Если у клиента задан DecodeFallbacks, вместо этого декодеры из цепочки пробуются по порядку.
 // Тело ответа автоматически закрывается.
-func Into[T any](r *Response) (T, error) {
+func Into[T any](r *Response, opts ...DecodeOption) (T, error) {
 	var res T
 
+	o := newDecodeOptions(opts)
+
 	if r.err != nil {
 		return res, r.err
 	}
 	defer r.resp.Body.Close()
 
-	err := json.NewDecoder(r.resp.Body).Decode(&res)
+	if r.prof != nil {
+		defer r.prof.decode()()
+	}
+
+	decode := r.decoder()
+
+	contentType := r.resp.Header.Get("Content-Type")
+
+	if len(r.decoders) == 0 && decode == nil && !hasSelectors(reflect.TypeFor[T]()) {
+		body := bufio.NewReader(r.resp.Body)
+
+		if head, _ := body.Peek(sniffLen); isHTML(head, contentType) {
+			data, err := io.ReadAll(body)
+			if err != nil {
+				return res, err
+			}
+
+			return res, r.htmlError(data)
+		}
+
+		err := o.decoder(body).Decode(&res)
+
+		return res, err
+	}
+
+	data, err := io.ReadAll(r.resp.Body)
+	if err != nil {
+		return res, err
+	}
+
+	if len(r.decoders) != 0 {
+		return decodeFallbacks[T](r.decoders, data)
+	}
+
+	if decode != nil {
+		if err := decode(data, &res); !errors.Is(err, ErrUnsupportedTarget) {
+			return res, err
+		}
+	}
+
+	if isHTML(data, contentType) {
+		return res, r.htmlError(data)
+	}
+
+	return decodeJSON[T](data, o)
+}
+
+// decodeJSON декодирует data как JSON и применяет селекторы полей.
+// Для структур с селекторами StrictFields не действует: их вложенные поля не описаны в структуре.
+func decodeJSON[T any](data []byte, o decodeOptions) (T, error) {
+	var res T
+
+	selectors := hasSelectors(reflect.TypeFor[T]())
+	if selectors {
+		o.strict = false
+	}
+
+	if !o.strict && !o.useNumber {
+		if err := json.Unmarshal(data, &res); err != nil {
+			return res, err
+		}
+	} else if err := o.decoder(bytes.NewReader(data)).Decode(&res); err != nil {
+		return res, err
+	}
+
+	if selectors {
+		return res, applySelectors(data, &res)
+	}
 
-	return res, err
+	return res, nil
 }
@@ -0,0 +1,669 @@
+package fluent_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/devem-tech/fluent"
+)
+
+func TestResponse_Text_Charset(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+		want        string
+	}{
+		{"default utf-8", "text/plain", []byte("привет"), "привет"},
+		{"latin1", "text/plain; charset=ISO-8859-1", []byte{'c', 'a', 'f', 0xE9}, "café"},
+		{"windows-1252", "text/html; charset=windows-1252", []byte{0x93, 'h', 'i', 0x94, ' ', 0x80}, "“hi” €"},
+		{"utf-16 bom", "text/plain", []byte{0xFF, 0xFE, 'o', 0, 'k', 0}, "ok"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				_, _ = w.Write(tt.body)
+			}))
+			t.Cleanup(srv.Close)
+
+			got, err := fluent.New().Get(context.Background(), srv.URL).Text()
+			if err != nil {
+				t.Fatalf("Text returned error: %v", err)
+			}
+
+			if got != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestResponse_HeadersInto(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "42")
+		w.Header().Set("X-RateLimit-Reset", "1700000000")
+		w.Header().Set("Retry-After", "30")
+		w.Header().Add("Link", "<a>")
+		w.Header().Add("Link", "<b>")
+	}))
+	t.Cleanup(srv.Close)
+
+	var h struct {
+		Remaining  int           `header:"X-RateLimit-Remaining"`
+		Reset      time.Time     `header:"X-RateLimit-Reset"`
+		RetryAfter time.Duration `header:"Retry-After"`
+		Links      []string      `header:"Link"`
+		Missing    string        `header:"X-Missing"`
+	}
+
+	if err := fluent.New().Get(context.Background(), srv.URL).HeadersInto(&h); err != nil {
+		t.Fatalf("HeadersInto returned error: %v", err)
+	}
+
+	if h.Remaining != 42 || h.RetryAfter != 30*time.Second || h.Reset.Unix() != 1700000000 {
+		t.Fatalf("unexpected headers: %+v", h)
+	}
+
+	if len(h.Links) != 2 || h.Missing != "" {
+		t.Fatalf("unexpected headers: %+v", h)
+	}
+}
+
+func TestResponse_ByteRanges(t *testing.T) {
+	t.Parallel()
+
+	const content = "0123456789abcdefghij"
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "data.txt", time.Time{}, strings.NewReader(content))
+	}))
+	t.Cleanup(srv.Close)
+
+	tests := []struct {
+		rangeHeader string
+		want        []string
+	}{
+		{"bytes=2-5", []string{"2345"}},
+		{"bytes=0-1,10-12", []string{"01", "abc"}},
+		{"", []string{content}},
+	}
+
+	for _, tt := range tests {
+		c := fluent.New()
+		if tt.rangeHeader != "" {
+			c.Header("Range", tt.rangeHeader)
+		}
+
+		ranges, err := c.Get(context.Background(), srv.URL).ByteRanges()
+		if err != nil {
+			t.Fatalf("%q: ByteRanges returned error: %v", tt.rangeHeader, err)
+		}
+
+		if len(ranges) != len(tt.want) {
+			t.Fatalf("%q: expected %d ranges, got %d", tt.rangeHeader, len(tt.want), len(ranges))
+		}
+
+		for i, want := range tt.want {
+			if string(ranges[i].Data) != want || ranges[i].Size != int64(len(content)) {
+				t.Fatalf("%q: unexpected range %d: %+v", tt.rangeHeader, i, ranges[i])
+			}
+		}
+	}
+}
+
+func TestResponse_Table(t *testing.T) {
+	t.Parallel()
+
+	srv := serve(t, `[{"id":1,"name":"Leanne","address":{"city":"Gwenborough"}},{"id":2,"name":"Ervin"}]`)
+
+	var buf strings.Builder
+
+	if err := fluent.New().Get(context.Background(), srv.URL).Table(&buf, "id", "name", "address.city"); err != nil {
+		t.Fatalf("Table returned error: %v", err)
+	}
+
+	want := "ID  NAME    ADDRESS.CITY\n" +
+		"1   Leanne  Gwenborough\n" +
+		"2   Ervin   \n"
+	if buf.String() != want {
+		t.Fatalf("unexpected table:\n%s", buf.String())
+	}
+}
+
+func TestResponse_PrettyJSON(t *testing.T) {
+	t.Parallel()
+
+	srv := serve(t, `{"id":1,"tags":["a"]}`)
+
+	var buf strings.Builder
+
+	if err := fluent.New().Get(context.Background(), srv.URL).PrettyJSON(&buf); err != nil {
+		t.Fatalf("PrettyJSON returned error: %v", err)
+	}
+
+	if buf.String() != "{\n  \"id\": 1,\n  \"tags\": [\n    \"a\"\n  ]\n}\n" {
+		t.Fatalf("unexpected output:\n%s", buf.String())
+	}
+}
+
+func TestResponse_RedirectHistory(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.Handle("/short", http.RedirectHandler("/hop", http.StatusMovedPermanently))
+	mux.Handle("/hop", http.RedirectHandler("/final", http.StatusFound))
+	mux.HandleFunc("/final", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	resp := fluent.New().Get(context.Background(), srv.URL+"/short")
+
+	last, err := resp.LastRequest()
+	if err != nil {
+		t.Fatalf("LastRequest returned error: %v", err)
+	}
+
+	if last.URL.Path != "/final" {
+		t.Fatalf("expected final URL /final, got %s", last.URL)
+	}
+
+	hops, err := resp.RedirectHistory()
+	if err != nil {
+		t.Fatalf("RedirectHistory returned error: %v", err)
+	}
+
+	want := []fluent.RedirectHop{
+		{URL: srv.URL + "/short", StatusCode: http.StatusMovedPermanently, Location: "/hop"},
+		{URL: srv.URL + "/hop", StatusCode: http.StatusFound, Location: "/final"},
+	}
+	if !reflect.DeepEqual(hops, want) {
+		t.Fatalf("unexpected redirect history: %+v", hops)
+	}
+}
+
+func TestResponse_Peek(t *testing.T) {
+	t.Parallel()
+
+	srv := serve(t, `{"id":1}`)
+
+	resp := fluent.New().Get(context.Background(), srv.URL)
+
+	head, err := resp.Peek(1)
+	if err != nil {
+		t.Fatalf("Peek returned error: %v", err)
+	}
+
+	if string(head) != "{" {
+		t.Fatalf("unexpected peek: %q", head)
+	}
+
+	if head, _ = resp.Peek(100); string(head) != `{"id":1}` {
+		t.Fatalf("expected whole short body, got %q", head)
+	}
+
+	v, err := fluent.Into[struct{ ID int }](resp)
+	if err != nil {
+		t.Fatalf("Into returned error: %v", err)
+	}
+
+	if v.ID != 1 {
+		t.Fatalf("expected body to be decoded after Peek, got %+v", v)
+	}
+}
+
+func TestResponse_SpoolToDisk(t *testing.T) {
+	t.Parallel()
+
+	payload := strings.Repeat("0123456789", 10)
+	srv := serve(t, payload)
+	dir := t.TempDir()
+
+	c := fluent.New().SpoolToDisk(10, dir)
+
+	body, err := c.Get(context.Background(), srv.URL).Seekable()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Fatalf("expected spooled file, got %d entries", len(entries))
+	}
+
+	if _, err := body.Seek(90, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+
+	if tail, _ := io.ReadAll(body); string(tail) != payload[90:] {
+		t.Fatalf("unexpected tail %q", tail)
+	}
+
+	if err := body.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Fatalf("expected spooled file to be removed, got %d entries", len(entries))
+	}
+
+	data, err := fluent.New().SpoolToDisk(1000, dir).Get(context.Background(), srv.URL).Raw()
+	if err != nil || string(data) != payload {
+		t.Fatalf("unexpected in-memory body %q: %v", data, err)
+	}
+
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Fatalf("small body must stay in memory, got %d entries", len(entries))
+	}
+}
+
+func TestResponse_SpoolToDisk_Observed(t *testing.T) {
+	t.Parallel()
+
+	payload := strings.Repeat("0123456789", 10)
+	srv := serve(t, payload)
+
+	observers := map[string]func(c *fluent.Client, n *atomic.Int32) *fluent.Client{
+		"Profile": func(c *fluent.Client, n *atomic.Int32) *fluent.Client {
+			return c.Profile(func(fluent.RequestProfile) { n.Add(1) })
+		},
+		"Metrics": func(c *fluent.Client, n *atomic.Int32) *fluent.Client {
+			return c.Metrics(metricsFunc(func(string, string, int, time.Duration, int64) { n.Add(1) }))
+		},
+	}
+
+	for name, observe := range observers {
+		dir := t.TempDir()
+
+		var observed atomic.Int32
+
+		c := observe(fluent.New().SpoolToDisk(10, dir), &observed)
+
+		body, err := c.Get(context.Background(), srv.URL).Seekable()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+			t.Fatalf("%s: expected spooled file to back the seekable body, got %d entries", name, len(entries))
+		}
+
+		if _, err := body.Seek(90, io.SeekStart); err != nil {
+			t.Fatal(err)
+		}
+
+		if tail, _ := io.ReadAll(body); string(tail) != payload[90:] {
+			t.Fatalf("%s: unexpected tail %q", name, tail)
+		}
+
+		if err := body.Close(); err != nil || observed.Load() != 1 {
+			t.Fatalf("%s: expected one observation on close, got %d: %v", name, observed.Load(), err)
+		}
+	}
+}
+
+func TestClient_Subscribe(t *testing.T) {
+	t.Parallel()
+
+	var conns atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+
+		switch conns.Add(1) {
+		case 1:
+			_, _ = w.Write([]byte(": hello\nretry: 10\nid: 1\ndata: a\n\ndata: unterminated"))
+		case 2:
+			if got := r.Header.Get("Last-Event-ID"); got != "1" {
+				t.Errorf("unexpected Last-Event-ID %q", got)
+			}
+
+			_, _ = w.Write([]byte("event: tick\r\ndata: b\r\ndata: c\r\n\r\n"))
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	ch := make(chan fluent.Event)
+	errc := make(chan error, 1)
+
+	go func() { errc <- fluent.New().Subscribe(context.Background(), srv.URL, ch) }()
+
+	var events []fluent.Event
+	for e := range ch {
+		events = append(events, e)
+	}
+
+	want := []fluent.Event{
+		{ID: "1", Type: "message", Data: "a"},
+		{ID: "1", Type: "tick", Data: "b\nc"},
+	}
+
+	if err := <-errc; err != nil || !reflect.DeepEqual(events, want) {
+		t.Fatalf("unexpected events %+v: %v", events, err)
+	}
+}
+
+func TestClient_FollowLocation_CrossOrigin(t *testing.T) {
+	t.Parallel()
+
+	storage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Header.Get("Authorization") + "|" + r.Header.Get("Cookie") + "|" +
+			r.Header.Get(fluent.DefaultSignatureHeader) + "|" + r.Header.Get("X-Trace")))
+	}))
+	t.Cleanup(storage.Close)
+
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Location", storage.URL+"/exports/1?sig=presigned")
+		w.WriteHeader(http.StatusCreated)
+	}))
+	t.Cleanup(api.Close)
+
+	c := fluent.New().
+		BaseURL(api.URL).
+		BearerToken("secret").
+		Sign((&fluent.HMACSigner{Key: []byte("k")}).Sign).
+		Use(func(next fluent.RoundFunc) fluent.RoundFunc {
+			return func(req *http.Request) (*http.Response, error) {
+				req = req.Clone(req.Context())
+				req.Header.Set("Cookie", "session=secret")
+
+				return next(req)
+			}
+		}).
+		FollowLocation()
+
+	got, err := c.Request().Header("X-Trace", "t1").Post(context.Background(), "/exports").Raw()
+	if err != nil || string(got) != "|||t1" {
+		t.Fatalf("expected no credentials on another origin, got %q: %v", got, err)
+	}
+}
+
+func TestClient_Subscribe_BodyReads(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: a\n\n"))
+		w.(http.Flusher).Flush()
+
+		// Бесконечный поток: отвечаем, пока клиент не отключится
+		<-r.Context().Done()
+	}))
+	t.Cleanup(srv.Close)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch := make(chan fluent.Event)
+	errc := make(chan error, 1)
+
+	c := fluent.New().RetryPolicy(fluent.RetryPolicy{MaxRetries: 1, BodyReads: true})
+
+	go func() { errc <- c.Subscribe(ctx, srv.URL, ch) }()
+
+	select {
+	case e := <-ch:
+		if e.Data != "a" {
+			t.Fatalf("unexpected event %+v", e)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected the event before the stream ends")
+	}
+
+	cancel()
+
+	for range ch { //nolint:revive
+	}
+
+	<-errc
+}
+
+func TestResponse_SaveTo_Resume(t *testing.T) {
+	t.Parallel()
+
+	content := strings.Repeat("0123456789", 100)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		http.ServeContent(w, r, "export.csv", time.Time{}, strings.NewReader(content))
+	}))
+	t.Cleanup(srv.Close)
+
+	path := filepath.Join(t.TempDir(), "export.csv")
+	c := fluent.New()
+
+	n, err := c.Request().Resume(path).Get(context.Background(), srv.URL).SaveTo(path)
+	if err != nil || n != int64(len(content)) {
+		t.Fatalf("full download: n=%d err=%v", n, err)
+	}
+
+	// Прерванная загрузка: часть файла и валидатор исходного ответа
+	if err := os.WriteFile(path, []byte(content[:300]), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(path+".resume", []byte(`"v1"`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err = c.Request().Resume(path).Get(context.Background(), srv.URL).SaveTo(path)
+	if err != nil || n != int64(len(content)-300) {
+		t.Fatalf("resumed download: n=%d err=%v", n, err)
+	}
+
+	if data, _ := os.ReadFile(path); string(data) != content {
+		t.Fatal("resumed file does not match content")
+	}
+
+	if _, err := os.Stat(path + ".resume"); !os.IsNotExist(err) {
+		t.Fatalf("resume file must be removed, got %v", err)
+	}
+
+	// Ресурс изменился: сервер отдает его целиком
+	_ = os.WriteFile(path, []byte("stale"), 0o600)
+	_ = os.WriteFile(path+".resume", []byte(`"v0"`), 0o600)
+
+	n, err = c.Request().Resume(path).Get(context.Background(), srv.URL).SaveTo(path)
+	if data, _ := os.ReadFile(path); err != nil || n != int64(len(content)) || string(data) != content {
+		t.Fatalf("restarted download: n=%d err=%v", n, err)
+	}
+}
+
+func TestResponse_Progress(t *testing.T) {
+	t.Parallel()
+
+	payload := strings.Repeat("x", 2000)
+	srv := serve(t, payload)
+
+	var last, total int64
+
+	data, err := fluent.New().Get(context.Background(), srv.URL).
+		Progress(func(read, size int64) { last, total = read, size }).
+		Raw()
+	if err != nil || len(data) != len(payload) {
+		t.Fatalf("unexpected body (%d bytes): %v", len(data), err)
+	}
+
+	if last != int64(len(payload)) || total != int64(len(payload)) {
+		t.Fatalf("unexpected progress %d/%d", last, total)
+	}
+}
+
+func TestResponse_Accessors(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "7")
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc"})
+
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	resp := fluent.New().Get(context.Background(), srv.URL)
+	defer resp.HTTP().Body.Close()
+
+	if resp.StatusCode() != http.StatusOK || resp.Header().Get("X-RateLimit-Remaining") != "7" {
+		t.Fatalf("unexpected status %d or headers %v", resp.StatusCode(), resp.Header())
+	}
+
+	if cookies := resp.Cookies(); len(cookies) != 1 || cookies[0].Name != "session" || cookies[0].Value != "abc" {
+		t.Fatalf("unexpected cookies %v", cookies)
+	}
+
+	missing := fluent.New().Get(context.Background(), srv.URL+"/missing")
+	if missing.StatusCode() != http.StatusNotFound || missing.Header().Get("X-RateLimit-Remaining") != "7" || missing.HTTP() != nil {
+		t.Fatalf("unexpected accessors for error response: %d %v", missing.StatusCode(), missing.Header())
+	}
+}
+
+func TestResponse_TraceInfo(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		_, _ = w.Write([]byte("ok"))
+	}))
+	t.Cleanup(srv.Close)
+
+	c := fluent.New()
+
+	first := c.Get(context.Background(), srv.URL)
+	if _, err := first.Raw(); err != nil {
+		t.Fatal(err)
+	}
+
+	info := first.TraceInfo()
+	if first.Duration() < 20*time.Millisecond || info.TTFB < 20*time.Millisecond || info.ServerTime <= 0 {
+		t.Fatalf("unexpected timings %+v", info)
+	}
+
+	if info.ConnReused || info.RemoteAddr == "" {
+		t.Fatalf("unexpected connection info %+v", info)
+	}
+
+	second := c.Get(context.Background(), srv.URL)
+	if _, err := second.Raw(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !second.TraceInfo().ConnReused {
+		t.Fatalf("expected reused connection %+v", second.TraceInfo())
+	}
+
+	if d := fluent.New().Get(context.Background(), "http://%zz").Duration(); d != 0 {
+		t.Fatalf("unexpected duration for unsent request %v", d)
+	}
+}
+
+func TestResponse_NoContent(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/deleted":
+			w.WriteHeader(http.StatusNoContent)
+		case "/missing":
+			http.Error(w, "not found", http.StatusNotFound)
+		default:
+			_, _ = w.Write([]byte(`{"id":1}`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	c := fluent.New().BaseURL(srv.URL)
+
+	deleted := c.Request().Do(context.Background(), http.MethodDelete, "/deleted")
+	if !deleted.NoContent() || deleted.StatusCode() != http.StatusNoContent {
+		t.Fatalf("expected 204 without content, got %d", deleted.StatusCode())
+	}
+
+	if data, err := deleted.Raw(); err != nil || len(data) != 0 {
+		t.Fatalf("unexpected body %q: %v", data, err)
+	}
+
+	head := c.Request().Do(context.Background(), http.MethodHead, "/missing")
+
+	var httpErr *fluent.HTTPError
+	if !head.NoContent() || !errors.As(head.Error(), &httpErr) || len(httpErr.Body) != 0 {
+		t.Fatalf("expected HEAD error without body, got %v", head.Error())
+	}
+
+	get := c.Get(context.Background(), "/")
+	if _, err := get.Raw(); err != nil || get.NoContent() {
+		t.Fatalf("expected GET with body to have content: %v", err)
+	}
+}
+
+func TestResponse_Location(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/items":
+			w.Header().Set("Location", "items/42?"+r.URL.RawQuery)
+			w.WriteHeader(http.StatusCreated)
+		case "/items/42":
+			_, _ = w.Write([]byte(`{"id":42,"auth":"` + r.Header.Get("Authorization") + `","query":"` + r.URL.RawQuery + `"}`))
+		case "/old":
+			w.Header().Set("Location", "/new")
+			w.WriteHeader(http.StatusMovedPermanently)
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	c := fluent.New().BaseURL(srv.URL).Query("api_key", "k")
+
+	u, err := c.Request().Body(map[string]int{"id": 42}).Post(context.Background(), "/api/items").Location()
+	if !errors.Is(err, fluent.ErrNoLocation) {
+		t.Fatalf("expected ErrNoLocation, got %v, %v", u, err)
+	}
+
+	if u, err = c.Post(context.Background(), "/items").Location(); err != nil || u.String() != srv.URL+"/items/42?api_key=k" {
+		t.Fatalf("expected resolved Location, got %v: %v", u, err)
+	}
+
+	noRedirects := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	if u, err = c.Request().WithHTTPClient(noRedirects).Get(context.Background(), "/old").Location(); err != nil ||
+		u.String() != srv.URL+"/new" {
+		t.Fatalf("expected Location of 301, got %v: %v", u, err)
+	}
+
+	type item struct {
+		ID    int    `json:"id"`
+		Auth  string `json:"auth"`
+		Query string `json:"query"`
+	}
+
+	resp := c.Request().Header("Authorization", "Bearer t").FollowLocation().Body(item{}).Post(context.Background(), "/items")
+
+	got, err := fluent.Into[item](resp)
+	if err != nil || got != (item{ID: 42, Auth: "Bearer t", Query: "api_key=k"}) {
+		t.Fatalf("expected created item, got %+v: %v", got, err)
+	}
+}
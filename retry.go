@@ -0,0 +1,256 @@
+package fluent
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"mime"
+	"net"
+	"net/http"
+	"slices"
+	"syscall"
+	"time"
+)
+
+const (
+	// DefaultMinBackoff — задержка перед первым повтором по умолчанию.
+	DefaultMinBackoff = 100 * time.Millisecond
+	// DefaultMaxBackoff — максимальная задержка между повторами по умолчанию.
+	DefaultMaxBackoff = 5 * time.Second
+	// DefaultBodyReadLimit — наибольший размер тела, которое RetryPolicy.BodyReads читает в память по умолчанию.
+	DefaultBodyReadLimit = 10 << 20
+)
+
+// DefaultRetryStatusCodes — коды ответа, при которых запрос повторяется по умолчанию.
+var DefaultRetryStatusCodes = []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout}
+
+// RetryPolicy задает повтор неудачных запросов с экспоненциальной задержкой и jitter.
+// Запрос повторяется при временных сетевых ошибках и при кодах ответа из StatusCodes.
+// Тело запроса воспроизводится при каждом повторе.
+type RetryPolicy struct {
+	// MaxRetries — число повторов после первой попытки. 0 выключает повторы.
+	MaxRetries int
+	// MinBackoff — задержка перед первым повтором, дальше она удваивается. По умолчанию DefaultMinBackoff.
+	MinBackoff time.Duration
+	// MaxBackoff — верхняя граница задержки. По умолчанию DefaultMaxBackoff. Если сервер просит в Retry-After
+	// подождать дольше, запрос не повторяется и возвращается ответ сервера.
+	MaxBackoff time.Duration
+	// StatusCodes — коды ответа, при которых запрос повторяется. По умолчанию DefaultRetryStatusCodes.
+	StatusCodes []int
+	// BodyReads включает повтор идемпотентных запросов, если чтение тела успешного ответа оборвалось
+	// (io.ErrUnexpectedEOF или разрыв соединения на нестабильной сети). Для этого тела ответов 2xx
+	// на идемпотентные запросы читаются в память целиком до возврата Response. Потоки не буферизуются
+	// и не повторяются: тела без Content-Length или длиннее BodyReadLimit, text/event-stream, NDJSON
+	// и ответы клиентов со SpoolToDisk.
+	BodyReads bool
+	// BodyReadLimit — наибольший Content-Length тела, которое BodyReads читает в память.
+	// По умолчанию DefaultBodyReadLimit.
+	BodyReadLimit int64
+}
+
+// backoff возвращает задержку перед повтором attempt (начиная с 1) с "полным" jitter.
+// Если сервер прислал Retry-After, задержка не меньше указанной, а если указанная больше MaxBackoff
+// или не укладывается в дедлайн ctx, backoff возвращает false: повтор не имеет смысла.
+func (p *RetryPolicy) backoff(ctx context.Context, attempt int, resp *http.Response) (time.Duration, bool) {
+	lo, hi := p.MinBackoff, p.MaxBackoff
+	if lo <= 0 {
+		lo = DefaultMinBackoff
+	}
+
+	if hi <= 0 {
+		hi = DefaultMaxBackoff
+	}
+
+	d := hi
+	if shift := attempt - 1; shift < 32 && lo<<shift < hi { //nolint:mnd
+		d = lo << shift
+	}
+
+	d = d/2 + rand.N(d/2+1) //nolint:gosec,mnd
+
+	if resp != nil {
+		if until, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+			wait := time.Until(until)
+			if wait > hi {
+				return 0, false
+			}
+
+			if deadline, ok := ctx.Deadline(); ok && until.After(deadline) {
+				return 0, false
+			}
+
+			d = max(d, wait)
+		}
+	}
+
+	return d, true
+}
+
+// retryable сообщает, нужно ли повторить запрос по результату попытки.
+func (p *RetryPolicy) retryable(ctx context.Context, resp *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	if err != nil {
+		return isTransient(err)
+	}
+
+	codes := p.StatusCodes
+	if codes == nil {
+		codes = DefaultRetryStatusCodes
+	}
+
+	return slices.Contains(codes, resp.StatusCode)
+}
+
+// isTransient сообщает, что сетевая ошибка временная и запрос имеет смысл повторить.
+func isTransient(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var ne net.Error
+	if errors.As(err, &ne) && ne.Timeout() {
+		return true
+	}
+
+	return isConnReset(err) || errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// execute отправляет запрос через цепочку Use с учетом Quota, Throttle, RetryOnReset и RetryPolicy
+// и передает ответы в Throttle и ClockSkew.
+func (c *Client) execute(client httpClient, req *http.Request) (*http.Response, error) {
+	var budget *resetBudget
+	if c.resetRetry && c.retry.MaxRetries > 0 {
+		budget = &resetBudget{}
+		req = req.WithContext(context.WithValue(req.Context(), resetBudgetKey{}, budget))
+	}
+
+	for attempt := 0; ; attempt++ {
+		if budget != nil {
+			budget.left = c.retry.MaxRetries - attempt
+		}
+
+		resp, err := c.attempt(client, req)
+
+		if budget != nil {
+			attempt += budget.used
+			budget.used = 0
+		}
+
+		if attempt >= c.retry.MaxRetries || !c.retry.retryable(req.Context(), resp, err) {
+			return resp, err
+		}
+
+		delay, ok := c.retry.backoff(req.Context(), attempt+1, resp)
+		if !ok {
+			return resp, err
+		}
+
+		if req.Body != nil && req.Body != http.NoBody {
+			if req.GetBody == nil {
+				return resp, err
+			}
+
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				return resp, err
+			}
+
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+
+			return nil, req.Context().Err()
+		}
+	}
+}
+
+// attempt выполняет одну попытку запроса.
+func (c *Client) attempt(client httpClient, req *http.Request) (*http.Response, error) {
+	if c.quota != nil {
+		if err := c.quota.acquire(req); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.limiter != nil {
+		if err := c.limiter.Wait(req.Context()); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := c.chain(client)(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.retry.BodyReads && idempotent(req.Method) && c.spool == nil && c.retry.bufferable(resp) {
+		if err := bufferBody(resp); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.limiter != nil {
+		c.limiter.Observe(resp)
+	}
+
+	if c.clock != nil {
+		c.clock.Observe(resp)
+	}
+
+	return resp, nil
+}
+
+// streamingTypes — типы тел, которые приходят потоком и не читаются в память ради BodyReads.
+var streamingTypes = []string{"text/event-stream", "application/x-ndjson", "application/jsonl", "application/json-seq"}
+
+// bufferable сообщает, что тело успешного ответа можно прочитать в память целиком ради BodyReads:
+// его длина известна и не больше BodyReadLimit, и это не поток.
+func (p *RetryPolicy) bufferable(resp *http.Response) bool {
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return false
+	}
+
+	limit := p.BodyReadLimit
+	if limit <= 0 {
+		limit = DefaultBodyReadLimit
+	}
+
+	if resp.ContentLength < 0 || resp.ContentLength > limit {
+		return false
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+
+	return !slices.Contains(streamingTypes, mediaType)
+}
+
+// bufferBody читает тело ответа целиком и подменяет его прочитанной копией.
+func bufferBody(resp *http.Response) error {
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+
+	return nil
+}
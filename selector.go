@@ -0,0 +1,78 @@
+package fluent
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ErrSelector возвращается при неверном теге `fluent:"..."`.
+var ErrSelector = errors.New("invalid field selector")
+
+// selectorPrefix — префикс селектора JSON в теге fluent.
+const selectorPrefix = "json:"
+
+// hasSelectors сообщает, что у структуры t есть поля с тегом fluent.
+func hasSelectors(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+
+	for i := range t.NumField() {
+		if _, ok := t.Field(i).Tag.Lookup("fluent"); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// applySelectors заполняет поля структуры, на которую указывает v, значениями вложенных полей JSON data
+// по тегам вида `fluent:"json:data.user.name"`. Элементы массивов выбираются по индексу: "items.0.id".
+// Поле, путь которого в ответе не найден, получает нулевое значение.
+func applySelectors(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return err
+	}
+
+	rv := reflect.ValueOf(v).Elem()
+
+	for i := range rv.NumField() {
+		f, field := rv.Type().Field(i), rv.Field(i)
+
+		tag, ok := f.Tag.Lookup("fluent")
+		if !ok {
+			continue
+		}
+
+		path, ok := strings.CutPrefix(tag, selectorPrefix)
+		if !ok || path == "" {
+			return fmt.Errorf("%w: field %s: %q", ErrSelector, f.Name, tag)
+		}
+
+		found := lookupPath(doc, path)
+		if found == nil {
+			field.SetZero()
+
+			continue
+		}
+
+		raw, err := json.Marshal(found)
+		if err != nil {
+			return err
+		}
+
+		if err := json.Unmarshal(raw, field.Addr().Interface()); err != nil {
+			return fmt.Errorf("field %s: %w", f.Name, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package fluent_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/devem-tech/fluent"
+)
+
+func TestClient_Sign_HMAC(t *testing.T) {
+	t.Parallel()
+
+	key := []byte("secret")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(r.Method + "\n" + r.URL.EscapedPath() + "\n" + r.Header.Get("X-Ts") + "\n" + string(body)))
+
+		if r.Header.Get("X-Ts") != "1700000000" || r.Header.Get(fluent.DefaultSignatureHeader) != hex.EncodeToString(mac.Sum(nil)) {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(srv.Close)
+
+	signer := &fluent.HMACSigner{
+		Key:             key,
+		TimestampHeader: "X-Ts",
+		Now:             func() time.Time { return time.Unix(1700000000, 0) },
+	}
+
+	err := fluent.New().
+		BaseURL(srv.URL).
+		Sign(signer.Sign).
+		Body(map[string]int{"n": 1}).
+		Post(context.Background(), "/orders").
+		Error()
+	if err != nil {
+		t.Fatalf("Post returned error: %v", err)
+	}
+}
+
+func TestRequest_Snapshot(t *testing.T) {
+	t.Parallel()
+
+	signer := &fluent.HMACSigner{Key: []byte("k"), Now: func() time.Time { return time.Unix(1700000000, 0) }}
+
+	got, err := fluent.New().
+		BaseURL("http://api.example.com").
+		Sign(signer.Sign).
+		Request().
+		Header("X-Tenant", "acme").
+		Query("page", "2").
+		BodyString("hi").
+		Snapshot(http.MethodPost, "/notes")
+	if err != nil {
+		t.Fatalf("Snapshot returned error: %v", err)
+	}
+
+	mac := hmac.New(sha256.New, []byte("k"))
+	mac.Write([]byte("POST\n/notes\n1700000000\nhi"))
+
+	want := "POST /notes?page=2 HTTP/1.1\r\n" +
+		"Host: api.example.com\r\n" +
+		"User-Agent: Go-http-client/1.1\r\n" +
+		"Content-Length: 2\r\n" +
+		"Content-Type: text/plain; charset=utf-8\r\n" +
+		"X-Signature: " + hex.EncodeToString(mac.Sum(nil)) + "\r\n" +
+		"X-Tenant: acme\r\n" +
+		"X-Timestamp: 1700000000\r\n" +
+		"Accept-Encoding: gzip\r\n" +
+		"\r\n" +
+		"hi"
+	if string(got) != want {
+		t.Fatalf("unexpected snapshot:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,86 @@
+package fluent_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/devem-tech/fluent"
+)
+
+var awsTestCredentials = fluent.AWSCredentials{
+	AccessKeyID:     "AKIDEXAMPLE",
+	SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+}
+
+func TestAWSSigner_GetVanilla(t *testing.T) {
+	t.Parallel()
+
+	var audit fluent.SignatureAudit
+
+	// Пример get-vanilla из AWS Signature Version 4 Test Suite.
+	signer := &fluent.AWSSigner{
+		Credentials: awsTestCredentials,
+		Region:      "us-east-1",
+		Service:     "service",
+		Now:         func() time.Time { return time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC) },
+		Audit:       func(a fluent.SignatureAudit) { audit = a },
+	}
+
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, "https://example.amazonaws.com/", nil)
+
+	if err := signer.Sign(req); err != nil {
+		t.Fatalf("Sign returned error: %v", err)
+	}
+
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+		"SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Fatalf("unexpected Authorization:\n got %s\nwant %s", got, want)
+	}
+
+	canonical := "GET\n/\n\nhost:example.amazonaws.com\nx-amz-date:20150830T123600Z\n\nhost;x-amz-date\n" +
+		"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	if audit.CanonicalRequest != canonical || !strings.HasSuffix(want, audit.Signature) {
+		t.Fatalf("unexpected audit: %+v", audit)
+	}
+}
+
+func TestClient_SignAWSv4(t *testing.T) {
+	t.Parallel()
+
+	var got http.Header
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(srv.Close)
+
+	err := fluent.New().
+		BaseURL(srv.URL).
+		SignAWSv4(awsTestCredentials, "eu-central-1", "s3").
+		Body(map[string]int{"n": 1}).
+		Post(context.Background(), "/bucket/key").
+		Error()
+	if err != nil {
+		t.Fatalf("Post returned error: %v", err)
+	}
+
+	sum := sha256.Sum256([]byte(`{"n":1}`))
+	if got.Get("X-Amz-Content-Sha256") != hex.EncodeToString(sum[:]) {
+		t.Fatalf("unexpected payload hash: %q", got.Get("X-Amz-Content-Sha256"))
+	}
+
+	auth := got.Get("Authorization")
+	if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") ||
+		!strings.Contains(auth, "/eu-central-1/s3/aws4_request, SignedHeaders=content-type;host;x-amz-content-sha256;x-amz-date,") {
+		t.Fatalf("unexpected Authorization: %q", auth)
+	}
+}
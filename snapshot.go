@@ -0,0 +1,33 @@
+package fluent
+
+import (
+	"context"
+	"net/http/httputil"
+)
+
+// Snapshot возвращает байты запроса method path ровно в том виде, в каком он уйдет в сеть по HTTP/1.1:
+// стартовую строку, заголовки (включая добавленные транспортом net/http) и тело после Seal,
+// ContentEncoding и подписей Sign. Запрос не отправляется. Полезно для предварительного расчета подписей,
+// golden-тестов и поиска расхождений в канонизации. Middleware из Use не применяются,
+// а подписи с меткой времени отражают момент вызова Snapshot.
+func (r *Request) Snapshot(method, path string) ([]byte, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	fullURL, err := r.c.fullURL(path, &r.requestState)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := r.c.newRequest(context.Background(), method, fullURL, &r.requestState)
+	if err != nil {
+		return nil, err
+	}
+
+	if req, err = r.c.sign(req); err != nil {
+		return nil, err
+	}
+
+	return httputil.DumpRequestOut(req, true)
+}
@@ -0,0 +1,55 @@
+package fluent
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// ErrUnsupportedSocketOption возвращается при dial, если опция сокета не поддерживается на текущей платформе.
+var ErrUnsupportedSocketOption = errors.New("unsupported socket option")
+
+// SocketOptions — параметры TCP-сокетов исходящих соединений.
+// Полезны для долгоживущих потоковых соединений, которые проходят через агрессивные NAT.
+type SocketOptions struct {
+	// KeepAlive — параметры TCP keepalive: задержка до первой пробы, интервал и число проб.
+	KeepAlive net.KeepAliveConfig
+	// UserTimeout — TCP_USER_TIMEOUT: сколько переданные данные могут оставаться неподтвержденными,
+	// прежде чем соединение будет закрыто. Поддерживается только на Linux.
+	UserTimeout time.Duration
+	// TOS — значение IP_TOS (IPV6_TCLASS для IPv6), например DSCP << 2. Поддерживается только на Linux.
+	TOS int
+}
+
+// NewTransport возвращает копию http.DefaultTransport, которая применяет opts к каждому новому соединению:
+//
+//	c.HTTPClient(&http.Client{Transport: fluent.NewTransport(fluent.SocketOptions{...})})
+func NewTransport(opts SocketOptions) *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone() //nolint:forcetypeassert
+	dialer := &net.Dialer{
+		Timeout:         30 * time.Second, //nolint:mnd
+		KeepAliveConfig: opts.KeepAlive,
+		Control: func(network, _ string, conn syscall.RawConn) error {
+			var sockErr error
+
+			err := conn.Control(func(fd uintptr) {
+				sockErr = setSocketOptions(network, fd, opts)
+			})
+
+			return errors.Join(err, sockErr)
+		},
+	}
+
+	if !opts.KeepAlive.Enable {
+		dialer.KeepAlive = 30 * time.Second //nolint:mnd
+	}
+
+	t.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return dialer.DialContext(ctx, network, addr)
+	}
+
+	return t
+}
@@ -0,0 +1,35 @@
+package fluent
+
+import (
+	"os"
+	"strings"
+	"syscall"
+)
+
+// tcpUserTimeout — TCP_USER_TIMEOUT из linux/tcp.h, которого нет в пакете syscall.
+const tcpUserTimeout = 0x12
+
+func setSocketOptions(network string, fd uintptr, opts SocketOptions) error {
+	if opts.UserTimeout > 0 {
+		ms := int(opts.UserTimeout.Milliseconds())
+		if err := syscall.SetsockoptInt(int(fd), syscall.IPPROTO_TCP, tcpUserTimeout, ms); err != nil {
+			return os.NewSyscallError("setsockopt TCP_USER_TIMEOUT", err)
+		}
+	}
+
+	if opts.TOS != 0 {
+		if strings.HasSuffix(network, "6") {
+			if err := syscall.SetsockoptInt(int(fd), syscall.IPPROTO_IPV6, syscall.IPV6_TCLASS, opts.TOS); err != nil {
+				return os.NewSyscallError("setsockopt IPV6_TCLASS", err)
+			}
+
+			return nil
+		}
+
+		if err := syscall.SetsockoptInt(int(fd), syscall.IPPROTO_IP, syscall.IP_TOS, opts.TOS); err != nil {
+			return os.NewSyscallError("setsockopt IP_TOS", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,17 @@
+//go:build !linux
+
+package fluent
+
+import "fmt"
+
+func setSocketOptions(_ string, _ uintptr, opts SocketOptions) error {
+	if opts.UserTimeout > 0 {
+		return fmt.Errorf("%w: TCP_USER_TIMEOUT", ErrUnsupportedSocketOption)
+	}
+
+	if opts.TOS != 0 {
+		return fmt.Errorf("%w: IP_TOS", ErrUnsupportedSocketOption)
+	}
+
+	return nil
+}
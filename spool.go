@@ -0,0 +1,111 @@
+package fluent
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+)
+
+// SpoolToDisk сохраняет тела успешных ответов больше threshold байт во временный файл в каталоге dir
+// (по умолчанию os.TempDir()) вместо памяти, защищая воркеры с ограниченной памятью при обработке
+// больших выгрузок. Тела не больше threshold читаются в память. Тело читается целиком до возврата
+// из Get, Post и Do и доступно как io.ReadSeekCloser через Response.Seekable; временный файл удаляется
+// при закрытии тела. Значение threshold меньше 0 отключает сохранение.
+func (c *Client) SpoolToDisk(threshold int64, dir string) *Client {
+	c.spool = nil
+	if threshold >= 0 {
+		c.spool = &spooler{threshold: threshold, dir: dir}
+	}
+
+	return c
+}
+
+// Seekable возвращает тело ответа как io.ReadSeekCloser, например для повторного чтения или загрузки
+// по частям. Если у клиента задан SpoolToDisk, возвращается уже сохраненное тело, иначе тело
+// предварительно читается в память. Вызовите Close самостоятельно.
+func (r *Response) Seekable() (io.ReadSeekCloser, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	if rs, ok := r.resp.Body.(io.ReadSeekCloser); ok {
+		return rs, nil
+	}
+
+	data, err := r.Raw()
+	if err != nil {
+		return nil, err
+	}
+
+	return memoryBody{bytes.NewReader(data)}, nil
+}
+
+// spooler читает тело ответа в память или, если оно больше threshold, во временный файл.
+type spooler struct {
+	threshold int64
+	dir       string
+}
+
+func (s *spooler) spool(body io.ReadCloser) (io.ReadSeekCloser, error) {
+	defer body.Close()
+
+	var buf bytes.Buffer
+
+	n, err := io.CopyN(&buf, body, s.threshold+1)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	if n <= s.threshold {
+		return memoryBody{bytes.NewReader(buf.Bytes())}, nil
+	}
+
+	f, err := os.CreateTemp(s.dir, "fluent-spool-*")
+	if err != nil {
+		return nil, err
+	}
+
+	file := &fileBody{File: f}
+
+	if _, err := buf.WriteTo(f); err != nil {
+		file.Close()
+
+		return nil, err
+	}
+
+	if _, err := io.Copy(f, body); err != nil {
+		file.Close()
+
+		return nil, err
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		file.Close()
+
+		return nil, err
+	}
+
+	return file, nil
+}
+
+// memoryBody — тело в памяти с поддержкой Seek.
+type memoryBody struct {
+	*bytes.Reader
+}
+
+func (memoryBody) Close() error { return nil }
+
+// fileBody — тело во временном файле, который удаляется при закрытии.
+type fileBody struct {
+	*os.File
+}
+
+func (f *fileBody) Close() error {
+	err := f.File.Close()
+	if rmErr := os.Remove(f.Name()); rmErr != nil && err == nil {
+		err = rmErr
+	}
+
+	return err
+}
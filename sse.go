@@ -0,0 +1,161 @@
+package fluent
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultSSERetry — пауза перед переподключением к потоку событий, пока сервер не задал свою в поле retry.
+const DefaultSSERetry = 3 * time.Second
+
+// Event — событие Server-Sent Events.
+type Event struct {
+	// ID — идентификатор события из поля id. Сохраняется между событиями, пока сервер его не сменит.
+	ID string
+	// Type — тип события из поля event, по умолчанию "message".
+	Type string
+	// Data — данные события; строки нескольких полей data объединяются через "\n".
+	Data string
+}
+
+// Subscribe подписывается на поток Server-Sent Events (text/event-stream) по пути path и отправляет
+// события в ch, так же как Request.Subscribe. Клиент при этом не изменяется.
+func (c *Client) Subscribe(ctx context.Context, path string, ch chan<- Event) error {
+	return c.Request().Subscribe(ctx, path, ch)
+}
+
+// Subscribe подписывается на поток Server-Sent Events (text/event-stream) по пути path и отправляет
+// события в ch. Если соединение оборвалось или сервер закрыл поток, Subscribe переподключается
+// после паузы DefaultSSERetry (или из поля retry) и передает заголовок Last-Event-ID, чтобы сервер
+// продолжил поток с места обрыва. Subscribe блокируется до отмены ctx, ответа 204 No Content
+// (сервер просит не переподключаться, возвращается nil) или ответа не 2xx (возвращается HTTPError).
+// По завершении ch закрывается:
+//
+//	events := make(chan fluent.Event)
+//	go func() { errc <- c.Subscribe(ctx, "/stream", events) }()
+//	for e := range events { ... }
+func (r *Request) Subscribe(ctx context.Context, path string, ch chan<- Event) error {
+	defer close(ch)
+
+	s := &sseStream{retry: DefaultSSERetry}
+
+	for {
+		req := &Request{requestState: r.fork(), c: r.c}
+		req.SetHeader("Accept", "text/event-stream")
+		req.SetHeader("Cache-Control", "no-cache")
+
+		if s.lastID != "" {
+			req.SetHeader("Last-Event-ID", s.lastID)
+		}
+
+		resp := req.Get(ctx, path)
+
+		err := resp.Error()
+		if err == nil {
+			if resp.resp.StatusCode == http.StatusNoContent {
+				resp.resp.Body.Close()
+
+				return nil
+			}
+
+			err = s.read(ctx, resp.resp.Body, ch)
+		}
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if errors.Is(err, ErrNotOK) {
+			return err
+		}
+
+		timer := time.NewTimer(s.retry)
+
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+
+			return ctx.Err()
+		}
+	}
+}
+
+// sseStream хранит состояние потока событий между переподключениями.
+type sseStream struct {
+	lastID string
+	retry  time.Duration
+}
+
+// read разбирает поток text/event-stream из body и отправляет события в ch до конца потока или ошибки.
+func (s *sseStream) read(ctx context.Context, body io.ReadCloser, ch chan<- Event) error { //nolint:cyclop
+	defer body.Close()
+
+	var (
+		br        = bufio.NewReader(body)
+		eventType string
+		data      strings.Builder
+		hasData   bool
+	)
+
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			// Незавершенное событие в конце потока отбрасывается
+			return err
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+
+		if line == "" {
+			if hasData {
+				e := Event{ID: s.lastID, Type: eventType, Data: data.String()}
+				if e.Type == "" {
+					e.Type = "message"
+				}
+
+				select {
+				case ch <- e:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+
+			eventType, hasData = "", false
+			data.Reset()
+
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+
+		switch field {
+		case "":
+			// Комментарий
+		case "event":
+			eventType = value
+		case "data":
+			if hasData {
+				data.WriteByte('\n')
+			}
+
+			data.WriteString(value)
+			hasData = true
+		case "id":
+			if !strings.ContainsRune(value, 0) {
+				s.lastID = value
+			}
+		case "retry":
+			if ms, err := strconv.Atoi(value); err == nil && ms >= 0 {
+				s.retry = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package fluent
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultAdaptiveRate — начальная скорость AdaptiveLimiter, если Rate не задан, запросов в секунду.
+const DefaultAdaptiveRate = 10
+
+// AdaptiveLimiter — клиентский ограничитель скорости запросов, который подстраивается под ответы 429
+// по схеме AIMD: при 429 скорость умножается на Decrease, а при успешных ответах плавно растет на Increase
+// запросов в секунду за каждую секунду. Так клиент сам удерживается в неизвестных лимитах провайдера,
+// вместо того чтобы вслепую повторять запросы. Если 429 содержит Retry-After, запросы приостанавливаются
+// на указанное время.
+//
+// AdaptiveLimiter безопасен для конкурентного использования и может разделяться несколькими клиентами.
+type AdaptiveLimiter struct {
+	// Rate — начальная скорость, запросов в секунду. По умолчанию DefaultAdaptiveRate.
+	Rate float64
+	// Min и Max — границы скорости. По умолчанию Min = 0.1, Max = Rate. Max меньше Min поднимается до Min.
+	Min, Max float64
+	// Increase — рост скорости за секунду без 429, запросов в секунду. По умолчанию 1.
+	Increase float64
+	// Decrease — множитель скорости при 429. По умолчанию 0.5.
+	Decrease float64
+
+	once sync.Once
+	mu   sync.Mutex
+	rate float64
+	next time.Time
+}
+
+func (l *AdaptiveLimiter) init() {
+	l.once.Do(func() {
+		if l.Rate <= 0 {
+			l.Rate = DefaultAdaptiveRate
+		}
+
+		if l.Min <= 0 {
+			l.Min = 0.1
+		}
+
+		if l.Max <= 0 {
+			l.Max = l.Rate
+		}
+
+		l.Max = max(l.Max, l.Min)
+
+		if l.Increase <= 0 {
+			l.Increase = 1
+		}
+
+		if l.Decrease <= 0 || l.Decrease >= 1 {
+			l.Decrease = 0.5
+		}
+
+		l.rate = min(max(l.Rate, l.Min), l.Max)
+	})
+}
+
+// CurrentRate возвращает текущую скорость, запросов в секунду.
+func (l *AdaptiveLimiter) CurrentRate() float64 {
+	l.init()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.rate
+}
+
+// Wait ждет своей очереди на отправку запроса или отмены ctx.
+func (l *AdaptiveLimiter) Wait(ctx context.Context) error {
+	l.init()
+
+	l.mu.Lock()
+
+	now := time.Now()
+
+	slot := l.next
+	if slot.Before(now) {
+		slot = now
+	}
+
+	l.next = slot.Add(time.Duration(float64(time.Second) / l.rate))
+
+	l.mu.Unlock()
+
+	delay := slot.Sub(now)
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Observe подстраивает скорость по ответу: 429 снижает ее, остальные ответы плавно повышают.
+func (l *AdaptiveLimiter) Observe(resp *http.Response) {
+	l.init()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if resp.StatusCode != http.StatusTooManyRequests {
+		l.rate = min(l.rate+l.Increase/l.rate, l.Max)
+
+		return
+	}
+
+	l.rate = max(l.rate*l.Decrease, l.Min)
+
+	until, ok := retryAfter(resp.Header.Get("Retry-After"))
+	if ok && until.After(l.next) {
+		l.next = until
+	}
+}
+
+// retryAfter разбирает Retry-After в виде числа секунд или HTTP-date и возвращает момент, до которого нужно ждать.
+func retryAfter(value string) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+
+	if d, err := parseHeaderDuration(value); err == nil {
+		return time.Now().Add(d), true
+	}
+
+	if t, err := http.ParseTime(value); err == nil {
+		return t, true
+	}
+
+	return time.Time{}, false
+}
@@ -0,0 +1,51 @@
+package fluent
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// DefaultTimeout задает таймаут запросов, контекст которых не содержит дедлайна, чтобы забытый таймаут
+// не оставлял горутины висеть вечно. Дедлайн вызывающего кода всегда имеет приоритет.
+// Таймаут охватывает весь запрос, включая повторы и чтение тела ответа. d <= 0 выключает таймаут.
+// Действует на Get, Post и Do; для Transport используйте http.Client.Timeout.
+func (c *Client) DefaultTimeout(d time.Duration) *Client {
+	c.timeout = d
+
+	return c
+}
+
+// UsedDefaultTimeout сообщает, что запрос выполнялся с таймаутом DefaultTimeout,
+// потому что контекст вызывающего кода не содержал дедлайна.
+func (r *Response) UsedDefaultTimeout() bool {
+	return r.defaultTimeout
+}
+
+// withDefaultTimeout добавляет к ctx таймаут DefaultTimeout, если у ctx нет дедлайна.
+// Возвращает nil вместо cancel, если таймаут не добавлялся.
+func (c *Client) withDefaultTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.timeout <= 0 {
+		return ctx, nil
+	}
+
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, nil
+	}
+
+	return context.WithTimeout(ctx, c.timeout)
+}
+
+// cancelBody освобождает контекст запроса (DefaultTimeout, Op) при закрытии тела ответа.
+type cancelBody struct {
+	io.ReadCloser
+
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+
+	return err
+}
@@ -0,0 +1,127 @@
+package fluent
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// TraceInfo — разбивка времени запроса по этапам из net/http/httptrace. При повторах (RetryPolicy,
+// OnUnauthorized) этапы соединения относятся к последней попытке, а Total — ко всему запросу.
+type TraceInfo struct {
+	// DNSLookup — разрешение имени хоста.
+	DNSLookup time.Duration
+	// Connect — установка TCP-соединения.
+	Connect time.Duration
+	// TLSHandshake — TLS-рукопожатие.
+	TLSHandshake time.Duration
+	// ServerTime — от окончания отправки запроса до первого байта ответа.
+	ServerTime time.Duration
+	// TTFB — от начала запроса до первого байта ответа.
+	TTFB time.Duration
+	// Total — от начала запроса до получения заголовков ответа или ошибки, как Response.Duration.
+	Total time.Duration
+	// ConnReused сообщает, что использовано соединение из пула keep-alive.
+	ConnReused bool
+	// RemoteAddr — адрес сервера, с которым установлено соединение.
+	RemoteAddr string
+}
+
+// Duration возвращает время от начала запроса до получения заголовков ответа или ошибки, включая
+// повторы и middleware. Чтение тела не учитывается. Для запросов, не дошедших до отправки, возвращает 0.
+func (r *Response) Duration() time.Duration {
+	return r.TraceInfo().Total
+}
+
+// TraceInfo возвращает разбивку времени запроса по этапам: DNS, соединение, TLS и ожидание ответа,
+// чтобы разбираться с задержками отдельных вызовов.
+func (r *Response) TraceInfo() TraceInfo {
+	if r.trace == nil {
+		return TraceInfo{}
+	}
+
+	r.trace.mu.Lock()
+	defer r.trace.mu.Unlock()
+
+	return r.trace.info
+}
+
+// tracer собирает TraceInfo запроса. Хуки httptrace могут вызываться из других горутин.
+type tracer struct {
+	mu        sync.Mutex
+	start     time.Time
+	dnsStart  time.Time
+	connStart time.Time
+	tlsStart  time.Time
+	wrote     time.Time
+	info      TraceInfo
+}
+
+// withTrace возвращает контекст с хуками httptrace, которые заполняют tracer.
+func withTrace(ctx context.Context) (context.Context, *tracer) {
+	t := &tracer{start: time.Now()}
+
+	trace := &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { t.mark(&t.dnsStart) },
+		DNSDone:  func(httptrace.DNSDoneInfo) { t.since(&t.info.DNSLookup, &t.dnsStart) },
+		ConnectStart: func(string, string) {
+			t.mark(&t.connStart)
+		},
+		ConnectDone: func(string, string, error) {
+			t.since(&t.info.Connect, &t.connStart)
+		},
+		TLSHandshakeStart: func() { t.mark(&t.tlsStart) },
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.since(&t.info.TLSHandshake, &t.tlsStart)
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+
+			t.info.ConnReused = info.Reused
+			if info.Conn != nil {
+				t.info.RemoteAddr = info.Conn.RemoteAddr().String()
+			}
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) { t.mark(&t.wrote) },
+		GotFirstResponseByte: func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+
+			now := time.Now()
+			t.info.TTFB = now.Sub(t.start)
+
+			if !t.wrote.IsZero() {
+				t.info.ServerTime = now.Sub(t.wrote)
+			}
+		},
+	}
+
+	return httptrace.WithClientTrace(ctx, trace), t
+}
+
+// done фиксирует общее время запроса.
+func (t *tracer) done() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.info.Total = time.Since(t.start)
+}
+
+func (t *tracer) mark(at *time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	*at = time.Now()
+}
+
+func (t *tracer) since(d *time.Duration, start *time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !start.IsZero() {
+		*d = time.Since(*start)
+	}
+}
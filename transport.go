@@ -0,0 +1,200 @@
+package fluent
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Transport возвращает http.RoundTripper, который применяет к любому запросу настройки клиента:
+// заголовки и query-параметры, защиту и сжатие тела, middleware, повторы, ограничения, кэши и Decrypt.
+// Это позволяет использовать возможности fluent в коде и сторонних SDK, которые принимают *http.Client:
+//
+//	sdk := github.NewClient(&http.Client{Transport: c.Transport()})
+//
+// Запрос уходит через транспорт http-клиента из HTTPClient (или через его метод Do, если это не *http.Client).
+// В отличие от Get и Post, ответы не 2xx не превращаются в ошибку, как и положено http.RoundTripper.
+// Транспорт читает настройки клиента при каждом запросе, поэтому не изменяйте клиент, пока транспорт используется.
+func (c *Client) Transport() http.RoundTripper {
+	return &transport{c: c}
+}
+
+// WrapTransport создает клиент, который отправляет запросы через существующий транспорт base,
+// например транспорт, предоставленный SDK. Вместе с Transport это позволяет наслоить возможности fluent
+// поверх чужого транспорта, не отказываясь от него:
+//
+//	c := fluent.WrapTransport(sdkTransport).Header("X-Tenant", tenant)
+//	sdk := github.NewClient(&http.Client{Transport: c.Transport()})
+//
+// Если base равен nil, используется http.DefaultTransport.
+func WrapTransport(base http.RoundTripper) *Client {
+	return New().HTTPClient(&http.Client{Transport: base})
+}
+
+type transport struct {
+	c *Client
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) { //nolint:cyclop
+	c := t.c
+
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	// RoundTripper не должен изменять исходный запрос
+	req = req.Clone(req.Context())
+
+	// Ключи копируются как есть, чтобы не потерять регистр заголовков из HeaderExact
+	for k, v := range c.headers {
+		req.Header[k] = append(req.Header[k], v...)
+	}
+
+	if len(c.params) != 0 || c.rawQuery != "" {
+		req.URL.RawQuery = c.encodeQuery(req.URL.RawQuery, c.ordered)
+	}
+
+	if c.closeConn {
+		req.Close = true
+	}
+
+	if (c.identity || c.compat.Identity) && req.Header.Get("Accept-Encoding") == "" {
+		req.Header.Set("Accept-Encoding", "identity")
+	}
+
+	c.costCenter.apply(req.Header)
+	c.authorize(req.Header)
+	c.applyLocale(req)
+
+	if len(c.accept) != 0 && req.Header.Get("Accept-Encoding") == "" {
+		req.Header.Set("Accept-Encoding", strings.Join(c.accept, ", "))
+	}
+
+	if (len(c.sealers) != 0 || c.encoding != "") && req.Body != nil && req.Body != http.NoBody {
+		if err := c.seal(req); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := c.compat.fixLength(req); err != nil {
+		return nil, err
+	}
+
+	if c.precheck != nil && !c.precheck(req) {
+		e := negativeEntry{statusCode: http.StatusNotFound, status: "404 Not Found", header: make(http.Header)}
+
+		return e.response(req), nil
+	}
+
+	negative := c.negative != nil && req.Method == http.MethodGet
+	if negative {
+		if e, ok := c.negative.get(req.URL.String()); ok {
+			return e.response(req), nil
+		}
+	}
+
+	resp, err := c.execute(roundTripperDoer{c.roundTripper()}, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if negative && c.negative.cacheable(req.Method, resp.StatusCode) {
+		if err := c.remember(req, resp); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.decrypter != nil && resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices {
+		if err := c.decrypt(resp); err != nil {
+			return nil, err
+		}
+	}
+
+	return resp, nil
+}
+
+// remember читает тело ответа для негативного кэша и подменяет его прочитанной копией.
+func (c *Client) remember(req *http.Request, resp *http.Response) error {
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	c.negative.put(req.URL.String(), resp, body, req.Header.Get(c.requestIDHeader()))
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	return nil
+}
+
+// seal применяет к телу запроса цепочку Seal и ContentEncoding и заменяет его результатом.
+func (c *Client) seal(req *http.Request) error {
+	b, err := io.ReadAll(req.Body)
+	req.Body.Close()
+
+	if err != nil {
+		return err
+	}
+
+	for _, s := range c.sealers {
+		if b, err = s.Seal(b, req.Header); err != nil {
+			return err
+		}
+	}
+
+	if c.encoding != "" {
+		if b, err = compress(c.encoding, b); err != nil {
+			return err
+		}
+
+		req.Header.Set("Content-Encoding", c.encoding)
+	}
+
+	req.Body = io.NopCloser(bytes.NewReader(b))
+	req.ContentLength = int64(len(b))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(b)), nil
+	}
+
+	return nil
+}
+
+// roundTripper возвращает транспорт, через который запросы уходят в сеть.
+func (c *Client) roundTripper() http.RoundTripper {
+	hc, ok := c.client.(*http.Client)
+	if !ok {
+		return doerTransport{c.client}
+	}
+
+	if hc.Transport != nil {
+		return hc.Transport
+	}
+
+	return http.DefaultTransport
+}
+
+// roundTripperDoer адаптирует http.RoundTripper к интерфейсу httpClient.
+type roundTripperDoer struct {
+	rt http.RoundTripper
+}
+
+func (d roundTripperDoer) Do(req *http.Request) (*http.Response, error) {
+	return d.rt.RoundTrip(req)
+}
+
+func (d roundTripperDoer) CloseIdleConnections() {
+	if ic, ok := d.rt.(idleCloser); ok {
+		ic.CloseIdleConnections()
+	}
+}
+
+// doerTransport адаптирует httpClient к интерфейсу http.RoundTripper.
+type doerTransport struct {
+	client httpClient
+}
+
+func (d doerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return d.client.Do(req)
+}
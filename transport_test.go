@@ -0,0 +1,276 @@
+package fluent_test
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/devem-tech/fluent"
+)
+
+func TestClient_Transport(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" || r.URL.Query().Get("api-version") != "2" {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		_, _ = w.Write([]byte(jweA3))
+	}))
+	t.Cleanup(srv.Close)
+
+	c := fluent.New().
+		Header("Authorization", "Bearer token").
+		Query("api-version", "2").
+		Decrypt(&fluent.JWE{Key: jweA3Key})
+
+	sdk := &http.Client{Transport: c.Transport()}
+
+	resp, err := sdk.Get(srv.URL + "/repos?page=1")
+	if err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "Live long and prosper." {
+		t.Fatalf("unexpected body: %q", body)
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestWrapTransport(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Sdk") != "1" || r.Header.Get("X-Tenant") != "acme" {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	sdkTransport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		req.Header.Set("X-Sdk", "1")
+
+		return http.DefaultTransport.RoundTrip(req)
+	})
+
+	c := fluent.WrapTransport(sdkTransport).Header("X-Tenant", "acme")
+
+	resp, err := (&http.Client{Transport: c.Transport()}).Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+
+	if err := c.Get(context.Background(), srv.URL).Error(); err != nil {
+		t.Fatalf("fluent Get returned error: %v", err)
+	}
+}
+
+// flakyKeepAlive имитирует транспорт, у которого первые переиспользованные соединения (одно, если failures
+// не задан) оказались разорваны.
+type flakyKeepAlive struct {
+	calls    atomic.Int32
+	failures int32
+}
+
+func (f *flakyKeepAlive) RoundTrip(req *http.Request) (*http.Response, error) {
+	if f.calls.Add(1) <= max(f.failures, 1) {
+		if trace := httptrace.ContextClientTrace(req.Context()); trace != nil && trace.GotConn != nil {
+			trace.GotConn(httptrace.GotConnInfo{Reused: true})
+		}
+
+		return nil, io.EOF
+	}
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Status:     "200 OK",
+		Header:     make(http.Header),
+		Body:       io.NopCloser(strings.NewReader("ok")),
+		Request:    req,
+	}, nil
+}
+
+func TestClient_RetryOnReset(t *testing.T) {
+	t.Parallel()
+
+	for _, enabled := range []bool{true, false} {
+		rt := &flakyKeepAlive{}
+
+		err := fluent.New().
+			HTTPClient(&http.Client{Transport: rt}).
+			RetryOnReset(enabled).
+			Body(map[string]any{"name": "x"}).
+			Do(context.Background(), http.MethodPut, "http://example.test/items/1").
+			Error()
+
+		if enabled && (err != nil || rt.calls.Load() != 2) {
+			t.Fatalf("expected a single retry, got calls=%d err=%v", rt.calls.Load(), err)
+		}
+
+		if !enabled && (err == nil || rt.calls.Load() != 1) {
+			t.Fatalf("expected no retry, got calls=%d err=%v", rt.calls.Load(), err)
+		}
+	}
+
+	rt := &flakyKeepAlive{}
+	if err := fluent.New().HTTPClient(&http.Client{Transport: rt}).Get(context.Background(), "http://example.test/").Error(); err == nil ||
+		rt.calls.Load() != 1 {
+		t.Fatalf("expected no retry by default, got calls=%d err=%v", rt.calls.Load(), err)
+	}
+
+	rt = &flakyKeepAlive{failures: 10}
+
+	err := fluent.New().
+		HTTPClient(&http.Client{Transport: rt}).
+		RetryOnReset(true).
+		RetryPolicy(fluent.RetryPolicy{MaxRetries: 2, MinBackoff: time.Millisecond}).
+		Get(context.Background(), "http://example.test/").
+		Error()
+	if err == nil || rt.calls.Load() != 3 {
+		t.Fatalf("expected the reset retry to count against MaxRetries (3 calls), got calls=%d err=%v", rt.calls.Load(), err)
+	}
+}
+
+func TestNewTransport_SocketOptions(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	t.Cleanup(srv.Close)
+
+	tr := fluent.NewTransport(fluent.SocketOptions{
+		KeepAlive:   net.KeepAliveConfig{Enable: true, Idle: 30 * time.Second, Interval: 10 * time.Second, Count: 3},
+		UserTimeout: 20 * time.Second,
+		TOS:         0x28 << 2,
+	})
+	t.Cleanup(tr.CloseIdleConnections)
+
+	err := fluent.New().HTTPClient(&http.Client{Transport: tr}).Get(context.Background(), srv.URL).Error()
+	if runtime.GOOS != "linux" {
+		if !errors.Is(err, fluent.ErrUnsupportedSocketOption) {
+			t.Fatalf("expected ErrUnsupportedSocketOption, got: %v", err)
+		}
+
+		return
+	}
+
+	if err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+}
+
+func TestClient_Compat(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/truncated" {
+			conn, buf, _ := http.NewResponseController(w).Hijack()
+			_, _ = buf.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 10\r\n\r\nhello")
+			_ = buf.Flush()
+			_ = conn.Close()
+
+			return
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		_, _ = io.WriteString(w, strings.Join([]string{
+			r.Proto, strings.Join(r.TransferEncoding, ","), r.Header.Get("Accept-Encoding"), string(body),
+		}, "|"))
+	}))
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+
+	ctx := context.Background()
+	modern := fluent.New().BaseURL(srv.URL).HTTPClient(srv.Client())
+	compat := fluent.New().BaseURL(srv.URL).HTTPClient(srv.Client()).
+		Compat(fluent.Compat{HTTP1: true, ContentLength: true, Identity: true, TolerateTruncated: true})
+
+	body := func() io.Reader { return io.MultiReader(strings.NewReader("a"), strings.NewReader("b")) }
+
+	if got, _ := modern.Request().BodyReader(body(), "text/plain").Post(ctx, "/").Text(); !strings.HasPrefix(got, "HTTP/2.0|") {
+		t.Fatalf("expected HTTP/2 without Compat, got %q", got)
+	}
+
+	if got, err := compat.Request().BodyReader(body(), "text/plain").Post(ctx, "/").Text(); err != nil || got != "HTTP/1.1||identity|ab" {
+		t.Fatalf("expected HTTP/1.1 with Content-Length and identity, got %q: %v", got, err)
+	}
+
+	http1 := fluent.New().BaseURL(srv.URL).HTTPClient(srv.Client()).Compat(fluent.Compat{HTTP1: true})
+	if _, err := http1.Get(ctx, "/truncated").Raw(); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("expected io.ErrUnexpectedEOF without TolerateTruncated, got %v", err)
+	}
+
+	if got, err := compat.Get(ctx, "/truncated").Text(); err != nil || got != "hello" {
+		t.Fatalf("expected truncated body, got %q: %v", got, err)
+	}
+}
+
+func TestClient_Compression(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Encoding") != "gzip" || r.Header.Get("Accept-Encoding") != "deflate" {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		body, _ := io.ReadAll(zr)
+
+		w.Header().Set("Content-Encoding", "deflate")
+
+		zw := zlib.NewWriter(w)
+		_, _ = zw.Write(body)
+		_ = zw.Close()
+	}))
+	t.Cleanup(srv.Close)
+
+	got, err := fluent.New().
+		ContentEncoding("gzip").
+		AcceptEncoding("deflate").
+		Body(map[string]any{"id": 1}).
+		Post(context.Background(), srv.URL).
+		Raw()
+	if err != nil {
+		t.Fatalf("Post returned error: %v", err)
+	}
+
+	if string(got) != `{"id":1}` {
+		t.Fatalf("unexpected echo: %q", got)
+	}
+}
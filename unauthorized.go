@@ -0,0 +1,40 @@
+package fluent
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+// OnUnauthorized задает функцию, которая вызывается один раз на запрос, если сервер ответил 401:
+// например, заново входит в систему или ротирует ключ. После ее успешного выполнения запрос собирается
+// заново и повторяется; ошибка функции возвращается из запроса. Новые учетные данные должны попадать
+// в запрос при его сборке — через BearerTokenFunc, Sign или middleware. Потоковое тело BodyReader
+// повторить нельзя, такие запросы возвращают исходный ответ 401.
+// Действует на Get, Post и Do; для OAuth2 см. пакет auth.
+func (c *Client) OnUnauthorized(refresh func(ctx context.Context) error) *Client {
+	c.relogin = refresh
+
+	return c
+}
+
+// reauth обновляет учетные данные через OnUnauthorized и повторяет запрос.
+func (c *Client) reauth(client httpClient, req *http.Request, r *requestState, resp *http.Response) (*http.Response, error) {
+	if raw, ok := r.body.(rawBody); ok && raw.reader != nil {
+		return resp, nil
+	}
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	if err := c.relogin(req.Context()); err != nil {
+		return nil, err
+	}
+
+	retry, err := c.newRequest(req.Context(), req.Method, req.URL.String(), r)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.execute(client, retry)
+}
@@ -0,0 +1,38 @@
+package fluent
+
+import "encoding/xml"
+
+// xmlBody — тело запроса, которое сериализуется в XML.
+type xmlBody struct {
+	v any
+}
+
+// BodyXML задает тело запроса, которое будет сериализовано в XML (с заголовком <?xml ...?>)
+// и отправлено с Content-Type: application/xml. Подходит для SOAP и устаревших XML API.
+func (c *Client) BodyXML(body any) *Client {
+	c.body = xmlBody{v: body}
+
+	return c
+}
+
+// BodyXML задает тело запроса в XML, так же как Client.BodyXML.
+func (r *Request) BodyXML(body any) *Request {
+	r.body = xmlBody{v: body}
+
+	return r
+}
+
+// IntoXML декодирует тело ответа из XML в значение типа T с помощью encoding/xml.
+// Тело ответа автоматически закрывается.
+func IntoXML[T any](r *Response) (T, error) {
+	var res T
+
+	if r.err != nil {
+		return res, r.err
+	}
+	defer r.resp.Body.Close()
+
+	err := xml.NewDecoder(r.resp.Body).Decode(&res)
+
+	return res, err
+}